@@ -2,68 +2,111 @@ package command
 
 import (
 	"context"
-	"fmt"
+	"github.com/devlibx/go-template-project/config"
+	"github.com/devlibx/go-template-project/internal/handler"
 	"github.com/devlibx/go-template-project/pkg/base"
 	jsonplaceholderClient "github.com/devlibx/go-template-project/pkg/clients/jsonplaceholder"
+	"github.com/devlibx/go-template-project/pkg/infra/admin"
+	"github.com/devlibx/go-template-project/pkg/infra/auth"
+	"github.com/devlibx/go-template-project/pkg/infra/capture"
 	"github.com/devlibx/go-template-project/pkg/infra/database"
+	"github.com/devlibx/go-template-project/pkg/infra/health"
+	"github.com/devlibx/go-template-project/pkg/infra/httpserver"
+	"github.com/devlibx/go-template-project/pkg/infra/idempotency"
 	consumers "github.com/devlibx/go-template-project/pkg/infra/messaging"
+	"github.com/devlibx/go-template-project/pkg/infra/ratelimit"
+	"github.com/devlibx/go-template-project/pkg/infra/requestid"
+	"github.com/devlibx/go-template-project/pkg/infra/shutdown"
 	"github.com/devlibx/go-template-project/pkg/service"
 	"github.com/devlibx/gox-base/v2"
 	"github.com/devlibx/gox-base/v2/errors"
 	"github.com/devlibx/gox-base/v2/metrics"
-	goxServer "github.com/devlibx/gox-base/v2/server"
-	"github.com/devlibx/gox-base/v2/server/common"
-	goxHttpApi "github.com/devlibx/gox-http/v4/api"
 	statsCommon "github.com/devlibx/gox-metrics/v2/common"
 	goxCadence "github.com/devlibx/gox-workfkow/workflow/framework/cadence"
 	"go.uber.org/fx"
 	"go.uber.org/zap"
 	"log/slog"
+	"net"
 	"time"
 )
 
-func AppMain(ctx context.Context, appConfig *ApplicationConfig, applicationContext *base.ApplicationContext) error {
+// AppMain starts the application. It is stopped when the context is done - the returned channel is closed once all
+// shutdown stages are done
+func AppMain(ctx context.Context, reloader *ConfigReloader, applicationContext *base.ApplicationContext) (<-chan struct{}, error) {
+	appConfig := reloader.Current()
 	appConfig.SetDefaults()
 
-	var sh goxServer.ServerShutdownHook
 	var serverSignal *ServerSignal
+	var adminServer *admin.Server
 	app := fx.New(
 		// Supplied arguments
 		fx.Supply(appConfig),
+		fx.Supply(reloader),
 		fx.Supply(appConfig.App),
 		fx.Supply(appConfig.HttpConfig),
 		fx.Supply(appConfig.MetricConfig),
 		fx.Supply(appConfig.MessagingConfig),
 		fx.Supply(appConfig.RequestResponseSecurityConfig),
 		fx.Supply(appConfig.CadenceConfig),
+		fx.Supply(appConfig.Health),
+		fx.Supply(appConfig.Server),
+		fx.Supply(appConfig.Shutdown),
+		fx.Supply(appConfig.Admin),
+		fx.Supply(appConfig.ClientAuth),
+		fx.Supply(appConfig.JwtAuth),
+		fx.Supply(appConfig.RateLimit),
+		fx.Supply(appConfig.AccessLog),
+		fx.Supply(appConfig.RequestCapture),
+		fx.Supply(appConfig.Idempotency),
 		fx.Supply(appConfig.OrdersRoMysqlConfig, appConfig.OrdersMysqlConfig),
 
 		// Common generics dependencies
 		fx.Provide(newCrossFunctionProvider),
 		fx.Provide(statsCommon.NewMetricService),
-		fx.Provide(common.NewServer),
-		fx.Provide(goxServer.NewFxServerShutdownHook),
-		fx.Provide(goxHttpApi.NewGoxHttpContext),
+		fx.Provide(httpserver.NewServer),
+		fx.Provide(shutdown.NewCoordinator),
+		fx.Provide(admin.NewServer),
+		fx.Provide(ratelimit.NewLimiter),
+		fx.Provide(newGoxHttpContext),
 		fx.Provide(goxCadence.NewCadenceClient),
 		fx.Provide(consumers.NewMessagingFactory),
+		fx.Provide(NewRequestResponseSecurityConfigHolder),
+		fx.Provide(newAccessLogger),
+		fx.Provide(newCapturer),
 
 		// Services
 		service.Provider,
 		database.Provider,
+		health.Provider,
+		idempotency.Provider,
 
 		// Clients
 		jsonplaceholderClient.Provider,
 
+		// Handlers, and the authenticators used by their route groups
+		handler.Provider,
+		auth.Provider,
+
 		// Invoke - these will execute before app starts
+		fx.Invoke(newAdminEntryPoint),
+		fx.Invoke(requestid.SetupGoxHttp),
 		fx.Invoke(newApplicationEntryPoint),
-		fx.Invoke(postApplicationSeverStart),
 		fx.Invoke(consumers.NewMessagingFactoryLifecycle),
 		fx.Invoke(goxCadence.NewCadenceWorkflowApiInvokerAtBoot),
+		fx.Invoke(setupConfigReload),
+		fx.Invoke(registerServerShutdown),
+		fx.Invoke(registerWorkflowShutdown),
+		fx.Invoke(consumers.NewMessagingShutdown),
+		fx.Invoke(capture.NewCapturerLifecycle),
+
+		// Must be the last lifecycle hook - it is stopped first, and runs all shutdown stages in order
+		fx.Invoke(shutdown.NewCoordinatorLifecycle),
 
 		// This is a server signal which is sent when server is started
-		fx.Provide(func() *ServerSignal { return &ServerSignal{StartedCh: make(chan error, 5)} }),
-		fx.Populate(&sh, &serverSignal),
+		fx.Provide(func() *ServerSignal { return &ServerSignal{StartedCh: make(chan ServerStarted, 1)} }),
+		fx.Populate(&serverSignal),
 
+		fx.Populate(&adminServer),
 		fx.Populate(
 			&applicationContext.GoxHttpContext,
 			&applicationContext.OrdersDataStore,
@@ -72,94 +115,114 @@ func AppMain(ctx context.Context, appConfig *ApplicationConfig, applicationConte
 
 	err := app.Start(ctx)
 	if err != nil {
-		return errors.Wrap(err, "failed to start server - app.Start() failed")
+		return nil, errors.Wrap(err, "failed to start server - app.Start() failed")
 	}
 
-	serverSignalError := <-serverSignal.StartedCh
-	if serverSignalError != nil {
-		return errors.Wrap(serverSignalError, "failed to start server")
+	started := <-serverSignal.StartedCh
+	if started.Err != nil {
+		return nil, errors.Wrap(started.Err, "failed to start server")
+	}
+	applicationContext.ServerAddr = started.Addr
+	if applicationContext.AdminAddr, err = adminServer.Listen(); err != nil {
+		return nil, errors.Wrap(err, "failed to get admin server address")
 	}
 
-	sh.Setup(app)
-	return nil
+	stopped := make(chan struct{})
+	go func() {
+		defer close(stopped)
+		<-ctx.Done()
+		if err := app.Stop(context.Background()); err != nil {
+			slog.Error("failed to stop application", "error", err)
+		}
+	}()
+	return stopped, nil
 }
 
-func newCrossFunctionProvider(appConfig *ApplicationConfig, metric metrics.Scope) (gox.CrossFunction, metrics.Publisher, error) {
+// newCrossFunctionProvider also gives the log level, so it can be changed at runtime on config reload. Every log line
+// goes through config.Redact, so a secret value never reaches the logs
+func newCrossFunctionProvider(appConfig *ApplicationConfig, metric metrics.Scope) (gox.CrossFunction, metrics.Publisher, zap.AtomicLevel, error) {
 	env := appConfig.App.Environment
 	var loggerConfig zap.Config
 	if env == "prod" {
 		loggerConfig = zap.NewProductionConfig()
+		loggerConfig.Encoding = config.RedactedEncoding
 	} else {
 		loggerConfig = zap.NewDevelopmentConfig()
+		loggerConfig.Encoding = config.RedactedConsoleEncoding
 	}
-	loggerConfig.Level = zap.NewAtomicLevelAt(zap.DebugLevel)
+	level, err := zap.ParseAtomicLevel(appConfig.Logger.LogLevel)
+	if err != nil {
+		return nil, nil, level, errors.Wrap(err, "failed to parse log level: level=%s", appConfig.Logger.LogLevel)
+	}
+	loggerConfig.Level = level
+	config.RegisterRedactingEncoders()
 	logger, _ := loggerConfig.Build()
 
 	// Build metric publisher
 	publisher, err := consumers.NewMetricPublisher(appConfig.MessagingConfig, logger)
 	if err != nil {
-		return nil, nil, err
+		return nil, nil, level, err
 	}
 
-	return gox.NewCrossFunction(logger, metric, publisher), publisher, nil
+	return gox.NewCrossFunction(logger, metric, publisher), publisher, level, nil
 }
 
 type None string
 
-// newApplicationEntryPoint is the main entry point function - which will start the server
+// newApplicationEntryPoint is the main entry point function - which will start the server. Startup is done once the
+// listener is bound and accepts connections, and it fails if this does not happen in server.startup_timeout_ms
 func newApplicationEntryPoint(lc fx.Lifecycle, serverImpl ServerImpl, serverSignal *ServerSignal) None {
 	lc.Append(
 		fx.Hook{
-			OnStart: func(context.Context) error {
+			OnStart: func(ctx context.Context) error {
 
 				// Setup routes
-				serverImpl.routes()
-
-				// Start server
-				ch := make(chan error, 2)
-				go func() {
-					err := serverImpl.Start()
-					ch <- err
-				}()
-
-				// Wait for server for 2 sec - in case of error we will stop
-				select {
-				case <-time.After(2 * time.Second):
-					serverSignal.StartedCh <- nil
+				if err := serverImpl.routes(); err != nil {
+					serverSignal.StartedCh <- ServerStarted{Err: err}
 					close(serverSignal.StartedCh)
-					slog.Info("Server started...")
-					return nil
-				case e := <-ch:
-					if e != nil {
-						serverSignal.StartedCh <- e
-						close(serverSignal.StartedCh)
-						slog.Error("Server failed to start...", "error", e)
-						return e
-					} else {
-						serverSignal.StartedCh <- nil
-						close(serverSignal.StartedCh)
-						slog.Info("Server started...")
-						return nil
-					}
+					return errors.Wrap(err, "failed to setup routes")
 				}
-			},
-			OnStop: func(ctx context.Context) error {
-				fmt.Println("Calling stop")
-				<-serverImpl.Stop()
+
+				addr, err := startServer(ctx, serverImpl, serverImpl.ServerConfig)
+				serverSignal.StartedCh <- ServerStarted{Addr: addr, Err: err}
+				close(serverSignal.StartedCh)
+				if err != nil {
+					slog.Error("Server failed to start...", "error", err)
+					return err
+				}
+
+				serverImpl.HealthRegistry.MarkReady(true)
+				slog.Info("Server started...", "address", addr.String())
 				return nil
 			},
+
+			// No OnStop - the server is drained once, in the http_drain shutdown stage (see registerServerShutdown)
 		},
 	)
 	return ""
 }
 
-func postApplicationSeverStart(lc fx.Lifecycle) {
+// startServer starts the server, it fails if the server does not serve in server.startup_timeout_ms
+func startServer(ctx context.Context, server httpserver.Server, serverConfig *ServerConfig) (net.Addr, error) {
+	ctx, cancel := context.WithTimeout(ctx, time.Duration(serverConfig.StartupTimeoutMs)*time.Millisecond)
+	defer cancel()
+	return httpserver.StartServer(ctx, server)
+}
+
+// newAdminEntryPoint starts the admin server before the application server, so health and metrics are available
+// during startup. It is stopped after all shutdown stages
+func newAdminEntryPoint(lc fx.Lifecycle, adminServer *admin.Server, serverConfig *ServerConfig) {
 	lc.Append(fx.Hook{
 		OnStart: func(ctx context.Context) error {
+			addr, err := startServer(ctx, adminServer, serverConfig)
+			if err != nil {
+				return errors.Wrap(err, "failed to start admin server")
+			}
+			slog.Info("Admin server started...", "address", addr.String())
 			return nil
 		},
 		OnStop: func(ctx context.Context) error {
-			fmt.Println("Calling server stop")
+			<-adminServer.Stop()
 			return nil
 		},
 	})
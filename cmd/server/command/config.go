@@ -1,8 +1,16 @@
 package command
 
 import (
+	"github.com/devlibx/go-template-project/pkg/infra/accesslog"
+	"github.com/devlibx/go-template-project/pkg/infra/admin"
+	"github.com/devlibx/go-template-project/pkg/infra/auth"
+	"github.com/devlibx/go-template-project/pkg/infra/capture"
 	orderRoDataStore "github.com/devlibx/go-template-project/pkg/infra/database/mysql/user/ro"
 	ordersDataStore "github.com/devlibx/go-template-project/pkg/infra/database/mysql/user/rw"
+	"github.com/devlibx/go-template-project/pkg/infra/health"
+	"github.com/devlibx/go-template-project/pkg/infra/idempotency"
+	"github.com/devlibx/go-template-project/pkg/infra/ratelimit"
+	"github.com/devlibx/go-template-project/pkg/infra/shutdown"
 	goxBaseConfig "github.com/devlibx/gox-base/v2/config"
 	goxBaseMetrics "github.com/devlibx/gox-base/v2/metrics"
 	goxHttpApi "github.com/devlibx/gox-http/v4/api"
@@ -13,6 +21,18 @@ import (
 
 type ApplicationConfig struct {
 	App                           *goxBaseConfig.App                        `yaml:"app"`
+	Logger                        *goxBaseConfig.Logger                     `yaml:"logger"`
+	ConfigReload                  *ConfigReloadConfig                       `yaml:"config_reload"`
+	Server                        *ServerConfig                             `yaml:"server"`
+	Shutdown                      *shutdown.Config                          `yaml:"shutdown"`
+	Admin                         *admin.Config                             `yaml:"admin"`
+	Health                        *health.Config                            `yaml:"health"`
+	ClientAuth                    *auth.ClientAuthConfig                    `yaml:"client_auth"`
+	JwtAuth                       *auth.JwtAuthConfig                       `yaml:"jwt_auth"`
+	RateLimit                     *ratelimit.Config                         `yaml:"rate_limit"`
+	AccessLog                     *accesslog.Config                         `yaml:"access_log"`
+	RequestCapture                *capture.Config                           `yaml:"request_capture"`
+	Idempotency                   *idempotency.Config                       `yaml:"idempotency"`
 	MetricConfig                  *goxBaseMetrics.Config                    `yaml:"metric"`
 	HttpConfig                    *goxHttp.Config                           `yaml:"server_config"`
 	MessagingConfig               *goxMessaging.Configuration               `yaml:"messaging_config"`
@@ -23,7 +43,67 @@ type ApplicationConfig struct {
 	OrdersRoMysqlConfig *orderRoDataStore.MySqlConfig `yaml:"orders_ro_mysql_config"`
 }
 
+// ConfigReloadConfig controls how often external config files are checked for changes
+type ConfigReloadConfig struct {
+	IntervalMs int `yaml:"interval_ms"`
+}
+
+// ServerConfig controls the lifecycle of the http server
+type ServerConfig struct {
+	// StartupTimeoutMs is the max time to wait for the server to accept connections
+	StartupTimeoutMs int `yaml:"startup_timeout_ms"`
+
+	// TrustedProxies are the IPs or CIDRs of the load balancers in front of the server. The client IP is only read from
+	// X-Forwarded-For / X-Real-IP of requests which come from them - with none, it is the remote address of the request
+	TrustedProxies []string `yaml:"trusted_proxies"`
+}
+
 func (a *ApplicationConfig) SetDefaults() {
+	if a.Logger == nil || a.Logger.LogLevel == "" {
+		a.Logger = &goxBaseConfig.Logger{LogLevel: "debug"}
+	}
+	if a.ConfigReload == nil || a.ConfigReload.IntervalMs <= 0 {
+		a.ConfigReload = &ConfigReloadConfig{IntervalMs: 5000}
+	}
+	if a.Server == nil {
+		a.Server = &ServerConfig{}
+	}
+	if a.Server.StartupTimeoutMs <= 0 {
+		a.Server.StartupTimeoutMs = 10000
+	}
+	if a.Shutdown == nil {
+		a.Shutdown = &shutdown.Config{}
+	}
+	a.Shutdown.SetupDefaults()
+	if a.Admin == nil {
+		a.Admin = &admin.Config{}
+	}
+	if a.Health == nil {
+		a.Health = &health.Config{}
+	}
+	a.Health.SetupDefaults()
+	if a.ClientAuth == nil {
+		a.ClientAuth = &auth.ClientAuthConfig{}
+	}
+	a.ClientAuth.SetupDefaults()
+	if a.JwtAuth == nil {
+		a.JwtAuth = &auth.JwtAuthConfig{}
+	}
+	a.JwtAuth.SetupDefaults()
+	if a.RateLimit == nil {
+		a.RateLimit = &ratelimit.Config{}
+	}
+	if a.AccessLog == nil {
+		a.AccessLog = &accesslog.Config{}
+	}
+	if a.RequestCapture == nil {
+		a.RequestCapture = &capture.Config{}
+	}
+	a.RequestCapture.SetupDefaults()
+	if a.Idempotency == nil {
+		a.Idempotency = &idempotency.Config{}
+	}
+	a.Idempotency.SetupDefaults()
 	if a.CadenceConfig == nil {
 		a.CadenceConfig = &cadenceConfig.Config{Disabled: true}
 	}
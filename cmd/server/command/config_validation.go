@@ -0,0 +1,316 @@
+package command
+
+import (
+	"encoding/hex"
+	"fmt"
+	"github.com/devlibx/go-template-project/config"
+	"github.com/devlibx/go-template-project/pkg/infra/auth"
+	"github.com/devlibx/go-template-project/pkg/infra/database"
+	"github.com/devlibx/go-template-project/pkg/infra/idempotency"
+	"github.com/devlibx/go-template-project/pkg/infra/ratelimit"
+	"github.com/devlibx/go-template-project/pkg/infra/shutdown"
+	"github.com/golang-jwt/jwt/v5"
+	"go.uber.org/zap/zapcore"
+	"net"
+	"net/url"
+	"strings"
+)
+
+// Validate checks all sections of the application config and reports all problems in a single error
+func (a *ApplicationConfig) Validate() error {
+	errs := &config.ValidationErrors{}
+
+	a.validateApp(errs)
+	a.validateServer(errs)
+	a.validateAdmin(errs)
+	a.validateClientAuth(errs)
+	a.validateJwtAuth(errs)
+	a.validateRateLimit(errs)
+	a.validateAccessLog(errs)
+	a.validateRequestCapture(errs)
+	a.validateIdempotency(errs)
+	a.validateLogger(errs)
+	a.validateMetric(errs)
+	a.validateHttp(errs)
+	a.validateMessaging(errs)
+	a.validateCadence(errs)
+
+	if a.OrdersMysqlConfig == nil {
+		errs.Add("orders_mysql_config", "section is missing")
+	} else {
+		validateMySqlConfig(errs, "orders_mysql_config", a.OrdersMysqlConfig)
+	}
+	if a.OrdersRoMysqlConfig == nil {
+		errs.Add("orders_ro_mysql_config", "section is missing")
+	} else {
+		validateMySqlConfig(errs, "orders_ro_mysql_config", a.OrdersRoMysqlConfig)
+	}
+
+	return errs.Err()
+}
+
+func (a *ApplicationConfig) validateApp(errs *config.ValidationErrors) {
+	if a.App == nil {
+		errs.Add("app", "section is missing")
+		return
+	}
+	errs.RequireString("app.name", a.App.AppName)
+	errs.RequireListenPort("app.http_port", a.App.HttpPort)
+	errs.RequireNonNegative("app.request_read_timeout_ms", a.App.RequestReadTimeoutMs)
+	errs.RequireNonNegative("app.request_write_timeout_ms", a.App.RequestWriteTimeoutMs)
+	errs.RequireNonNegative("app.outstanding_request_timeout_ms", a.App.OutstandingRequestTimeoutMs)
+	errs.RequireNonNegative("app.idle_timeout_ms", a.App.IdleTimeoutMs)
+}
+
+func (a *ApplicationConfig) validateServer(errs *config.ValidationErrors) {
+	if a.Server != nil {
+		errs.RequireNonNegative("server.startup_timeout_ms", a.Server.StartupTimeoutMs)
+		for i, proxy := range a.Server.TrustedProxies {
+			_, _, cidrErr := net.ParseCIDR(proxy)
+			errs.Check(cidrErr == nil || net.ParseIP(proxy) != nil, fmt.Sprintf("server.trusted_proxies[%d]", i), "must be an IP or a CIDR, got %q", proxy)
+		}
+	}
+	if a.Shutdown != nil {
+		errs.RequireNonNegative("shutdown.readiness_grace_ms", a.Shutdown.ReadinessGraceMs)
+		for stage, timeoutMs := range a.Shutdown.StageTimeoutsMs {
+			path := "shutdown.stage_timeouts_ms." + stage
+			errs.Check(shutdown.IsStage(stage), path, "unknown stage, expected one of %v", shutdown.Stages)
+			errs.RequireNonNegative(path, timeoutMs)
+		}
+	}
+}
+
+func (a *ApplicationConfig) validateAdmin(errs *config.ValidationErrors) {
+	if a.Admin == nil {
+		return
+	}
+	errs.RequireListenPort("admin.http_port", a.Admin.HttpPort)
+	if a.App != nil && a.Admin.HttpPort != 0 {
+		errs.Check(a.Admin.HttpPort != a.App.HttpPort, "admin.http_port", "must not be same as app.http_port %d", a.App.HttpPort)
+	}
+	if a.Admin.BasicAuth.Enabled() {
+		errs.RequireString("admin.basic_auth.password", a.Admin.BasicAuth.Password)
+	}
+}
+
+func (a *ApplicationConfig) validateClientAuth(errs *config.ValidationErrors) {
+	if a.ClientAuth == nil {
+		return
+	}
+	if a.ClientAuth.Store != "" {
+		errs.Check(a.ClientAuth.Store == auth.StoreConfig || a.ClientAuth.Store == auth.StoreMySql, "client_auth.store",
+			"unknown store [%s], use one of %s, %s", a.ClientAuth.Store, auth.StoreConfig, auth.StoreMySql)
+	}
+	errs.RequireNonNegative("client_auth.cache_ttl_ms", a.ClientAuth.CacheTtlMs)
+	for i, client := range a.ClientAuth.Clients {
+		if client.ID == "" {
+			continue
+		}
+		path := fmt.Sprintf("client_auth.clients[%d]", i)
+		_, err := hex.DecodeString(client.TokenSha256)
+		errs.Check(err == nil && len(client.TokenSha256) == 64, path+".token_sha256", "must be the hex sha256 digest of the token of client [%s]", client.ID)
+	}
+}
+
+func (a *ApplicationConfig) validateJwtAuth(errs *config.ValidationErrors) {
+	if a.JwtAuth == nil || !a.JwtAuth.Enabled {
+		return
+	}
+	jwtAuth := a.JwtAuth
+	errs.Check((jwtAuth.JwksFile == "") != (jwtAuth.JwksUrl == ""), "jwt_auth", "exactly one of jwks_file or jwks_url must be set")
+	if jwtAuth.JwksUrl != "" {
+		u, err := url.Parse(jwtAuth.JwksUrl)
+		errs.Check(err == nil && (u.Scheme == "http" || u.Scheme == "https") && u.Host != "", "jwt_auth.jwks_url", "must be a http or https url, got [%s]", jwtAuth.JwksUrl)
+	}
+	for _, algorithm := range jwtAuth.Algorithms {
+		errs.Check(jwt.GetSigningMethod(algorithm) != nil && algorithm != "none" && !strings.HasPrefix(algorithm, "HS"),
+			"jwt_auth.algorithms", "unsupported algorithm [%s], use an RSA or EC algorithm e.g. RS256, ES256", algorithm)
+	}
+	errs.RequireNonNegative("jwt_auth.leeway_ms", jwtAuth.LeewayMs)
+	errs.RequireNonNegative("jwt_auth.jwks_refresh_interval_ms", jwtAuth.JwksRefreshIntervalMs)
+}
+
+func (a *ApplicationConfig) validateRateLimit(errs *config.ValidationErrors) {
+	if a.RateLimit == nil || !a.RateLimit.Enabled {
+		return
+	}
+	names := map[string]bool{}
+	for i, rule := range a.RateLimit.Rules {
+		path := fmt.Sprintf("rate_limit.rules[%d]", i)
+		errs.RequireString(path+".name", rule.Name)
+		errs.Check(!names[rule.Name], path+".name", "duplicate rule name [%s]", rule.Name)
+		names[rule.Name] = true
+		errs.Check(ratelimit.IsKey(rule.Key), path+".key", "unknown key [%s], use one of %v", rule.Key, ratelimit.Keys)
+		errs.Check(rule.RequestsPerSecond > 0, path+".requests_per_second", "must be greater than 0, got %v", rule.RequestsPerSecond)
+		errs.Check(rule.Burst >= 1, path+".burst", "must be at least 1, got %d", rule.Burst)
+		for _, route := range rule.Routes {
+			errs.Check(strings.HasPrefix(route, "/"), path+".routes", "route [%s] must start with /", route)
+		}
+	}
+}
+
+func (a *ApplicationConfig) validateAccessLog(errs *config.ValidationErrors) {
+	if a.AccessLog == nil || !a.AccessLog.Enabled {
+		return
+	}
+	validateLogLevel(errs, "access_log.level", a.AccessLog.Level)
+	validateLogLevel(errs, "access_log.error_level", a.AccessLog.ErrorLevel)
+	if rate := a.AccessLog.SampleRate; rate != nil {
+		errs.Check(*rate >= 0 && *rate <= 1, "access_log.sample_rate", "must be between 0 and 1, got %v", *rate)
+	}
+	errs.RequireNonNegative("access_log.slow_request_ms", a.AccessLog.SlowRequestMs)
+	for _, route := range a.AccessLog.SkipRoutes {
+		errs.Check(strings.HasPrefix(route, "/"), "access_log.skip_routes", "route [%s] must start with /", route)
+	}
+}
+
+func (a *ApplicationConfig) validateRequestCapture(errs *config.ValidationErrors) {
+	if a.RequestCapture == nil {
+		return
+	}
+	for _, route := range a.RequestCapture.Routes {
+		errs.Check(strings.HasPrefix(route, "/"), "request_capture.routes", "route [%s] must start with /", route)
+	}
+	errs.RequireNonNegative("request_capture.max_body_bytes", a.RequestCapture.MaxBodyBytes)
+	errs.RequireNonNegative("request_capture.max_capture_bytes", a.RequestCapture.MaxCaptureBytes)
+	errs.RequireNonNegative("request_capture.queue_size", a.RequestCapture.QueueSize)
+
+	// The producer is checked only if messaging is used - without it records are only logged to the console
+	if len(a.RequestCapture.Routes) > 0 && a.RequestCapture.Producer != "" && a.MessagingConfig != nil && a.MessagingConfig.Enabled {
+		_, ok := a.MessagingConfig.Producers[a.RequestCapture.Producer]
+		errs.Check(ok, "request_capture.producer", "producer [%s] is not in messaging_config.producers", a.RequestCapture.Producer)
+	}
+}
+
+func (a *ApplicationConfig) validateIdempotency(errs *config.ValidationErrors) {
+	if a.Idempotency == nil || !a.Idempotency.Enabled {
+		return
+	}
+	for _, method := range a.Idempotency.Methods {
+		errs.Check(idempotency.IsMethod(method), "idempotency.methods", "unsupported method [%s], use one of %v", method, idempotency.Methods)
+	}
+	errs.RequireNonNegative("idempotency.ttl_sec", a.Idempotency.TtlSec)
+	errs.RequireNonNegative("idempotency.lock_timeout_ms", a.Idempotency.LockTimeoutMs)
+	errs.RequireNonNegative("idempotency.max_request_body_bytes", a.Idempotency.MaxRequestBodyBytes)
+	errs.RequireNonNegative("idempotency.max_response_body_bytes", a.Idempotency.MaxResponseBodyBytes)
+	errs.Check(a.Idempotency.MaxResponseBodyBytes <= idempotency.MaxStoredResponseBytes, "idempotency.max_response_body_bytes", "must not be larger than %d, the size of the response_body column", idempotency.MaxStoredResponseBytes)
+	if a.Idempotency.TtlSec > 0 {
+		errs.Check(a.Idempotency.LockTimeoutMs <= a.Idempotency.TtlSec*1000, "idempotency.lock_timeout_ms", "must not be longer than ttl_sec")
+	}
+	errs.RequireNonNegative("idempotency.cleanup_interval_ms", a.Idempotency.CleanupIntervalMs)
+	errs.RequireNonNegative("idempotency.cleanup_batch_size", a.Idempotency.CleanupBatchSize)
+}
+
+func (a *ApplicationConfig) validateLogger(errs *config.ValidationErrors) {
+	if a.Logger == nil {
+		return
+	}
+	validateLogLevel(errs, "logger.level", a.Logger.LogLevel)
+}
+
+func validateLogLevel(errs *config.ValidationErrors, path string, level string) {
+	if level == "" {
+		return
+	}
+	_, err := zapcore.ParseLevel(level)
+	errs.Check(err == nil, path, "unknown log level [%s], use one of debug, info, warn, error", level)
+}
+
+func (a *ApplicationConfig) validateMetric(errs *config.ValidationErrors) {
+	if a.MetricConfig == nil {
+		errs.Add("metric", "section is missing")
+		return
+	}
+	if a.MetricConfig.Enabled && a.MetricConfig.EnableStatsd {
+		errs.RequireString("metric.statsd.address", a.MetricConfig.Statsd.Address)
+	}
+}
+
+func (a *ApplicationConfig) validateHttp(errs *config.ValidationErrors) {
+	if a.HttpConfig == nil {
+		return
+	}
+
+	for name, server := range a.HttpConfig.Servers {
+		path := fmt.Sprintf("server_config.servers.%s", name)
+		if server == nil {
+			errs.Add(path, "server definition is empty")
+			continue
+		}
+		errs.RequireString(path+".host", server.Host)
+		if server.Port != -1 {
+			errs.RequirePort(path+".port", server.Port)
+		}
+		errs.RequireNonNegative(path+".connect_timeout", server.ConnectTimeout)
+		errs.RequireNonNegative(path+".connection_request_timeout", server.ConnectionRequestTimeout)
+	}
+
+	for name, api := range a.HttpConfig.Apis {
+		path := fmt.Sprintf("server_config.apis.%s", name)
+		if api == nil {
+			errs.Add(path, "api definition is empty")
+			continue
+		}
+		errs.RequireString(path+".method", api.Method)
+		errs.RequireString(path+".path", api.Path)
+		if _, ok := a.HttpConfig.Servers[api.Server]; !ok {
+			errs.Add(path+".server", "refers to server [%s] which is not defined in server_config.servers", api.Server)
+		}
+		errs.Check(api.Timeout > 0, path+".timeout", "must be greater than 0, got %d", api.Timeout)
+		errs.RequireNonNegative(path+".retry_count", api.RetryCount)
+		errs.RequireNonNegative(path+".concurrency", api.Concurrency)
+	}
+}
+
+func (a *ApplicationConfig) validateMessaging(errs *config.ValidationErrors) {
+	if a.MessagingConfig == nil || !a.MessagingConfig.Enabled {
+		return
+	}
+
+	for name, producer := range a.MessagingConfig.Producers {
+		if producer.Enabled {
+			validateMessagingEndpoint(errs, fmt.Sprintf("messaging_config.producers.%s", name), producer.Type, producer.Endpoint, producer.Topic)
+		}
+	}
+	for name, consumer := range a.MessagingConfig.Consumers {
+		if consumer.Enabled {
+			validateMessagingEndpoint(errs, fmt.Sprintf("messaging_config.consumers.%s", name), consumer.Type, consumer.Endpoint, consumer.Topic)
+		}
+	}
+}
+
+func validateMessagingEndpoint(errs *config.ValidationErrors, path string, messagingType string, endpoint string, topic string) {
+	errs.RequireString(path+".type", messagingType)
+	errs.RequireString(path+".topic", topic)
+	if strings.EqualFold(messagingType, "kafka") {
+		errs.RequireString(path+".endpoint", endpoint)
+		for _, broker := range strings.Split(endpoint, ",") {
+			if broker = strings.TrimSpace(broker); broker != "" {
+				errs.Check(strings.Contains(broker, ":"), path+".endpoint", "broker [%s] must be in host:port format", broker)
+			}
+		}
+	}
+}
+
+func (a *ApplicationConfig) validateCadence(errs *config.ValidationErrors) {
+	if a.CadenceConfig == nil || a.CadenceConfig.Disabled {
+		return
+	}
+	for name, group := range a.CadenceConfig.WorkerGroups {
+		if !group.Disabled {
+			path := fmt.Sprintf("cadence_config.worker_groups.%s", name)
+			errs.RequireString(path+".domain", group.Domain)
+			errs.RequireString(path+".host_port", group.HostPort)
+		}
+	}
+}
+
+// validateMySqlConfig must run before SetupDefault() is called, otherwise a missing host silently becomes localhost
+func validateMySqlConfig(errs *config.ValidationErrors, path string, cfg database.ConfigProvider) {
+	errs.RequireString(path+".host", cfg.GetHost())
+	errs.RequirePort(path+".port", cfg.GetPort())
+	errs.RequireString(path+".user", cfg.GetUser())
+	errs.RequireString(path+".database", cfg.GetDatabase())
+	errs.RequireNonNegative(path+".max_open_connections", cfg.GetMaxOpenConnection())
+	errs.RequireNonNegative(path+".max_idle_connections", cfg.GetMaxIdleConnection())
+}
@@ -0,0 +1,177 @@
+package command
+
+import (
+	"github.com/devlibx/go-template-project/pkg/infra/accesslog"
+	"testing"
+
+	"github.com/devlibx/go-template-project/pkg/infra/admin"
+	"github.com/devlibx/go-template-project/pkg/infra/auth"
+	"github.com/devlibx/go-template-project/pkg/infra/capture"
+	orderRoDataStore "github.com/devlibx/go-template-project/pkg/infra/database/mysql/user/ro"
+	ordersDataStore "github.com/devlibx/go-template-project/pkg/infra/database/mysql/user/rw"
+	"github.com/devlibx/go-template-project/pkg/infra/idempotency"
+	"github.com/devlibx/go-template-project/pkg/infra/ratelimit"
+	"github.com/devlibx/go-template-project/pkg/infra/shutdown"
+	goxBaseConfig "github.com/devlibx/gox-base/v2/config"
+	goxBaseMetrics "github.com/devlibx/gox-base/v2/metrics"
+	goxHttp "github.com/devlibx/gox-http/v4/command"
+	goxMessaging "github.com/devlibx/gox-messaging/v2"
+	"github.com/stretchr/testify/assert"
+)
+
+func validApplicationConfig() *ApplicationConfig {
+	return &ApplicationConfig{
+		App:          &goxBaseConfig.App{AppName: "test", HttpPort: 9010},
+		MetricConfig: &goxBaseMetrics.Config{},
+		HttpConfig: &goxHttp.Config{
+			Servers: goxHttp.Servers{"jsonplaceholder": {Host: "jsonplaceholder.typicode.com", Port: -1}},
+			Apis:    goxHttp.Apis{"getPosts": {Method: "GET", Path: "/todos/{postId}", Server: "jsonplaceholder", Timeout: 1000}},
+		},
+		MessagingConfig: &goxMessaging.Configuration{
+			Enabled: true,
+			Producers: map[string]goxMessaging.ProducerConfig{
+				"metrics": {Enabled: true, Type: "kafka", Topic: "test", Endpoint: "localhost:9092"},
+			},
+		},
+		OrdersMysqlConfig:   &ordersDataStore.MySqlConfig{Host: "localhost", Port: 3306, User: "test", Database: "test_db"},
+		OrdersRoMysqlConfig: &orderRoDataStore.MySqlConfig{Host: "localhost", Port: 3306, User: "root", Database: "test_db"},
+	}
+}
+
+func TestApplicationConfig_Validate(t *testing.T) {
+	assert.NoError(t, validApplicationConfig().Validate())
+}
+
+func TestApplicationConfig_Validate_ReportsAllProblems(t *testing.T) {
+	appConfig := validApplicationConfig()
+	appConfig.App.HttpPort = -1
+	appConfig.OrdersMysqlConfig.Host = ""
+	appConfig.HttpConfig.Apis["getPosts"].Server = "missing"
+	appConfig.MessagingConfig.Producers["metrics"] = goxMessaging.ProducerConfig{Enabled: true, Type: "kafka", Topic: "test"}
+	appConfig.OrdersRoMysqlConfig = nil
+
+	err := appConfig.Validate()
+	assert.Error(t, err)
+	assert.Contains(t, err.Error(), "app.http_port")
+	assert.Contains(t, err.Error(), "orders_mysql_config.host")
+	assert.Contains(t, err.Error(), "server_config.apis.getPosts.server")
+	assert.Contains(t, err.Error(), "messaging_config.producers.metrics.endpoint")
+	assert.Contains(t, err.Error(), "orders_ro_mysql_config: section is missing")
+}
+
+func TestApplicationConfig_Validate_AdminAndShutdown(t *testing.T) {
+	appConfig := validApplicationConfig()
+	appConfig.Admin = &admin.Config{HttpPort: 9010, BasicAuth: &admin.BasicAuthConfig{Username: "admin"}}
+	appConfig.Shutdown = &shutdown.Config{StageTimeoutsMs: map[string]int{"unknown": 100}}
+	appConfig.Server = &ServerConfig{TrustedProxies: []string{"10.0.0.0/8", "192.168.1.10", "lb.internal"}}
+
+	err := appConfig.Validate()
+	assert.Error(t, err)
+	assert.Contains(t, err.Error(), "admin.http_port")
+	assert.Contains(t, err.Error(), "admin.basic_auth.password")
+	assert.Contains(t, err.Error(), "shutdown.stage_timeouts_ms.unknown")
+	assert.Contains(t, err.Error(), "server.trusted_proxies[2]")
+	assert.NotContains(t, err.Error(), "server.trusted_proxies[0]")
+	assert.NotContains(t, err.Error(), "server.trusted_proxies[1]")
+}
+
+func TestApplicationConfig_Validate_ClientAuth(t *testing.T) {
+	appConfig := validApplicationConfig()
+	appConfig.ClientAuth = &auth.ClientAuthConfig{Store: "redis", Clients: []auth.ClientConfig{
+		{ID: "c1", TokenSha256: "plain-token"},
+		{ID: "c2", TokenSha256: auth.HashToken("token")},
+		{ID: "", TokenSha256: ""},
+	}}
+
+	err := appConfig.Validate()
+	assert.Error(t, err)
+	assert.Contains(t, err.Error(), "client_auth.store")
+	assert.Contains(t, err.Error(), "client_auth.clients[0].token_sha256")
+	assert.NotContains(t, err.Error(), "client_auth.clients[1]")
+	assert.NotContains(t, err.Error(), "client_auth.clients[2]")
+}
+
+func TestApplicationConfig_Validate_JwtAuth(t *testing.T) {
+	appConfig := validApplicationConfig()
+	appConfig.JwtAuth = &auth.JwtAuthConfig{Enabled: true, JwksFile: "jwks.json", JwksUrl: "file:///jwks.json", Algorithms: []string{"RS256", "HS256"}}
+
+	err := appConfig.Validate()
+	assert.Error(t, err)
+	assert.Contains(t, err.Error(), "exactly one of jwks_file or jwks_url")
+	assert.Contains(t, err.Error(), "jwt_auth.jwks_url")
+	assert.Contains(t, err.Error(), "unsupported algorithm [HS256]")
+
+	// Nothing is checked if it is disabled
+	appConfig.JwtAuth.Enabled = false
+	assert.NoError(t, appConfig.Validate())
+}
+
+func TestApplicationConfig_Validate_RateLimit(t *testing.T) {
+	appConfig := validApplicationConfig()
+	appConfig.RateLimit = &ratelimit.Config{Enabled: true, Rules: []ratelimit.Rule{
+		{Name: "a", Key: ratelimit.KeyClient, RequestsPerSecond: 1, Burst: 1, Routes: []string{"/api/v1/post/:postId"}},
+		{Name: "a", Key: "user", RequestsPerSecond: 0, Burst: 0, Routes: []string{"api/v1/post"}},
+	}}
+
+	err := appConfig.Validate()
+	assert.Error(t, err)
+	assert.NotContains(t, err.Error(), "rate_limit.rules[0]")
+	assert.Contains(t, err.Error(), "duplicate rule name [a]")
+	assert.Contains(t, err.Error(), "rate_limit.rules[1].key")
+	assert.Contains(t, err.Error(), "rate_limit.rules[1].requests_per_second")
+	assert.Contains(t, err.Error(), "rate_limit.rules[1].burst")
+	assert.Contains(t, err.Error(), "rate_limit.rules[1].routes")
+}
+
+func TestApplicationConfig_Validate_AccessLog(t *testing.T) {
+	appConfig := validApplicationConfig()
+	sampleRate := 1.5
+	appConfig.AccessLog = &accesslog.Config{Enabled: true, Level: "loud", ErrorLevel: "error", SampleRate: &sampleRate, SlowRequestMs: -1, SkipRoutes: []string{"api/v1/ping"}}
+
+	err := appConfig.Validate()
+	assert.Error(t, err)
+	assert.Contains(t, err.Error(), "access_log.level")
+	assert.NotContains(t, err.Error(), "access_log.error_level")
+	assert.Contains(t, err.Error(), "access_log.sample_rate")
+	assert.Contains(t, err.Error(), "access_log.slow_request_ms")
+	assert.Contains(t, err.Error(), "access_log.skip_routes")
+
+	appConfig.AccessLog.Enabled = false
+	assert.NoError(t, appConfig.Validate())
+}
+
+func TestApplicationConfig_Validate_RequestCapture(t *testing.T) {
+	appConfig := validApplicationConfig()
+	appConfig.RequestCapture = &capture.Config{Routes: []string{"api/v1/post"}, Producer: "requestResponseLogging", MaxBodyBytes: -1, QueueSize: -1}
+
+	err := appConfig.Validate()
+	assert.Error(t, err)
+	assert.Contains(t, err.Error(), "request_capture.routes")
+	assert.Contains(t, err.Error(), "request_capture.max_body_bytes")
+	assert.NotContains(t, err.Error(), "request_capture.max_capture_bytes")
+	assert.Contains(t, err.Error(), "request_capture.queue_size")
+	assert.Contains(t, err.Error(), "request_capture.producer")
+
+	appConfig.RequestCapture = &capture.Config{Routes: []string{"/api/v1/post/:postId"}, Producer: "metrics"}
+	assert.NoError(t, appConfig.Validate())
+}
+
+func TestApplicationConfig_Validate_Idempotency(t *testing.T) {
+	appConfig := validApplicationConfig()
+	appConfig.Idempotency = &idempotency.Config{Enabled: true, Methods: []string{"post", "GET"}, TtlSec: -1, LockTimeoutMs: -1}
+
+	err := appConfig.Validate()
+	assert.Error(t, err)
+	assert.Contains(t, err.Error(), "unsupported method [GET]")
+	assert.NotContains(t, err.Error(), "[post]")
+	assert.Contains(t, err.Error(), "idempotency.ttl_sec")
+	assert.Contains(t, err.Error(), "idempotency.lock_timeout_ms")
+
+	appConfig.Idempotency = &idempotency.Config{Enabled: true, TtlSec: 60, LockTimeoutMs: 120000, MaxResponseBodyBytes: 32 * 1024 * 1024}
+	err = appConfig.Validate()
+	assert.ErrorContains(t, err, "idempotency.lock_timeout_ms")
+	assert.ErrorContains(t, err, "idempotency.max_response_body_bytes")
+
+	appConfig.Idempotency.Enabled = false
+	assert.NoError(t, appConfig.Validate())
+}
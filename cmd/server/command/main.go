@@ -5,31 +5,33 @@ import (
 	"github.com/devlibx/go-template-project/config"
 	"github.com/devlibx/go-template-project/pkg/base"
 	"github.com/devlibx/gox-base/v2/errors"
-	"github.com/devlibx/gox-base/v2/serialization"
 	"log/slog"
+	"os"
+	"os/signal"
+	"syscall"
 )
 
 func FullMain(ctx context.Context, started chan bool, applicationContext *base.ApplicationContext) {
 
-	// Read merged configs
-	fullConfig, err := config.GetEnvExpandedMergedYamlApplicationConfig()
+	// Build application config - embedded yaml and json config files and external config files (CONFIG_DIR or --config)
+	// are merged together, variables are expanded and the config is validated. All problems are reported together
+	reloader, err := NewConfigReloader()
 	if err != nil {
-		panic(errors.Wrap(err, "something is wrong, failed to generate merged application config"))
-	}
-
-	// Build application config
-	appConfig := ApplicationConfig{}
-	err = serialization.ReadParameterizedYaml(fullConfig, &appConfig, "env")
-	if err != nil {
-		panic(errors.Wrap(err, "something is wrong, failed to build application config"))
+		panic(config.RedactError(errors.Wrap(err, "something is wrong, failed to read application config")))
 	}
+	appConfig := reloader.Current()
 
 	slog.Info("Http Port", slog.Int("port", appConfig.App.HttpPort))
 
+	// Stop on SIGINT or SIGTERM - the app goes through all shutdown stages before this returns
+	ctx, stop := signal.NotifyContext(ctx, os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
 	// Start server
-	if err = AppMain(ctx, &appConfig, applicationContext); err != nil {
-		panic(err)
+	stopped, err := AppMain(ctx, reloader, applicationContext)
+	if err != nil {
+		panic(config.RedactError(err))
 	}
 	started <- true
-	<-ctx.Done()
+	<-stopped
 }
@@ -0,0 +1,216 @@
+package command
+
+import (
+	"context"
+	"github.com/devlibx/go-template-project/config"
+	"github.com/devlibx/go-template-project/pkg/infra/accesslog"
+	"github.com/devlibx/go-template-project/pkg/infra/capture"
+	consumers "github.com/devlibx/go-template-project/pkg/infra/messaging"
+	"github.com/devlibx/go-template-project/pkg/infra/ratelimit"
+	"github.com/devlibx/go-template-project/pkg/infra/requestid"
+	"github.com/devlibx/gox-base/v2"
+	goxBaseConfig "github.com/devlibx/gox-base/v2/config"
+	"github.com/devlibx/gox-base/v2/errors"
+	goxHttpApi "github.com/devlibx/gox-http/v4/api"
+	goxHttp "github.com/devlibx/gox-http/v4/command"
+	"github.com/go-resty/resty/v2"
+	"go.uber.org/fx"
+	"go.uber.org/zap"
+	"log/slog"
+	"sync/atomic"
+	"time"
+)
+
+// reloadableSections are the config sections which are safe to change at runtime - a change to these sections in
+// external config files (CONFIG_DIR or --config) is applied without a restart. Changes to any other section need a restart
+var reloadableSections = []string{
+	"logger",
+	"gox_http_request_response_security_config",
+	"server_config.apis",
+	"rate_limit",
+	"access_log",
+}
+
+// ConfigReloader holds the current application config and applies changes from external config files
+type ConfigReloader = config.Reloader[ApplicationConfig]
+
+// NewConfigReloader builds the application config from all config files
+func NewConfigReloader() (*ConfigReloader, error) {
+	return config.NewReloader(reloadableSections, buildApplicationConfig)
+}
+
+// buildApplicationConfig builds the application config from the merged yaml config. Variables are expanded and all
+// unresolved variables and config problems are reported together
+func buildApplicationConfig(data string) (*ApplicationConfig, error) {
+	appConfig := &ApplicationConfig{}
+
+	// "env:" values pick the branch of DP_RUN_ENV. Before the per-environment overlays they were read with the literal
+	// env "env", so they always gave their default - see "Per-Environment Overlays" in the README
+	if err := config.ReadParameterizedConfig(data, appConfig, config.GetRunEnv()); err != nil {
+		return nil, errors.Wrap(err, "failed to build application config")
+	}
+	if err := appConfig.Validate(); err != nil {
+		return nil, errors.Wrap(err, "application config is not valid")
+	}
+	appConfig.SetDefaults()
+	return appConfig, nil
+}
+
+// RequestResponseSecurityConfigHolder gives the current request logging config - it is updated on config reload, so
+// use Get() on every request instead of keeping the config. The access log and request capture read it this way. A
+// component which is given the *RequestResponseSecurityConfig itself (e.g. ServerImpl.RequestResponseSecurityConfig or
+// the request logger middleware of gox-http) keeps the config it started with, and needs a restart to see a change
+type RequestResponseSecurityConfigHolder struct {
+	value atomic.Pointer[goxHttpApi.RequestResponseSecurityConfig]
+}
+
+func NewRequestResponseSecurityConfigHolder(securityConfig *goxHttpApi.RequestResponseSecurityConfig) *RequestResponseSecurityConfigHolder {
+	h := &RequestResponseSecurityConfigHolder{}
+	h.value.Store(securityConfig)
+	return h
+}
+
+func (h *RequestResponseSecurityConfigHolder) Get() *goxHttpApi.RequestResponseSecurityConfig {
+	return h.value.Load()
+}
+
+// goxHttpContext is the gox-http context of the upstream apis. gox-http changes an api in place on ReloadApi, without
+// a lock against the requests which use it, and it can not remove an api - so a change of server_config.apis builds a
+// new gox-http context, which is swapped in for the next requests. Running requests finish with the context they
+// started with, and a removed api gets the error gox-http gives for an unknown api
+type goxHttpContext struct {
+	cf      gox.CrossFunction
+	config  *goxHttp.Config
+	current atomic.Pointer[goxHttpApis]
+	build   func(cf gox.CrossFunction, config *goxHttp.Config) (goxHttpApi.GoxHttpContext, error)
+}
+
+// goxHttpApis is a gox-http context with the config it was built from - neither is changed after it is built
+type goxHttpApis struct {
+	ctx    goxHttpApi.GoxHttpContext
+	config *goxHttp.Config
+}
+
+func newGoxHttpContext(cf gox.CrossFunction, config *goxHttp.Config) (goxHttpApi.GoxHttpContext, error) {
+	g := &goxHttpContext{cf: cf, config: config, build: goxHttpApi.NewGoxHttpContext}
+	ctx, err := g.build(cf, config)
+	if err != nil {
+		return nil, err
+	}
+	g.current.Store(&goxHttpApis{ctx: ctx, config: config})
+	return g, nil
+}
+
+func (g *goxHttpContext) Execute(ctx context.Context, request *goxHttp.GoxRequest) (*goxHttp.GoxResponse, error) {
+	return g.current.Load().ctx.Execute(ctx, request)
+}
+
+// ReloadApi builds a new gox-http context with the current apis, the api is not changed in place
+func (g *goxHttpContext) ReloadApi(apiToReload string) error {
+	return g.reloadApis(g.current.Load().config.Apis)
+}
+
+// GetRestyClient keeps goxHttpApi.GetRestyClientFromGoxHttpCtx working through this wrapper
+func (g *goxHttpContext) GetRestyClient(api string) (*resty.Client, bool) {
+	return goxHttpApi.GetRestyClientFromGoxHttpCtx(g.current.Load().ctx, api)
+}
+
+// reloadApis builds a gox-http context with the apis of a reloaded config and the servers the application started
+// with, and swaps it in. gox-http fills defaults into the config it is given, so it gets copies. If the context can
+// not be built, the current one is kept
+func (g *goxHttpContext) reloadApis(apis goxHttp.Apis) error {
+	config := &goxHttp.Config{Env: g.config.Env, Servers: goxHttp.Servers{}, Apis: goxHttp.Apis{}}
+	for name, server := range g.config.Servers {
+		copied := *server
+		config.Servers[name] = &copied
+	}
+	for name, api := range apis {
+		copied := *api
+		config.Apis[name] = &copied
+	}
+	ctx, err := g.build(g.cf, config)
+	if err != nil {
+		return err
+	}
+	requestid.SetupGoxHttp(ctx, config)
+
+	previous := g.current.Swap(&goxHttpApis{ctx: ctx, config: config})
+	for name := range previous.config.Apis {
+		if _, ok := config.Apis[name]; !ok {
+			slog.Info("http api is removed, calls to it are rejected", "api", name)
+		}
+	}
+	return nil
+}
+
+// newAccessLogger builds the access log with the ignored headers of the holder, so they are reloaded too
+func newAccessLogger(cf gox.CrossFunction, config *accesslog.Config, app *goxBaseConfig.App, securityConfigHolder *RequestResponseSecurityConfigHolder) *accesslog.Logger {
+	return accesslog.NewLogger(cf, config, app, securityConfigHolder.Get)
+}
+
+// newCapturer builds the request capture with the flags and masked keys of the holder, so they are reloaded too
+func newCapturer(cf gox.CrossFunction, config *capture.Config, app *goxBaseConfig.App, securityConfigHolder *RequestResponseSecurityConfigHolder, factory consumers.MessagingFactory) *capture.Capturer {
+	return capture.NewCapturer(cf, config, app, securityConfigHolder.Get, factory)
+}
+
+// setupConfigReload subscribes the reloadable components to config changes, and watches the external config
+// files while the application is running
+func setupConfigReload(
+	lc fx.Lifecycle,
+	reloader *ConfigReloader,
+	appConfig *ApplicationConfig,
+	logLevel zap.AtomicLevel,
+	securityConfigHolder *RequestResponseSecurityConfigHolder,
+	goxHttpCtx goxHttpApi.GoxHttpContext,
+	rateLimiter *ratelimit.Limiter,
+	accessLogger *accesslog.Logger,
+) error {
+
+	if err := reloader.Subscribe("logger", func(c *ApplicationConfig) {
+		if err := logLevel.UnmarshalText([]byte(c.Logger.LogLevel)); err != nil {
+			slog.Error("failed to update log level", "level", c.Logger.LogLevel, "error", err)
+		}
+	}); err != nil {
+		return err
+	}
+
+	if err := reloader.Subscribe("gox_http_request_response_security_config", func(c *ApplicationConfig) {
+		securityConfigHolder.value.Store(c.RequestResponseSecurityConfig)
+	}); err != nil {
+		return err
+	}
+
+	if err := reloader.Subscribe("server_config.apis", func(c *ApplicationConfig) {
+		if g, ok := goxHttpCtx.(*goxHttpContext); ok && c.HttpConfig != nil {
+			if err := g.reloadApis(c.HttpConfig.Apis); err != nil {
+				slog.Error("failed to reload http apis, the current apis are kept", "error", err)
+			}
+		}
+	}); err != nil {
+		return err
+	}
+
+	if err := reloader.Subscribe("rate_limit", func(c *ApplicationConfig) {
+		rateLimiter.Update(c.RateLimit)
+	}); err != nil {
+		return err
+	}
+
+	if err := reloader.Subscribe("access_log", func(c *ApplicationConfig) {
+		accessLogger.Update(c.AccessLog)
+	}); err != nil {
+		return err
+	}
+
+	lc.Append(fx.Hook{
+		OnStart: func(ctx context.Context) error {
+			reloader.Start(time.Duration(appConfig.ConfigReload.IntervalMs) * time.Millisecond)
+			return nil
+		},
+		OnStop: func(ctx context.Context) error {
+			reloader.Stop()
+			return nil
+		},
+	})
+	return nil
+}
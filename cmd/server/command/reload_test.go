@@ -0,0 +1,103 @@
+package command
+
+import (
+	"context"
+	"github.com/devlibx/gox-base/v2"
+	"github.com/devlibx/gox-base/v2/errors"
+	goxHttpApi "github.com/devlibx/gox-http/v4/api"
+	goxHttp "github.com/devlibx/gox-http/v4/command"
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/zap"
+	"sync"
+	"testing"
+)
+
+// testGoxHttpContext knows the apis of the config it is built with, like gox-http
+type testGoxHttpContext struct {
+	config *goxHttp.Config
+}
+
+func (t *testGoxHttpContext) ReloadApi(name string) error {
+	return errors.New("api must not be changed in place: name=%s", name)
+}
+
+func (t *testGoxHttpContext) Execute(ctx context.Context, request *goxHttp.GoxRequest) (*goxHttp.GoxResponse, error) {
+	if _, ok := t.config.Apis[request.Api]; !ok {
+		return nil, &goxHttp.GoxHttpError{Err: goxHttpApi.ErrCommandNotRegisteredForApi}
+	}
+	return &goxHttp.GoxResponse{}, nil
+}
+
+func newTestGoxHttpContext(t *testing.T, config *goxHttp.Config) *goxHttpContext {
+	g := &goxHttpContext{cf: gox.NewCrossFunction(zap.NewNop()), config: config}
+	g.build = func(cf gox.CrossFunction, config *goxHttp.Config) (goxHttpApi.GoxHttpContext, error) {
+		if _, ok := config.Apis["broken"]; ok {
+			return nil, errors.New("failed to create http command: api=broken")
+		}
+		return &testGoxHttpContext{config: config}, nil
+	}
+	ctx, err := g.build(g.cf, config)
+	assert.NoError(t, err)
+	g.current.Store(&goxHttpApis{ctx: ctx, config: config})
+	return g
+}
+
+func TestGoxHttpContext_ReloadApis(t *testing.T) {
+	server := &goxHttp.Server{Host: "jsonplaceholder.typicode.com", Port: 443}
+	getPosts := &goxHttp.Api{Name: "getPosts", Server: "jsonplaceholder", Timeout: 1000}
+	getUsers := &goxHttp.Api{Name: "getUsers", Server: "jsonplaceholder", Timeout: 1000}
+	config := &goxHttp.Config{
+		Servers: goxHttp.Servers{"jsonplaceholder": server},
+		Apis:    goxHttp.Apis{"getPosts": getPosts, "getUsers": getUsers},
+	}
+	g := newTestGoxHttpContext(t, config)
+	started := g.current.Load().ctx
+
+	// A changed api is in the new context, a removed api is rejected - and the config the application started with is
+	// not changed
+	changed := &goxHttp.Api{Name: "getPosts", Server: "jsonplaceholder", Timeout: 2000}
+	assert.NoError(t, g.reloadApis(goxHttp.Apis{"getPosts": changed}))
+	assert.Equal(t, goxHttp.Apis{"getPosts": getPosts, "getUsers": getUsers}, config.Apis)
+	assert.Equal(t, 1000, getPosts.Timeout)
+
+	current := g.current.Load()
+	assert.NotSame(t, started, current.ctx)
+	assert.Equal(t, 2000, current.config.Apis["getPosts"].Timeout)
+	assert.NotSame(t, changed, current.config.Apis["getPosts"])
+	assert.NotSame(t, server, current.config.Servers["jsonplaceholder"])
+
+	_, err := g.Execute(context.Background(), &goxHttp.GoxRequest{Api: "getUsers"})
+	assert.ErrorIs(t, err, goxHttpApi.ErrCommandNotRegisteredForApi)
+	_, err = g.Execute(context.Background(), &goxHttp.GoxRequest{Api: "getPosts"})
+	assert.NoError(t, err)
+
+	// If the new context can not be built, the current one is kept
+	assert.Error(t, g.reloadApis(goxHttp.Apis{"getPosts": changed, "broken": getUsers}))
+	assert.Same(t, current, g.current.Load())
+
+	// A removed api can be added again
+	assert.NoError(t, g.reloadApis(goxHttp.Apis{"getPosts": changed, "getUsers": getUsers}))
+	_, err = g.Execute(context.Background(), &goxHttp.GoxRequest{Api: "getUsers"})
+	assert.NoError(t, err)
+}
+
+func TestGoxHttpContext_ReloadWhileExecuting(t *testing.T) {
+	api := &goxHttp.Api{Name: "getPosts", Server: "jsonplaceholder", Timeout: 1000}
+	g := newTestGoxHttpContext(t, &goxHttp.Config{Apis: goxHttp.Apis{"getPosts": api}})
+
+	var wg sync.WaitGroup
+	for i := 0; i < 4; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for j := 0; j < 100; j++ {
+				_, err := g.Execute(context.Background(), &goxHttp.GoxRequest{Api: "getPosts"})
+				assert.NoError(t, err)
+			}
+		}()
+	}
+	for i := 0; i < 100; i++ {
+		assert.NoError(t, g.reloadApis(goxHttp.Apis{"getPosts": api}))
+	}
+	wg.Wait()
+}
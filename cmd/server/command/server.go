@@ -1,64 +1,79 @@
 package command
 
 import (
-	"github.com/devlibx/go-template-project/internal/handler"
+	"github.com/devlibx/go-template-project/pkg/apperror"
+	"github.com/devlibx/go-template-project/pkg/infra/accesslog"
+	"github.com/devlibx/go-template-project/pkg/infra/capture"
+	"github.com/devlibx/go-template-project/pkg/infra/health"
+	"github.com/devlibx/go-template-project/pkg/infra/httpserver"
+	"github.com/devlibx/go-template-project/pkg/infra/idempotency"
+	"github.com/devlibx/go-template-project/pkg/infra/ratelimit"
+	"github.com/devlibx/go-template-project/pkg/infra/requestid"
+	"github.com/devlibx/go-template-project/pkg/infra/router"
 	"github.com/devlibx/gox-base/v2"
 	goxBaseConfig "github.com/devlibx/gox-base/v2/config"
-	"github.com/devlibx/gox-base/v2/server/common"
+	"github.com/devlibx/gox-base/v2/errors"
 	goxHttpApi "github.com/devlibx/gox-http/v4/api"
-	stats "github.com/devlibx/gox-metrics/v2/common"
-	"github.com/gin-gonic/gin"
 	"go.uber.org/fx"
 	gintrace "gopkg.in/DataDog/dd-trace-go.v1/contrib/gin-gonic/gin"
+	"net"
 	"net/http"
 )
 
+// ServerStarted is sent on ServerSignal.StartedCh once the server accepts connections, or failed to start
+type ServerStarted struct {
+	Addr net.Addr
+	Err  error
+}
+
 type ServerSignal struct {
-	StartedCh chan error
+	StartedCh chan ServerStarted
 }
 
 type ServerImpl struct {
 	fx.In
-	common.Server
+	httpserver.Server
 	gox.CrossFunction
 	App                           *goxBaseConfig.App
 	ServerSignal                  *ServerSignal
+	ServerConfig                  *ServerConfig
 	RequestResponseSecurityConfig *goxHttpApi.RequestResponseSecurityConfig
-	MetricHandler                 *stats.MetricHandler
+	HealthRegistry                *health.Registry
+	RateLimiter                   *ratelimit.Limiter
+	AccessLogger                  *accesslog.Logger
+	Capturer                      *capture.Capturer
+	IdempotencyGuard              *idempotency.Guard
 
-	PostHandler handler.PostHandler
+	// Routes of all handler modules, and the authenticators used by them
+	RouteRegistrars []router.RouteRegistrar `group:"route_registrars"`
+	Authenticators  []router.Authenticator  `group:"authenticators"`
 }
 
 func (s *ServerImpl) ServeHTTP(w http.ResponseWriter, r *http.Request) {
 	s.GetRouter().ServeHTTP(w, r)
 }
 
-func (s *ServerImpl) routes() {
+// routes mounts the routes of all RouteRegistrar under /<app name>/api/<version>
+func (s *ServerImpl) routes() error {
 
-	router := s.GetRouter()
+	// Metrics, health and pprof are served by the admin server - see admin.NewServer
 
-	// Setup prom path for stats
-	router.GET("/metrics", func(context *gin.Context) {
-		s.MetricHandler.ServeHTTP(context.Writer, context.Request)
-	})
-	router.GET("/health", s.healthCheck())
+	// The client IP (e.g. the key of ip rate limits) is only read from forwarded headers of trusted proxies
+	if err := s.GetRouter().SetTrustedProxies(s.ServerConfig.TrustedProxies); err != nil {
+		return errors.Wrap(err, "failed to set server.trusted_proxies")
+	}
 
 	// APIs which are exposed to other systems
-	publicRouter := router.Group(s.App.AppName)
+	publicRouter := s.GetRouter().Group(s.App.AppName)
 	publicRouter.Use(gintrace.Middleware(s.App.AppName))
+	publicRouter.Use(requestid.Middleware())
+	publicRouter.Use(s.AccessLogger.Middleware())
+	publicRouter.Use(s.Capturer.Middleware())
 
-	// V1 APIs - Protected
-	v1Apis := publicRouter.Group("/api/v1")
+	// Errors added with c.Error() are sent as application/problem+json
+	publicRouter.Use(apperror.Handler(s.CrossFunction))
 
-	// Test Api
-	v1UserApis := v1Apis.Group("/post")
-	{
-		v1UserApis.GET("/:postId", s.PostHandler.GetPost)
-	}
-}
-
-func (s *ServerImpl) healthCheck() gin.HandlerFunc {
-	return func(c *gin.Context) {
-		c.JSON(http.StatusOK, map[string]interface{}{"status": "ok"})
-	}
+	// Idempotency keys are scoped to the client, so it runs after auth - and after rate limits, so a rejected retry
+	// does not take the key
+	return router.Mount(publicRouter, s.RouteRegistrars, s.Authenticators, s.RateLimiter.Middleware(), s.IdempotencyGuard.Middleware())
 }
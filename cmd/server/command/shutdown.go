@@ -0,0 +1,49 @@
+package command
+
+import (
+	"context"
+	"github.com/devlibx/go-template-project/pkg/infra/shutdown"
+	"github.com/devlibx/gox-base/v2/errors"
+	goxCadence "github.com/devlibx/gox-workfkow/workflow/framework/cadence"
+	"time"
+)
+
+// registerServerShutdown turns readiness off and then drains the http server in the first two shutdown stages
+func registerServerShutdown(coordinator *shutdown.Coordinator, serverImpl ServerImpl, config *shutdown.Config) error {
+	err := coordinator.Register(shutdown.StageReadiness, "readiness", func(ctx context.Context) error {
+		serverImpl.HealthRegistry.MarkReady(false)
+
+		// Give load balancers time to see the instance is not ready, before the listener is closed
+		select {
+		case <-time.After(time.Duration(config.ReadinessGraceMs) * time.Millisecond):
+			return nil
+		case <-ctx.Done():
+			return errors.Wrap(ctx.Err(), "readiness grace period is longer than the stage timeout")
+		}
+	})
+	if err != nil {
+		return err
+	}
+
+	return coordinator.Register(shutdown.StageHttpDrain, "http", serverImpl.Shutdown)
+}
+
+// registerWorkflowShutdown stops the cadence workers. The lifecycle hook of NewCadenceWorkflowApiInvokerAtBoot does
+// the same - cadence shuts down only once, so it is a no-op after this stage
+func registerWorkflowShutdown(coordinator *shutdown.Coordinator, workflowApi goxCadence.Api, config *goxCadence.Config) error {
+	if config == nil || config.Disabled || workflowApi == nil {
+		return nil
+	}
+	return coordinator.Register(shutdown.StageWorkflows, "cadence", func(ctx context.Context) error {
+		ch, err := workflowApi.Shutdown(ctx)
+		if err != nil {
+			return err
+		}
+		select {
+		case err = <-ch:
+			return err
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	})
+}
@@ -2,7 +2,11 @@ package main
 
 import (
 	"context"
+	"flag"
+	"fmt"
+	goTemplate "github.com/devlibx/go-template-project"
 	"github.com/devlibx/go-template-project/cmd/server/command"
+	"github.com/devlibx/go-template-project/config"
 	"github.com/devlibx/go-template-project/pkg/base"
 	consumers "github.com/devlibx/go-template-project/pkg/infra/messaging"
 	httpCommand "github.com/devlibx/gox-http/v4/command/http"
@@ -11,15 +15,70 @@ import (
 )
 
 func main() {
+	profile := flag.String("profile", "", "env profile to load before start e.g. dev, stage (see env/profiles.yaml)")
+	configPaths := flag.String("config", "", "comma separated list of config files or dirs, merged on top of the embedded config in the given order (after CONFIG_DIR)")
+	flag.Parse()
+	config.SetConfigPaths(*configPaths)
+
+	// The only command is "config explain", with its own flags - any other argument is an error, the server is not
+	// started with arguments it does not understand
+	args := flag.Args()
+	explain := len(args) >= 2 && args[0] == "config" && args[1] == "explain"
+	explainFlags := flag.NewFlagSet("config explain", flag.ExitOnError)
+	dryRun := explainFlags.Bool("dry-run", false, "do not resolve secret references - no secret provider (e.g. vault) is called")
+	if explain {
+		_ = explainFlags.Parse(args[2:])
+		args = explainFlags.Args()
+	}
+	if len(args) > 0 {
+		fmt.Println("unknown arguments:", args)
+		if explain {
+			explainFlags.Usage()
+		} else {
+			flag.Usage()
+		}
+		os.Exit(2)
+	}
+
+	// Env vars of the profile are set, unless they are already set in the process env
+	if *profile != "" {
+		if err := goTemplate.SetupEnvProfile(*profile, map[string]string{}, goTemplate.DefaultEnvSetupFunc()); err != nil {
+			fmt.Println("failed to setup env profile:", config.RedactError(err))
+			os.Exit(1)
+		}
+	}
+
+	// "config explain" prints the final config with the source of every value, and exits
+	if explain {
+		if err := explainConfig(*dryRun); err != nil {
+			fmt.Println("failed to explain config:", config.RedactError(err))
+			os.Exit(1)
+		}
+		return
+	}
+
 	consumers.DumpPinotMetricOnConsoleToDebug = true
 	httpCommand.EnableRestyDebug = true
 
+	// Startup and config reload log with slog - secret values are redacted from every line
 	logger := slog.New(
-		slog.NewJSONHandler(os.Stdout, &slog.HandlerOptions{Level: slog.LevelInfo}),
+		slog.NewJSONHandler(config.RedactingWriter(os.Stdout), &slog.HandlerOptions{Level: slog.LevelInfo}),
 	)
 	slog.SetDefault(logger)
 
-	ctx := context.Background()
-	command.FullMain(ctx, make(chan bool, 10), &base.ApplicationContext{})
-	<-ctx.Done()
+	// FullMain returns once the server is stopped e.g. on SIGTERM
+	command.FullMain(context.Background(), make(chan bool, 10), &base.ApplicationContext{})
+}
+
+func explainConfig(dryRun bool) error {
+	explain := config.Explain
+	if dryRun {
+		explain = config.ExplainDryRun
+	}
+	values, err := explain(config.GetRunEnv())
+	if err != nil {
+		return err
+	}
+	fmt.Printf("%s=%s\n\n", config.RunEnvName, config.GetRunEnv())
+	return config.WriteExplanation(os.Stdout, values)
 }
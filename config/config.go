@@ -1,11 +1,23 @@
 package config
 
 import (
-	_ "embed"
-	"github.com/TwiN/deepmerge"
+	"embed"
+	"fmt"
+	"github.com/devlibx/gox-base/v2/errors"
+	"io/fs"
 	"os"
+	"path/filepath"
+	"sort"
+	"strings"
 )
 
+// RunEnvName is the env var which decides the environment we are running in e.g. dev, stage, prod
+const RunEnvName = "DP_RUN_ENV"
+
+// ConfigDirEnvName is the env var with an (optional) comma separated list of external config files or dirs. These are
+// merged on top of the embedded config in the given order, and are watched for changes by Reloader
+const ConfigDirEnvName = "CONFIG_DIR"
+
 //go:embed app.yaml
 var ApplicationConfigBytes []byte
 
@@ -15,34 +27,222 @@ var MessagingConfig []byte
 //go:embed http.yaml
 var HttpConfigBytes []byte
 
+// configFiles holds the embedded config files, this is used to pick json sources and the per-environment overlay files.
+// Only the files named here are embedded, so another file in this dir is never merged by accident - add the name of a
+// json config or an overlay (e.g. app.prod.yaml) to the go:embed line below to ship it
+//
+//go:embed app.yaml messaging.yaml http.yaml
+var configFiles embed.FS
+
+// baseConfigNames are the names of the base config files, overlays are named <base>.<env>.yaml or <base>.<env>.json
+var baseConfigNames = []string{"app", "messaging", "http"}
+
+// configPaths are the config files or dirs given on the command line (--config)
+var configPaths []string
+
+// GetRunEnv returns the environment set in DP_RUN_ENV (empty if not set)
+func GetRunEnv() string {
+	return strings.TrimSpace(os.Getenv(RunEnvName))
+}
+
+// SetConfigPaths sets the config files or dirs given on the command line (--config). They are merged after the ones
+// from CONFIG_DIR
+func SetConfigPaths(paths ...string) {
+	configPaths = nil
+	for _, path := range paths {
+		configPaths = append(configPaths, splitPaths(path)...)
+	}
+}
+
+// GetConfigPaths returns the external config files or dirs in the order they are merged - CONFIG_DIR first and then
+// the ones set with SetConfigPaths
+func GetConfigPaths() []string {
+	return append(splitPaths(os.Getenv(ConfigDirEnvName)), configPaths...)
+}
+
+func splitPaths(paths string) []string {
+	var out []string
+	for _, path := range strings.Split(paths, ",") {
+		if path = strings.TrimSpace(path); path != "" {
+			out = append(out, path)
+		}
+	}
+	return out
+}
+
+// GetEnvExpandedMergedYamlApplicationConfig gives the merged yaml config with variables expanded (see Expand)
 func GetEnvExpandedMergedYamlApplicationConfig() (string, error) {
 	if c, err := GetMergedYamlApplicationConfig(); err == nil {
-		return os.ExpandEnv(c), nil
+		return ExpandEnv(c)
 	} else {
 		return "", err
 	}
 }
 
+// GetMergedYamlApplicationConfig merges app.yaml, messaging.yaml, http.yaml and embedded json files. After that the
+// overlays for the current DP_RUN_ENV (e.g. app.<env>.yaml) are merged on top if they exist, and at last the external
+// config files or dirs (see GetConfigPaths). The embedded config is the fallback for everything not set externally
 func GetMergedYamlApplicationConfig() (string, error) {
-	if c, err := deepmerge.YAML(ApplicationConfigBytes, MessagingConfig); err != nil {
-		return "", err
-	} else {
-		if c, err := deepmerge.YAML(c, HttpConfigBytes); err != nil {
-			return "", err
-		} else {
-			return string(c), nil
-		}
-	}
+	return getMergedApplicationConfig(FormatYaml)
 }
 
+// GetEnvExpandedMergedJsonApplicationConfig gives the merged json config with variables expanded (see Expand)
 func GetEnvExpandedMergedJsonApplicationConfig() (string, error) {
 	if c, err := GetMergedJsonApplicationConfig(); err == nil {
-		return os.ExpandEnv(c), nil
+		return ExpandEnv(c)
 	} else {
 		return "", err
 	}
 }
 
+// GetMergedJsonApplicationConfig is same as GetMergedYamlApplicationConfig, but the merged config is given as json
 func GetMergedJsonApplicationConfig() (string, error) {
-	return "", nil
+	return getMergedApplicationConfig(FormatJson)
+}
+
+func getMergedApplicationConfig(format Format) (string, error) {
+	if merged, err := mergedApplicationConfig(); err != nil {
+		return "", err
+	} else {
+		return Render(merged, format)
+	}
+}
+
+func mergedApplicationConfig() (map[string]interface{}, error) {
+	if base, overlays, err := applicationConfigSources(); err != nil {
+		return nil, err
+	} else {
+		return MergeSources(base, overlays)
+	}
+}
+
+// applicationConfigSources gives the base sources and the overlays in the order they are merged
+func applicationConfigSources() ([]Source, []Source, error) {
+	overlays, err := envOverlaySources(GetRunEnv())
+	if err != nil {
+		return nil, nil, err
+	}
+
+	external, err := externalSources(GetConfigPaths())
+	if err != nil {
+		return nil, nil, err
+	}
+
+	embedded, err := embeddedSources()
+	if err != nil {
+		return nil, nil, err
+	}
+	return embedded, append(overlays, external...), nil
+}
+
+// embeddedSources gives the base config files - yaml files first and then json files sorted by name
+func embeddedSources() ([]Source, error) {
+	sources := []Source{
+		NewSource("app.yaml", ApplicationConfigBytes),
+		NewSource("messaging.yaml", MessagingConfig),
+		NewSource("http.yaml", HttpConfigBytes),
+	}
+
+	names, err := fs.Glob(configFiles, "*.json")
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to list embedded json config files")
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		if isOverlayFile(name) {
+			continue
+		}
+		data, err := configFiles.ReadFile(name)
+		if err != nil {
+			return nil, errors.Wrap(err, "failed to read embedded config: file=%s", name)
+		}
+		sources = append(sources, NewSource(name, data))
+	}
+	return sources, nil
+}
+
+// envOverlaySources gives <base>.<env>.yaml and <base>.<env>.json files (if present) for the given env
+func envOverlaySources(env string) ([]Source, error) {
+	if env == "" {
+		return nil, nil
+	}
+
+	var sources []Source
+	for _, base := range baseConfigNames {
+		for _, format := range []Format{FormatYaml, FormatJson} {
+			name := fmt.Sprintf("%s.%s.%s", base, env, format)
+			data, err := configFiles.ReadFile(name)
+			if os.IsNotExist(err) {
+				continue
+			} else if err != nil {
+				return nil, errors.Wrap(err, "failed to read config overlay: file=%s", name)
+			}
+			sources = append(sources, NewSource(name, data))
+		}
+	}
+	return sources, nil
+}
+
+// externalSources gives the external config files in the given order. A dir gives its yaml and json files sorted by
+// name - hidden files are skipped, so the "..data" entries of a mounted kubernetes ConfigMap are not picked
+func externalSources(paths []string) ([]Source, error) {
+	var sources []Source
+	for _, path := range paths {
+		info, err := os.Stat(path)
+		if err != nil {
+			return nil, errors.Wrap(err, "failed to read config path: path=%s", path)
+		}
+
+		files := []string{path}
+		if info.IsDir() {
+			if files, err = configFilesInDir(path); err != nil {
+				return nil, err
+			}
+		}
+
+		for _, file := range files {
+			data, err := os.ReadFile(file)
+			if err != nil {
+				return nil, errors.Wrap(err, "failed to read config file: file=%s", file)
+			}
+			sources = append(sources, NewSource(file, data))
+		}
+	}
+	return sources, nil
+}
+
+func configFilesInDir(dir string) ([]string, error) {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to read config dir: dir=%s", dir)
+	}
+
+	var files []string
+	for _, entry := range entries {
+		name := entry.Name()
+		if entry.IsDir() || strings.HasPrefix(name, ".") || !isConfigFile(name) {
+			continue
+		}
+		files = append(files, filepath.Join(dir, name))
+	}
+	return files, nil
+}
+
+// isConfigFile returns true for .yaml, .yml and .json files
+func isConfigFile(name string) bool {
+	switch strings.ToLower(filepath.Ext(name)) {
+	case ".yaml", ".yml", ".json":
+		return true
+	}
+	return false
+}
+
+// isOverlayFile returns true if the file name is a per-environment overlay e.g. app.stage.json
+func isOverlayFile(name string) bool {
+	for _, base := range baseConfigNames {
+		if strings.HasPrefix(name, base+".") && strings.Count(name, ".") == 2 {
+			return true
+		}
+	}
+	return false
 }
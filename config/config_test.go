@@ -0,0 +1,126 @@
+package config
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"gopkg.in/yaml.v3"
+)
+
+func TestMergeSources_Overlay(t *testing.T) {
+	base := NewSource("app.yaml", []byte(`
+app:
+  name: test
+  http_port: 9010
+server_config:
+  servers:
+    jsonplaceholder:
+      host: jsonplaceholder.typicode.com
+      https: true
+ignore_request_headers: [ "a", "b" ]
+`))
+	overlay := NewSource("app.stage.yaml", []byte(`
+app:
+  http_port: 8080
+server_config:
+  servers:
+    jsonplaceholder:
+      host: localhost
+ignore_request_headers: [ "c" ]
+`))
+
+	result, err := MergeSources([]Source{base}, []Source{overlay})
+	assert.NoError(t, err)
+
+	app := result["app"].(map[string]interface{})
+	assert.Equal(t, "test", app["name"])
+	assert.Equal(t, 8080, app["http_port"])
+
+	server := result["server_config"].(map[string]interface{})["servers"].(map[string]interface{})["jsonplaceholder"].(map[string]interface{})
+	assert.Equal(t, "localhost", server["host"])
+	assert.Equal(t, true, server["https"])
+
+	// Lists from overlay replace the base list
+	assert.Equal(t, []interface{}{"c"}, result["ignore_request_headers"])
+}
+
+func TestMergeSources_MixedJsonAndYaml(t *testing.T) {
+	yamlSource := NewSource("app.yaml", []byte(`
+app:
+  name: test
+`))
+	jsonSource := NewSource("generated.json", []byte(`{"app": {"http_port": 9010, "ratio": 0.5}, "other": {"enabled": true}}`))
+
+	result, err := MergeSources([]Source{yamlSource, jsonSource}, nil)
+	assert.NoError(t, err)
+
+	app := result["app"].(map[string]interface{})
+	assert.Equal(t, "test", app["name"])
+	assert.Equal(t, 9010, app["http_port"])
+	assert.Equal(t, 0.5, app["ratio"])
+	assert.Equal(t, true, result["other"].(map[string]interface{})["enabled"])
+
+	// Base sources must not define same primitive twice
+	_, err = MergeSources([]Source{yamlSource, NewSource("dup.json", []byte(`{"app": {"name": "other"}}`))}, nil)
+	assert.Error(t, err)
+}
+
+func TestGetMergedYamlApplicationConfig_WithoutOverlay(t *testing.T) {
+	_ = os.Setenv(RunEnvName, "env_without_overlay")
+	defer os.Unsetenv(RunEnvName)
+
+	out, err := GetMergedYamlApplicationConfig()
+	assert.NoError(t, err)
+
+	result := map[string]interface{}{}
+	assert.NoError(t, yaml.Unmarshal([]byte(out), &result))
+	assert.Contains(t, result, "app")
+	assert.Contains(t, result, "messaging_config")
+	assert.Contains(t, result, "server_config")
+}
+
+func TestGetMergedJsonApplicationConfig(t *testing.T) {
+	out, err := GetMergedJsonApplicationConfig()
+	assert.NoError(t, err)
+
+	result := map[string]interface{}{}
+	assert.NoError(t, json.Unmarshal([]byte(out), &result))
+	assert.Contains(t, result, "app")
+	assert.Contains(t, result, "server_config")
+
+	// Files in config/ which are not named in go:embed e.g. some.json are not merged
+	assert.NotContains(t, result, "a")
+}
+
+func TestGetMergedYamlApplicationConfig_ExternalPaths(t *testing.T) {
+	dir := t.TempDir()
+	assert.NoError(t, os.WriteFile(filepath.Join(dir, "a.yaml"), []byte("server_config:\n  servers:\n    jsonplaceholder:\n      host: from-dir\n"), 0600))
+	assert.NoError(t, os.WriteFile(filepath.Join(dir, "b.json"), []byte(`{"app": {"http_port": 8081}}`), 0600))
+	assert.NoError(t, os.WriteFile(filepath.Join(dir, ".hidden.yaml"), []byte("app:\n  http_port: 1\n"), 0600))
+	assert.NoError(t, os.WriteFile(filepath.Join(dir, "notes.txt"), []byte("not a config"), 0600))
+	file := filepath.Join(t.TempDir(), "override.yaml")
+	assert.NoError(t, os.WriteFile(file, []byte("app:\n  http_port: 8082\n"), 0600))
+
+	t.Setenv(ConfigDirEnvName, dir)
+	SetConfigPaths(file)
+	defer SetConfigPaths()
+	assert.Equal(t, []string{dir, file}, GetConfigPaths())
+
+	out, err := GetMergedYamlApplicationConfig()
+	assert.NoError(t, err)
+
+	result := map[string]interface{}{}
+	assert.NoError(t, yaml.Unmarshal([]byte(out), &result))
+	server := result["server_config"].(map[string]interface{})["servers"].(map[string]interface{})["jsonplaceholder"].(map[string]interface{})
+	assert.Equal(t, "from-dir", server["host"])
+	assert.Equal(t, true, server["https"])
+	assert.Equal(t, 8082, result["app"].(map[string]interface{})["http_port"])
+
+	// Missing path is an error
+	SetConfigPaths(filepath.Join(dir, "missing.yaml"))
+	_, err = GetMergedYamlApplicationConfig()
+	assert.Error(t, err)
+}
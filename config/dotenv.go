@@ -0,0 +1,209 @@
+package config
+
+import (
+	"fmt"
+	"strings"
+)
+
+// DotEnvError is a problem in a dotenv file, with the line it was found at
+type DotEnvError struct {
+	File    string
+	Line    int
+	Message string
+}
+
+func (e *DotEnvError) Error() string {
+	return fmt.Sprintf("%s:%d: %s", e.File, e.Line, e.Message)
+}
+
+// ParseDotEnv parses dotenv content. Name is only used in errors. Supported syntax:
+//   - blank lines and lines starting with # are ignored, "export KEY=VALUE" is same as "KEY=VALUE"
+//   - KEY= gives an empty value
+//   - unquoted values are trimmed, and " # comment" at the end is removed
+//   - 'single quoted' values are used as they are, they can span multiple lines
+//   - "double quoted" values can span multiple lines and support \n, \r, \t, \", \\ and \$ escapes
+//   - unquoted and double quoted values are expanded like Expand e.g. ${DB_HOST:-localhost}. A variable is looked up
+//     with lookup first and then in the keys defined above it in the same content. A $VAR which is not set is kept
+//     as it is e.g. PASSWORD=pa$word, but an unset ${VAR} is an error
+func ParseDotEnv(name string, content string, lookup LookupFunc) (map[string]string, error) {
+	return ParseDotEnvOver(name, content, lookup, nil)
+}
+
+// ParseDotEnvOver is ParseDotEnv for a file loaded over other files - a variable which is not found with lookup or in
+// this content is looked up with base e.g. in the values of the earlier files
+func ParseDotEnvOver(name string, content string, lookup LookupFunc, base LookupFunc) (map[string]string, error) {
+	p := &dotEnvParser{
+		name:   name,
+		lines:  strings.Split(strings.ReplaceAll(content, "\r\n", "\n"), "\n"),
+		lookup: lookup,
+		base:   base,
+		values: map[string]string{},
+	}
+	for p.next < len(p.lines) {
+		if err := p.parseLine(); err != nil {
+			return nil, err
+		}
+	}
+	return p.values, nil
+}
+
+type dotEnvParser struct {
+	name   string
+	lines  []string
+	next   int
+	lookup LookupFunc
+	base   LookupFunc
+	values map[string]string
+}
+
+func (p *dotEnvParser) parseLine() error {
+	lineNo := p.next + 1
+	line := strings.TrimLeft(p.lines[p.next], " \t")
+	p.next++
+
+	if trimmed := strings.TrimSpace(line); trimmed == "" || strings.HasPrefix(trimmed, "#") {
+		return nil
+	}
+	if strings.HasPrefix(line, "export ") || strings.HasPrefix(line, "export\t") {
+		line = strings.TrimLeft(line[len("export"):], " \t")
+	}
+
+	idx := strings.IndexByte(line, '=')
+	if idx < 0 {
+		return p.error(lineNo, "expected KEY=VALUE but got [%s]", strings.TrimSpace(line))
+	}
+	key := strings.TrimSpace(line[:idx])
+	if !isValidEnvName(key) {
+		return p.error(lineNo, "invalid variable name [%s]", key)
+	}
+
+	rest := strings.TrimLeft(line[idx+1:], " \t")
+	var value string
+	var err error
+	switch {
+	case strings.HasPrefix(rest, "'"):
+		value, err = p.quoted(rest, '\'', lineNo)
+	case strings.HasPrefix(rest, `"`):
+		if value, err = p.quoted(rest, '"', lineNo); err == nil {
+			value, err = p.expand(unescapeDoubleQuoted(value), lineNo)
+		}
+	default:
+		if strings.HasPrefix(rest, "#") {
+			rest = ""
+		} else if idx := strings.Index(rest, " #"); idx >= 0 {
+			rest = rest[:idx]
+		} else if idx := strings.Index(rest, "\t#"); idx >= 0 {
+			rest = rest[:idx]
+		}
+		value, err = p.expand(strings.TrimSpace(rest), lineNo)
+	}
+	if err != nil {
+		return err
+	}
+
+	p.values[key] = value
+	return nil
+}
+
+// quoted gives the content of a quoted value starting at text (which starts with the quote). If the closing quote is
+// not on this line then the following lines are taken as part of the value
+func (p *dotEnvParser) quoted(text string, quote byte, lineNo int) (string, error) {
+	text = text[1:]
+	var value strings.Builder
+	for {
+		if end := closingQuote(text, quote); end >= 0 {
+			value.WriteString(text[:end])
+			if remaining := strings.TrimSpace(text[end+1:]); remaining != "" && !strings.HasPrefix(remaining, "#") {
+				return "", p.error(p.next, "unexpected [%s] after quoted value", remaining)
+			}
+			return value.String(), nil
+		}
+
+		if p.next >= len(p.lines) {
+			return "", p.error(lineNo, "quoted value is not closed")
+		}
+		value.WriteString(text)
+		value.WriteByte('\n')
+		text = p.lines[p.next]
+		p.next++
+	}
+}
+
+// expand expands variables - a variable not found with lookup is looked up in the values parsed so far, and then
+// with base
+func (p *dotEnvParser) expand(value string, lineNo int) (string, error) {
+	out, err := expandKeepingUnset(value, func(name string) (string, bool) {
+		if p.lookup != nil {
+			if v, ok := p.lookup(name); ok {
+				return v, true
+			}
+		}
+		if v, ok := p.values[name]; ok {
+			return v, true
+		}
+		if p.base != nil {
+			return p.base(name)
+		}
+		return "", false
+	})
+	if err != nil {
+		return "", p.error(lineNo, "%v", err)
+	}
+	return out, nil
+}
+
+func (p *dotEnvParser) error(lineNo int, format string, args ...interface{}) error {
+	return &DotEnvError{File: p.name, Line: lineNo, Message: fmt.Sprintf(format, args...)}
+}
+
+// closingQuote gives the index of the closing quote, a \" in a double quoted value does not close it
+func closingQuote(text string, quote byte) int {
+	for i := 0; i < len(text); i++ {
+		if quote == '"' && text[i] == '\\' {
+			i++
+		} else if text[i] == quote {
+			return i
+		}
+	}
+	return -1
+}
+
+// unescapeDoubleQuoted replaces escapes in a double quoted value. An escaped \$ becomes $$, so Expand keeps it as $
+func unescapeDoubleQuoted(value string) string {
+	var out strings.Builder
+	for i := 0; i < len(value); i++ {
+		if value[i] != '\\' || i+1 == len(value) {
+			out.WriteByte(value[i])
+			continue
+		}
+		i++
+		switch value[i] {
+		case 'n':
+			out.WriteByte('\n')
+		case 'r':
+			out.WriteByte('\r')
+		case 't':
+			out.WriteByte('\t')
+		case '$':
+			out.WriteString("$$")
+		case '"', '\\':
+			out.WriteByte(value[i])
+		default:
+			out.WriteByte('\\')
+			out.WriteByte(value[i])
+		}
+	}
+	return out.String()
+}
+
+func isValidEnvName(name string) bool {
+	if name == "" || !isNameStart(name[0]) {
+		return false
+	}
+	for i := 1; i < len(name); i++ {
+		if !isNameChar(name[i]) && name[i] != '.' {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,71 @@
+package config
+
+import (
+	"testing"
+
+	"github.com/devlibx/gox-base/v2/errors"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParseDotEnv(t *testing.T) {
+	content := `
+# comment
+APP_NAME=test_me
+export HTTP_PORT=9010
+  SPACED = value with spaces   # inline comment
+EMPTY=
+EMPTY_WITH_COMMENT=   # nothing here
+HASH=abc#def
+PASSWORD=Test@123$
+SINGLE='literal ${APP_NAME} # not a comment'
+DOUBLE="port=${HTTP_PORT}\tname=\"$APP_NAME\" cost=\$5"
+DEFAULT=${MISSING:-fallback}
+FROM_LOOKUP=$HOST
+UNSET=pa$word "$word"
+MULTI="line 1
+line 2"
+MULTI_SINGLE='a
+  b'
+`
+	values, err := ParseDotEnv("test.env", content, testLookup(map[string]string{"HOST": "localhost", "HTTP_PORT": "8080"}))
+	assert.NoError(t, err)
+
+	assert.Equal(t, map[string]string{
+		"APP_NAME":           "test_me",
+		"HTTP_PORT":          "9010",
+		"SPACED":             "value with spaces",
+		"EMPTY":              "",
+		"EMPTY_WITH_COMMENT": "",
+		"HASH":               "abc#def",
+		"PASSWORD":           "Test@123$",
+		"SINGLE":             "literal ${APP_NAME} # not a comment",
+		"DOUBLE":             "port=8080\tname=\"test_me\" cost=$5",
+		"DEFAULT":            "fallback",
+		"FROM_LOOKUP":        "localhost",
+		"UNSET":              "pa$word \"$word\"",
+		"MULTI":              "line 1\nline 2",
+		"MULTI_SINGLE":       "a\n  b",
+	}, values)
+}
+
+func TestParseDotEnv_Errors(t *testing.T) {
+	tests := []struct {
+		content string
+		line    int
+	}{
+		{content: "A=1\nNO_EQUALS\n", line: 2},
+		{content: "A=1\n1BAD=2\n", line: 2},
+		{content: "A=1\nB=\"not closed\nC=3\n", line: 2},
+		{content: "A='x' y\n", line: 1},
+		{content: "A=1\n\nB=${MISSING}\n", line: 3},
+	}
+	for _, test := range tests {
+		_, err := ParseDotEnv("test.env", test.content, nil)
+		assert.Error(t, err, test.content)
+
+		e, ok := errors.AsTyped[*DotEnvError](err)
+		assert.True(t, ok, test.content)
+		assert.Equal(t, test.line, e.Line, test.content)
+		assert.Contains(t, err.Error(), "test.env:", test.content)
+	}
+}
@@ -0,0 +1,222 @@
+package config
+
+import (
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+)
+
+// LookupFunc gives the value of a variable, and false if it is not set (os.LookupEnv is the default)
+type LookupFunc func(name string) (string, bool)
+
+// UnresolvedVariablesError is returned when variables used in config could not be resolved
+type UnresolvedVariablesError struct {
+	// Variables which are not set and have no default value
+	Variables []string
+
+	// Messages from the required variables e.g. ${DB_PASSWORD:?must be set}
+	Messages []string
+}
+
+func (e *UnresolvedVariablesError) Error() string {
+	var parts []string
+	if len(e.Variables) > 0 {
+		parts = append(parts, fmt.Sprintf("unresolved variables [%s]", strings.Join(e.Variables, ", ")))
+	}
+	if len(e.Messages) > 0 {
+		parts = append(parts, strings.Join(e.Messages, "; "))
+	}
+	return strings.Join(parts, "; ")
+}
+
+// ExpandEnv is same as Expand with os.LookupEnv
+func ExpandEnv(input string) (string, error) {
+	return Expand(input, os.LookupEnv)
+}
+
+// Expand replaces variables in the input. Following forms are supported:
+//
+//	$VAR, ${VAR}         value of VAR - it is an error if VAR is not set
+//	${VAR:-default}      default is used if VAR is not set or is empty
+//	${VAR-default}       default is used if VAR is not set
+//	${VAR:?message}      error with message if VAR is not set or is empty
+//	${VAR?message}       error with message if VAR is not set
+//	$$                   literal $
+//
+// A $ which is not followed by a variable name is kept as it is (e.g. "Test@123$"). All unresolved variables are
+// reported together in UnresolvedVariablesError.
+func Expand(input string, lookup LookupFunc) (string, error) {
+	e := &expander{lookup: lookup}
+	out := e.expand(input)
+	if len(e.unresolved) == 0 && len(e.messages) == 0 {
+		return out, nil
+	}
+	sort.Strings(e.unresolved)
+	return out, &UnresolvedVariablesError{Variables: e.unresolved, Messages: e.messages}
+}
+
+// expandKeepingUnset is Expand, except that a $VAR which is not set is kept as it is e.g. "pa$word". Dotenv files
+// kept such values literally before they supported variables. An unset ${VAR} is still an error
+func expandKeepingUnset(input string, lookup LookupFunc) (string, error) {
+	e := &expander{lookup: lookup, keepUnset: true}
+	out := e.expand(input)
+	if len(e.unresolved) == 0 && len(e.messages) == 0 {
+		return out, nil
+	}
+	sort.Strings(e.unresolved)
+	return out, &UnresolvedVariablesError{Variables: e.unresolved, Messages: e.messages}
+}
+
+// expander keeps the state of a single expansion, so all problems can be reported together
+type expander struct {
+	lookup     LookupFunc
+	keepUnset  bool
+	unresolved []string
+	messages   []string
+}
+
+func (e *expander) expand(input string) string {
+	if !strings.Contains(input, "$") {
+		return input
+	}
+
+	var sb strings.Builder
+	for i := 0; i < len(input); i++ {
+		if input[i] != '$' || i+1 >= len(input) {
+			sb.WriteByte(input[i])
+			continue
+		}
+
+		next := input[i+1]
+		switch {
+		case next == '$':
+			sb.WriteByte('$')
+			i++
+		case next == '{':
+			end := matchingBrace(input, i+1)
+			if end < 0 {
+				// No closing brace - keep the text as it is
+				sb.WriteString(input[i:])
+				return sb.String()
+			}
+			sb.WriteString(e.expandBraced(input[i+2 : end]))
+			i = end
+		case isNameStart(next):
+			j := i + 1
+			for j < len(input) && isNameChar(input[j]) {
+				j++
+			}
+			if name := input[i+1 : j]; e.keepUnset {
+				if value, found := e.lookup(name); found {
+					sb.WriteString(value)
+				} else {
+					sb.WriteString(input[i:j])
+				}
+			} else {
+				sb.WriteString(e.resolve(name))
+			}
+			i = j - 1
+		default:
+			sb.WriteByte('$')
+		}
+	}
+	return sb.String()
+}
+
+// expandBraced handles the content of ${...}
+func (e *expander) expandBraced(content string) string {
+	j := 0
+	for j < len(content) && isNameChar(content[j]) {
+		j++
+	}
+	name, rest := content[:j], content[j:]
+	if name == "" || !isNameStart(name[0]) {
+		// Not a variable e.g. ${} or ${1abc} - keep it as it is
+		return "${" + content + "}"
+	}
+
+	if rest == "" {
+		return e.resolve(name)
+	}
+
+	value, found := e.lookup(name)
+	switch {
+	case strings.HasPrefix(rest, ":-"):
+		if found && value != "" {
+			return value
+		}
+		return e.expand(rest[2:])
+	case strings.HasPrefix(rest, "-"):
+		if found {
+			return value
+		}
+		return e.expand(rest[1:])
+	case strings.HasPrefix(rest, ":?"):
+		if found && value != "" {
+			return value
+		}
+		e.required(name, rest[2:])
+		return ""
+	case strings.HasPrefix(rest, "?"):
+		if found {
+			return value
+		}
+		e.required(name, rest[1:])
+		return ""
+	default:
+		// Unknown modifier - keep it as it is
+		return "${" + content + "}"
+	}
+}
+
+func (e *expander) resolve(name string) string {
+	if value, found := e.lookup(name); found {
+		return value
+	}
+	if !contains(e.unresolved, name) {
+		e.unresolved = append(e.unresolved, name)
+	}
+	return ""
+}
+
+func (e *expander) required(name string, message string) {
+	if message = strings.TrimSpace(e.expand(message)); message == "" {
+		message = "must be set"
+	}
+	e.messages = append(e.messages, fmt.Sprintf("%s: %s", name, message))
+}
+
+// matchingBrace gives the index of the } which closes the { at index start (nested ${...} are skipped)
+func matchingBrace(input string, start int) int {
+	depth := 0
+	for i := start; i < len(input); i++ {
+		switch input[i] {
+		case '{':
+			depth++
+		case '}':
+			depth--
+			if depth == 0 {
+				return i
+			}
+		}
+	}
+	return -1
+}
+
+func isNameStart(c byte) bool {
+	return c == '_' || (c >= 'a' && c <= 'z') || (c >= 'A' && c <= 'Z')
+}
+
+func isNameChar(c byte) bool {
+	return isNameStart(c) || (c >= '0' && c <= '9')
+}
+
+func contains(list []string, value string) bool {
+	for _, v := range list {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
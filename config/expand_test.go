@@ -0,0 +1,89 @@
+package config
+
+import (
+	"testing"
+
+	"github.com/devlibx/gox-base/v2/errors"
+	"github.com/stretchr/testify/assert"
+)
+
+func testLookup(values map[string]string) LookupFunc {
+	return func(name string) (string, bool) {
+		v, ok := values[name]
+		return v, ok
+	}
+}
+
+func TestExpand(t *testing.T) {
+	lookup := testLookup(map[string]string{
+		"HTTP_PORT": "8080",
+		"EMPTY":     "",
+		"PASSWORD":  "Test@123$",
+		"HOST":      "localhost",
+	})
+
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{input: "$HTTP_PORT", expected: "8080"},
+		{input: "${HTTP_PORT}", expected: "8080"},
+		{input: "http://${HOST}:$HTTP_PORT/path", expected: "http://localhost:8080/path"},
+		{input: "${MISSING:-9010}", expected: "9010"},
+		{input: "${EMPTY:-9010}", expected: "9010"},
+		{input: "${EMPTY-9010}", expected: ""},
+		{input: "${MISSING-9010}", expected: "9010"},
+		{input: "${MISSING:-${HOST}}", expected: "localhost"},
+		{input: "$PASSWORD", expected: "Test@123$"},
+		{input: "Test@123$", expected: "Test@123$"},
+		{input: "Test@$$abc", expected: "Test@$abc"},
+		{input: "cost is $5", expected: "cost is $5"},
+		{input: "env:int: dev=10000; prod=5000", expected: "env:int: dev=10000; prod=5000"},
+	}
+	for _, test := range tests {
+		out, err := Expand(test.input, lookup)
+		assert.NoError(t, err, test.input)
+		assert.Equal(t, test.expected, out, test.input)
+	}
+}
+
+func TestExpand_Unresolved(t *testing.T) {
+	lookup := testLookup(map[string]string{"EMPTY": ""})
+
+	_, err := Expand("$DB_HOST:${DB_PORT} ${DB_HOST} ${EMPTY:?must not be empty} ${MISSING?}", lookup)
+	assert.Error(t, err)
+
+	e, ok := errors.AsTyped[*UnresolvedVariablesError](err)
+	assert.True(t, ok)
+	assert.Equal(t, []string{"DB_HOST", "DB_PORT"}, e.Variables)
+	assert.Equal(t, []string{"EMPTY: must not be empty", "MISSING: must be set"}, e.Messages)
+}
+
+func TestReadParameterizedConfig(t *testing.T) {
+	type testConfig struct {
+		Port     int    `yaml:"port"`
+		Password string `yaml:"password"`
+		Timeout  int    `yaml:"timeout"`
+		Enabled  bool   `yaml:"enabled"`
+	}
+
+	data := `
+port: "${TEST_READER_PORT:-9010}"
+password: $TEST_READER_PASSWORD
+timeout: "env:int: dev=10000; prod=5000; default=1000"
+enabled: ${TEST_READER_ENABLED:-true}
+`
+	t.Setenv("TEST_READER_PASSWORD", "Te#st: $abc$")
+
+	out := testConfig{}
+	assert.NoError(t, ReadParameterizedConfig(data, &out, "prod"))
+	assert.Equal(t, 9010, out.Port)
+	assert.Equal(t, "Te#st: $abc$", out.Password)
+	assert.Equal(t, 5000, out.Timeout)
+	assert.Equal(t, true, out.Enabled)
+
+	t.Setenv("TEST_READER_PASSWORD", "007")
+	assert.NoError(t, ReadParameterizedConfig(data, &out, "dev"))
+	assert.Equal(t, "007", out.Password)
+	assert.Equal(t, 10000, out.Timeout)
+}
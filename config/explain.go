@@ -0,0 +1,209 @@
+package config
+
+import (
+	"fmt"
+	"github.com/devlibx/gox-base/v2/errors"
+	"gopkg.in/yaml.v3"
+	"io"
+	"os"
+	"sort"
+	"strings"
+	"text/tabwriter"
+)
+
+// ExplainedValue tells where a leaf value of the final config came from
+type ExplainedValue struct {
+	Path         string
+	Value        string
+	RawValue     string
+	Sources      []string
+	EnvVars      []EnvVarUsage
+	EnvDirective string
+	EnvBranch    string
+	Secret       bool
+	Error        string
+}
+
+// EnvVarUsage is an env var used to fill a config value
+type EnvVarUsage struct {
+	Name  string
+	Value string
+	Set   bool
+}
+
+// Explain merges all config files like GetMergedYamlApplicationConfig, resolves the values like
+// ReadParameterizedConfig for the given env, and tells for every leaf value the config files which set it, the env
+// vars used by it and the branch picked from an "env:" directive. Secrets are redacted. Values which can not be
+// resolved are not an error here - the problem is given in ExplainedValue.Error.
+//
+// Secret references are resolved with the registered providers e.g. vault is called - see ExplainDryRun
+func Explain(env string) ([]*ExplainedValue, error) {
+	return explain(env, false)
+}
+
+// ExplainDryRun is Explain without resolving secret references, so no secret provider is called. A secret value is
+// redacted as usual, but a reference which can not be resolved is not reported
+func ExplainDryRun(env string) ([]*ExplainedValue, error) {
+	return explain(env, true)
+}
+
+func explain(env string, dryRun bool) ([]*ExplainedValue, error) {
+	base, overlays, err := applicationConfigSources()
+	if err != nil {
+		return nil, err
+	}
+
+	merged, err := MergeSources(base, overlays)
+	if err != nil {
+		return nil, err
+	}
+	origins, err := sourcesOfValues(base, overlays)
+	if err != nil {
+		return nil, err
+	}
+
+	data, err := Render(merged, FormatYaml)
+	if err != nil {
+		return nil, err
+	}
+	root := &yaml.Node{}
+	if err := yaml.Unmarshal([]byte(data), root); err != nil {
+		return nil, errors.Wrap(err, "could not parse merged yaml config")
+	}
+
+	r := &nodeResolver{lookup: os.LookupEnv, env: env, errs: &ValidationErrors{}, explain: true, dryRun: dryRun}
+	r.walk(root, "")
+
+	for _, e := range r.explained {
+		e.Sources = origins[valuePath(e.Path)]
+		e.redact()
+	}
+	sort.SliceStable(r.explained, func(i, j int) bool { return r.explained[i].Path < r.explained[j].Path })
+	return r.explained, nil
+}
+
+// WriteExplanation writes the explained values as a table - one line per leaf value
+func WriteExplanation(w io.Writer, values []*ExplainedValue) error {
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	_, _ = fmt.Fprintln(tw, "KEY\tVALUE\tSOURCE\tDETAILS")
+	for _, e := range values {
+		_, _ = fmt.Fprintf(tw, "%s\t%s\t%s\t%s\n", e.Path, e.Value, strings.Join(e.Sources, ", "), e.details())
+	}
+	return tw.Flush()
+}
+
+func (e *ExplainedValue) details() string {
+	var details []string
+	if e.RawValue != e.Value && !e.Secret && e.EnvDirective == "" && len(e.EnvVars) > 0 {
+		details = append(details, fmt.Sprintf("raw=%s", e.RawValue))
+	}
+	for _, v := range e.EnvVars {
+		if v.Set {
+			details = append(details, fmt.Sprintf("env %s=%s", v.Name, v.Value))
+		} else {
+			details = append(details, fmt.Sprintf("env %s is not set", v.Name))
+		}
+	}
+	if e.EnvDirective != "" {
+		if e.EnvBranch == "" {
+			details = append(details, fmt.Sprintf("no branch of [%s] matched, value is null", e.EnvDirective))
+		} else {
+			details = append(details, fmt.Sprintf("branch=%s of [%s]", e.EnvBranch, e.EnvDirective))
+		}
+	}
+	if e.Secret {
+		details = append(details, "secret")
+	}
+	if e.Error != "" {
+		details = append(details, "error: "+e.Error)
+	}
+	return strings.Join(details, "; ")
+}
+
+// redact hides secret values, and values of keys or env vars with a sensitive name e.g. password
+func (e *ExplainedValue) redact() {
+	key := e.Path[strings.LastIndex(e.Path, ".")+1:]
+	if e.Value != "" && e.Error == "" && (e.Secret || IsSensitiveName(key)) {
+		e.Value = RedactedValue
+	}
+	e.Value, e.RawValue = Redact(e.Value), Redact(e.RawValue)
+	for i, v := range e.EnvVars {
+		if v.Value != "" && IsSensitiveName(v.Name) {
+			e.EnvVars[i].Value = RedactedValue
+		}
+		e.EnvVars[i].Value = Redact(e.EnvVars[i].Value)
+	}
+}
+
+// sourcesOfValues gives the config files which set each value. Lists are tracked as a single value - a list is
+// appended by base sources, and replaced by an overlay
+func sourcesOfValues(base []Source, overlays []Source) (map[string][]string, error) {
+	origins := map[string][]string{}
+	for i, source := range append(append([]Source{}, base...), overlays...) {
+		m, err := source.ToMap()
+		if err != nil {
+			return nil, err
+		}
+		isOverlay := i >= len(base)
+		for _, path := range leafPaths(m, "") {
+			if !isOverlay {
+				origins[path] = append(origins[path], source.Name)
+				continue
+			}
+
+			// An overlay replaces the value, including everything below it and a value set at a parent key
+			for existing := range origins {
+				if strings.HasPrefix(existing, path+".") || strings.HasPrefix(path, existing+".") {
+					delete(origins, existing)
+				}
+			}
+			origins[path] = []string{source.Name}
+		}
+	}
+	return origins, nil
+}
+
+func leafPaths(in map[string]interface{}, parent string) []string {
+	var paths []string
+	for key, value := range in {
+		path := joinPath(parent, key)
+		if child, ok := value.(map[string]interface{}); ok && len(child) > 0 {
+			paths = append(paths, leafPaths(child, path)...)
+		} else {
+			paths = append(paths, path)
+		}
+	}
+	return paths
+}
+
+// valuePath gives the path of the value tracked by sourcesOfValues e.g. "a.list[1].b" is tracked as "a.list"
+func valuePath(path string) string {
+	if idx := strings.Index(path, "["); idx >= 0 {
+		return path[:idx]
+	}
+	return path
+}
+
+// envBranch gives the branch of a "env:<type>: dev=...; default=..." directive used for the env - it is same as
+// serialization.ParameterizedValue. Empty means no branch matched
+func envBranch(directive string, env string) string {
+	parts := strings.SplitN(directive, ":", 3)
+	if len(parts) != 3 {
+		return ""
+	}
+
+	hasDefault := false
+	for _, token := range strings.Split(parts[2], ";") {
+		key, _, _ := strings.Cut(token, "=")
+		switch strings.TrimSpace(key) {
+		case env:
+			return env
+		case "default":
+			hasDefault = true
+		}
+	}
+	if hasDefault {
+		return "default"
+	}
+	return ""
+}
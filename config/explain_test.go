@@ -0,0 +1,113 @@
+package config
+
+import (
+	"bytes"
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestExplain(t *testing.T) {
+	dir := t.TempDir()
+	assert.NoError(t, os.WriteFile(filepath.Join(dir, "db_password"), []byte("explain-secret-value"), 0600))
+	assert.NoError(t, os.WriteFile(filepath.Join(dir, "override.yaml"), []byte(`
+server_config:
+  servers:
+    jsonplaceholder:
+      host: ${TEST_EXPLAIN_HOST}
+orders_ro_mysql_config:
+  password: secret://file`+filepath.Join(dir, "db_password")+`
+  user: secret://vault/secret/data/db#user
+orders_mysql_config:
+  host: ${TEST_EXPLAIN_DB_HOST:?must be set}
+`), 0600))
+	t.Setenv(ConfigDirEnvName, filepath.Join(dir, "override.yaml"))
+	t.Setenv(RunEnvName, "")
+	t.Setenv("TEST_EXPLAIN_HOST", "localhost")
+	t.Setenv("TEST_EXPLAIN_DB_HOST", "")
+	t.Setenv("DB_PASSWORD", "Test@123$")
+	vault := stubSecretProvider(t, "vault", "explain-vault-user")
+
+	values, err := Explain("prod")
+	assert.NoError(t, err)
+
+	byPath := map[string]*ExplainedValue{}
+	for _, v := range values {
+		byPath[v.Path] = v
+	}
+
+	host := byPath["server_config.servers.jsonplaceholder.host"]
+	assert.Equal(t, "localhost", host.Value)
+	assert.Equal(t, []string{filepath.Join(dir, "override.yaml")}, host.Sources)
+	assert.Equal(t, []EnvVarUsage{{Name: "TEST_EXPLAIN_HOST", Value: "localhost", Set: true}}, host.EnvVars)
+	assert.Equal(t, []string{"http.yaml"}, byPath["server_config.servers.jsonplaceholder.https"].Sources)
+
+	timeout := byPath["app.request_read_timeout_ms"]
+	assert.Equal(t, "5000", timeout.Value)
+	assert.Equal(t, "prod", timeout.EnvBranch)
+	assert.Equal(t, "default", envBranch("env:int: dev=1; default=2", "stage"))
+	assert.Equal(t, "", envBranch("env:int: dev=1", "stage"))
+
+	password := byPath["orders_mysql_config.password"]
+	assert.Equal(t, RedactedValue, password.Value)
+	assert.Equal(t, RedactedValue, password.EnvVars[0].Value)
+
+	roPassword := byPath["orders_ro_mysql_config.password"]
+	assert.True(t, roPassword.Secret)
+	assert.Equal(t, RedactedValue, roPassword.Value)
+
+	assert.NotEmpty(t, byPath["orders_mysql_config.host"].Error)
+	assert.Equal(t, RedactedValue, byPath["orders_ro_mysql_config.user"].Value)
+	assert.Equal(t, 1, *vault)
+
+	out := &bytes.Buffer{}
+	assert.NoError(t, WriteExplanation(out, values))
+	assert.NotContains(t, out.String(), "explain-secret-value")
+	assert.NotContains(t, out.String(), "Test@123$")
+	assert.Contains(t, out.String(), "branch=prod")
+}
+
+func TestExplainDryRun(t *testing.T) {
+	dir := t.TempDir()
+	assert.NoError(t, os.WriteFile(filepath.Join(dir, "override.yaml"), []byte(`
+orders_ro_mysql_config:
+  password: secret://vault/secret/data/db#password
+`), 0600))
+	t.Setenv(ConfigDirEnvName, filepath.Join(dir, "override.yaml"))
+	vault := stubSecretProvider(t, "vault", "explain-vault-password")
+
+	values, err := ExplainDryRun("prod")
+	assert.NoError(t, err)
+	for _, v := range values {
+		if v.Path == "orders_ro_mysql_config.password" {
+			assert.True(t, v.Secret)
+			assert.Equal(t, RedactedValue, v.Value)
+			assert.Empty(t, v.Error)
+		}
+	}
+	assert.Equal(t, 0, *vault)
+}
+
+// stubSecretProvider replaces a secret provider for the test, and counts the secrets it resolves
+func stubSecretProvider(t *testing.T, name string, value string) *int {
+	secretProvidersLock.RLock()
+	original := secretProviders[name]
+	secretProvidersLock.RUnlock()
+	t.Cleanup(func() { RegisterSecretProvider(name, original) })
+
+	calls := 0
+	RegisterSecretProvider(name, secretProviderFunc(func(ctx context.Context, path string, key string) (string, error) {
+		calls++
+		return value, nil
+	}))
+	return &calls
+}
+
+type secretProviderFunc func(ctx context.Context, path string, key string) (string, error)
+
+func (f secretProviderFunc) Resolve(ctx context.Context, path string, key string) (string, error) {
+	return f(ctx, path, key)
+}
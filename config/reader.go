@@ -0,0 +1,165 @@
+package config
+
+import (
+	"context"
+	"fmt"
+	"github.com/devlibx/gox-base/v2/errors"
+	"github.com/devlibx/gox-base/v2/serialization"
+	"gopkg.in/yaml.v3"
+	"os"
+	"strconv"
+	"strings"
+)
+
+// ReadParameterizedConfig is a replacement of serialization.ReadParameterizedYaml. It reads the (not env expanded)
+// yaml config into the object:
+//  1. variables in every value are expanded using Expand - so a value from env var is never expanded again, and
+//     special chars (e.g. $, #, :) in a value can not break the yaml
+//  2. "env:<type>: dev=...; prod=..." values are resolved for the given env
+//  3. secret references e.g. secret://file/etc/secrets/db_password are resolved using registered SecretProvider
+//
+// All unresolved variables and secrets are reported together with the path of the config key which uses them.
+func ReadParameterizedConfig(data string, object interface{}, env string) error {
+	root := &yaml.Node{}
+	if err := yaml.Unmarshal([]byte(data), root); err != nil {
+		return errors.Wrap(err, "could not parse yaml config")
+	}
+
+	r := &nodeResolver{lookup: os.LookupEnv, env: env, errs: &ValidationErrors{}}
+	r.walk(root, "")
+	if err := r.errs.Err(); err != nil {
+		return err
+	}
+
+	if err := root.Decode(object); err != nil {
+		return errors.Wrap(err, "could not build config object from yaml config")
+	}
+	return nil
+}
+
+// nodeResolver expands variables and resolves "env:" values in a yaml node tree. If explain is set then every scalar
+// is recorded in explained, with the env vars and "env:" branch used for it
+type nodeResolver struct {
+	lookup    LookupFunc
+	env       string
+	errs      *ValidationErrors
+	explain   bool
+	explained []*ExplainedValue
+
+	// dryRun keeps secret references as they are, so no secret provider is called
+	dryRun bool
+}
+
+func (r *nodeResolver) walk(node *yaml.Node, path string) {
+	switch node.Kind {
+	case yaml.DocumentNode:
+		for _, child := range node.Content {
+			r.walk(child, path)
+		}
+	case yaml.MappingNode:
+		for i := 0; i+1 < len(node.Content); i += 2 {
+			r.walk(node.Content[i+1], joinPath(path, node.Content[i].Value))
+		}
+	case yaml.SequenceNode:
+		for i, child := range node.Content {
+			r.walk(child, fmt.Sprintf("%s[%d]", path, i))
+		}
+	case yaml.ScalarNode:
+		if !r.explain {
+			r.resolveScalar(node, path, nil)
+		} else {
+			e := &ExplainedValue{Path: path, RawValue: node.Value}
+			r.resolveScalar(node, path, e)
+			e.Value = node.Value
+			r.explained = append(r.explained, e)
+		}
+
+		// A value of a key like "password" is a secret even if it is not a secret reference e.g. from an env var
+		if IsSensitiveName(path[strings.LastIndex(path, ".")+1:]) && !IsSecretReference(node.Value) {
+			MarkSecret(node.Value)
+		}
+	}
+}
+
+func (r *nodeResolver) resolveScalar(node *yaml.Node, path string, e *ExplainedValue) {
+	if node.Tag != "" && node.Tag != "!!str" {
+		// Only string values can have variables or "env:" directives
+		return
+	}
+
+	lookup := r.lookup
+	if e != nil {
+		lookup = func(name string) (string, bool) {
+			value, ok := r.lookup(name)
+			e.EnvVars = append(e.EnvVars, EnvVarUsage{Name: name, Value: value, Set: ok})
+			return value, ok
+		}
+	}
+
+	value, err := Expand(node.Value, lookup)
+	if err != nil {
+		r.errs.Add(path, "%v", err)
+		if e != nil {
+			e.Error = err.Error()
+		}
+		return
+	}
+	if value != node.Value {
+		// A value with variables is typed again after expansion e.g. "http_port: ${HTTP_PORT:-9010}" must become an
+		// int. This is needed because merge writes such values quoted. String fields still get the exact value.
+		setScalar(node, "", value)
+	}
+
+	if trimmed := strings.TrimSpace(node.Value); strings.HasPrefix(trimmed, "env:") {
+		if e != nil {
+			e.EnvDirective, e.EnvBranch = trimmed, envBranch(trimmed, r.env)
+		}
+		resolved, err := serialization.ParameterizedValue(trimmed).Get(r.env)
+		if err != nil {
+			// Same as serialization.ReadParameterizedYaml - a value not found for the env is set to null
+			setScalar(node, "!!null", "")
+			return
+		}
+		switch v := resolved.(type) {
+		case int:
+			setScalar(node, "!!int", strconv.Itoa(v))
+		case bool:
+			setScalar(node, "!!bool", strconv.FormatBool(v))
+		case float64:
+			setScalar(node, "!!float", strconv.FormatFloat(v, 'f', -1, 64))
+		default:
+			setScalar(node, "!!str", fmt.Sprintf("%v", v))
+		}
+	}
+
+	if IsSecretReference(node.Value) {
+		if e != nil {
+			e.Secret = true
+		}
+		if r.dryRun {
+			return
+		}
+		secret, err := ResolveSecret(context.Background(), node.Value)
+		if err != nil {
+			r.errs.Add(path, "%v", err)
+			if e != nil {
+				e.Error = err.Error()
+			}
+			return
+		}
+		setScalar(node, "", secret)
+	}
+}
+
+func setScalar(node *yaml.Node, tag string, value string) {
+	node.Tag = tag
+	node.Value = value
+	node.Style = 0
+}
+
+func joinPath(parent string, key string) string {
+	if parent == "" {
+		return key
+	}
+	return parent + "." + key
+}
@@ -0,0 +1,92 @@
+package config
+
+import (
+	"go.uber.org/zap"
+	"go.uber.org/zap/buffer"
+	"go.uber.org/zap/zapcore"
+	"io"
+	"sync"
+)
+
+// RedactedEncoding is the zap encoding of RedactingEncoder - set it as zap.Config.Encoding, instead of "json"
+const RedactedEncoding = "redacted-json"
+
+// RedactedConsoleEncoding is RedactedEncoding for the "console" encoding of zap
+const RedactedConsoleEncoding = "redacted-console"
+
+var registerEncodersOnce sync.Once
+
+// RegisterRedactingEncoders registers RedactedEncoding and RedactedConsoleEncoding with zap
+func RegisterRedactingEncoders() {
+	registerEncodersOnce.Do(func() {
+		_ = zap.RegisterEncoder(RedactedEncoding, func(c zapcore.EncoderConfig) (zapcore.Encoder, error) {
+			return RedactingEncoder(zapcore.NewJSONEncoder(c)), nil
+		})
+		_ = zap.RegisterEncoder(RedactedConsoleEncoding, func(c zapcore.EncoderConfig) (zapcore.Encoder, error) {
+			return RedactingEncoder(zapcore.NewConsoleEncoder(c)), nil
+		})
+	})
+}
+
+// RedactingEncoder gives a zap encoder which runs Redact on every encoded log line - message, fields and errors
+func RedactingEncoder(encoder zapcore.Encoder) zapcore.Encoder {
+	return &redactingEncoder{Encoder: encoder}
+}
+
+type redactingEncoder struct {
+	zapcore.Encoder
+}
+
+func (e *redactingEncoder) Clone() zapcore.Encoder {
+	return &redactingEncoder{Encoder: e.Encoder.Clone()}
+}
+
+func (e *redactingEncoder) EncodeEntry(entry zapcore.Entry, fields []zapcore.Field) (*buffer.Buffer, error) {
+	line, err := e.Encoder.EncodeEntry(entry, fields)
+	if err != nil {
+		return nil, err
+	}
+	if redacted := Redact(line.String()); redacted != line.String() {
+		line.Reset()
+		line.AppendString(redacted)
+	}
+	return line, nil
+}
+
+// RedactingWriter gives a writer which runs Redact on everything written to w e.g. the output of a slog handler, which
+// writes one log line at a time
+func RedactingWriter(w io.Writer) io.Writer {
+	return &redactingWriter{w: w}
+}
+
+type redactingWriter struct {
+	w io.Writer
+}
+
+func (r *redactingWriter) Write(p []byte) (int, error) {
+	if _, err := io.WriteString(r.w, Redact(string(p))); err != nil {
+		return 0, err
+	}
+	return len(p), nil
+}
+
+// RedactError gives an error with the message of err after Redact, e.g. to print a startup error. errors.Is and
+// errors.As still see err
+func RedactError(err error) error {
+	if err == nil {
+		return nil
+	}
+	return &redactedError{err: err}
+}
+
+type redactedError struct {
+	err error
+}
+
+func (r *redactedError) Error() string {
+	return Redact(r.err.Error())
+}
+
+func (r *redactedError) Unwrap() error {
+	return r.err
+}
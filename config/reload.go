@@ -0,0 +1,221 @@
+package config
+
+import (
+	"context"
+	"github.com/devlibx/gox-base/v2/errors"
+	"log/slog"
+	"reflect"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// BuildFunc builds (and validates) the config object from the merged yaml config
+type BuildFunc[T any] func(data string) (*T, error)
+
+// Reloader holds the current config and re-reads it when external config files (see GetConfigPaths) change. Only the
+// reloadable sections (e.g. "logger" or "server_config.apis") are taken from the changed config, and the subscribers of
+// a changed section are called with the new config. Changes in other sections are logged and ignored - they need a restart.
+//
+// The config object given to subscribers differs from the running config only in the reloadable sections, so a
+// subscriber must only read the section it subscribed to.
+type Reloader[T any] struct {
+	sections    []string
+	build       BuildFunc[T]
+	lock        *sync.Mutex
+	current     atomic.Pointer[T]
+	applied     map[string]interface{}
+	seen        map[string]interface{}
+	subscribers map[string][]func(*T)
+	cancel      context.CancelFunc
+	done        chan struct{}
+}
+
+// NewReloader merges all config files and builds the initial config. Sections are dot separated paths of the config
+// which are safe to change at runtime e.g. "logger", "server_config.apis"
+func NewReloader[T any](sections []string, build BuildFunc[T]) (*Reloader[T], error) {
+	merged, err := mergedApplicationConfig()
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to generate merged application config")
+	}
+
+	r := &Reloader[T]{
+		sections:    sections,
+		build:       build,
+		lock:        &sync.Mutex{},
+		applied:     merged,
+		seen:        merged,
+		subscribers: map[string][]func(*T){},
+	}
+
+	value, err := r.buildFromMap(merged)
+	if err != nil {
+		return nil, err
+	}
+	r.current.Store(value)
+	return r, nil
+}
+
+// Current gives the current config
+func (r *Reloader[T]) Current() *T {
+	return r.current.Load()
+}
+
+// Subscribe registers a subscriber which is called with the new config when the given section changes
+func (r *Reloader[T]) Subscribe(section string, subscriber func(*T)) error {
+	if !contains(r.sections, section) {
+		return errors.New("config section is not reloadable: section=%s reloadable=%v", section, r.sections)
+	}
+
+	r.lock.Lock()
+	defer r.lock.Unlock()
+	r.subscribers[section] = append(r.subscribers[section], subscriber)
+	return nil
+}
+
+// Start checks the external config files for changes at the given interval. It does nothing if there are no external
+// config files
+func (r *Reloader[T]) Start(interval time.Duration) {
+	paths := GetConfigPaths()
+	if len(paths) == 0 || r.cancel != nil {
+		return
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	r.cancel, r.done = cancel, make(chan struct{})
+	slog.Info("watching config files for changes", "paths", paths, "interval", interval, "reloadable_sections", r.sections)
+
+	go func() {
+		defer close(r.done)
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+				if _, err := r.Reload(); err != nil {
+					slog.Error("failed to reload config", "error", err)
+				}
+			}
+		}
+	}()
+}
+
+// Stop stops watching the external config files
+func (r *Reloader[T]) Stop() {
+	if r.cancel != nil {
+		r.cancel()
+		<-r.done
+		r.cancel = nil
+	}
+}
+
+// Reload re-reads all config files once. If reloadable sections changed, the new config is built and validated, and
+// the subscribers of the changed sections are called. It returns the changed sections.
+//
+// If the new config is not valid then the current config is kept, and the same files are not tried again until they
+// change.
+func (r *Reloader[T]) Reload() ([]string, error) {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+
+	merged, err := mergedApplicationConfig()
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to generate merged application config")
+	}
+	if reflect.DeepEqual(merged, r.seen) {
+		return nil, nil
+	}
+	r.seen = merged
+
+	if !reflect.DeepEqual(r.withoutSections(merged), r.withoutSections(r.applied)) {
+		slog.Warn("config changed outside of reloadable sections, these changes need a restart", "reloadable_sections", r.sections)
+	}
+
+	// Only reloadable sections are taken from the new config
+	candidate := r.applied
+	var changed []string
+	for _, section := range r.sections {
+		if !reflect.DeepEqual(sectionValue(merged, section), sectionValue(r.applied, section)) {
+			changed = append(changed, section)
+			candidate = withSection(candidate, merged, strings.Split(section, "."))
+		}
+	}
+	if len(changed) == 0 {
+		return nil, nil
+	}
+
+	value, err := r.buildFromMap(candidate)
+	if err != nil {
+		return nil, errors.Wrap(err, "reloaded config is not valid, current config is kept: changed_sections=%v", changed)
+	}
+	r.current.Store(value)
+	r.applied = candidate
+
+	slog.Info("config reloaded", "changed_sections", changed)
+	for _, section := range changed {
+		for _, subscriber := range r.subscribers[section] {
+			subscriber(value)
+		}
+	}
+	return changed, nil
+}
+
+func (r *Reloader[T]) buildFromMap(merged map[string]interface{}) (*T, error) {
+	data, err := Render(merged, FormatYaml)
+	if err != nil {
+		return nil, err
+	}
+	return r.build(data)
+}
+
+// withoutSections gives a copy of the config with all reloadable sections removed
+func (r *Reloader[T]) withoutSections(in map[string]interface{}) map[string]interface{} {
+	for _, section := range r.sections {
+		in = withSection(in, nil, strings.Split(section, "."))
+	}
+	return in
+}
+
+// sectionValue gives the value at a dot separated path (nil if not present)
+func sectionValue(in map[string]interface{}, section string) interface{} {
+	var value interface{} = in
+	for _, key := range strings.Split(section, ".") {
+		m, ok := value.(map[string]interface{})
+		if !ok {
+			return nil
+		}
+		value = m[key]
+	}
+	return value
+}
+
+// withSection gives a copy of "in" where the value at path is taken from "from" (or removed if "from" does not have
+// it). Only the maps on the path are copied, "in" is not modified
+func withSection(in map[string]interface{}, from map[string]interface{}, path []string) map[string]interface{} {
+	out := make(map[string]interface{}, len(in))
+	for k, v := range in {
+		out[k] = v
+	}
+
+	key := path[0]
+	fromValue, fromOk := from[key]
+	if len(path) == 1 {
+		if fromOk {
+			out[key] = fromValue
+		} else {
+			delete(out, key)
+		}
+		return out
+	}
+
+	child, _ := in[key].(map[string]interface{})
+	fromChild, _ := fromValue.(map[string]interface{})
+	if child == nil && fromChild == nil {
+		return out
+	}
+	out[key] = withSection(child, fromChild, path[1:])
+	return out
+}
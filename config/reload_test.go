@@ -0,0 +1,79 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/devlibx/gox-base/v2/errors"
+	"github.com/stretchr/testify/assert"
+	"gopkg.in/yaml.v3"
+)
+
+type reloadTestConfig struct {
+	App struct {
+		HttpPort int `yaml:"http_port"`
+	} `yaml:"app"`
+	Logger struct {
+		Level string `yaml:"level"`
+	} `yaml:"logger"`
+}
+
+func buildReloadTestConfig(data string) (*reloadTestConfig, error) {
+	out := &reloadTestConfig{}
+	if err := yaml.Unmarshal([]byte(data), out); err != nil {
+		return nil, err
+	}
+	if out.Logger.Level == "invalid" {
+		return nil, errors.New("invalid logger level")
+	}
+	return out, nil
+}
+
+func TestReloader(t *testing.T) {
+	dir := t.TempDir()
+	t.Setenv(ConfigDirEnvName, dir)
+	assert.NoError(t, os.WriteFile(filepath.Join(dir, "app.yaml"), []byte("app:\n  http_port: 8080\nlogger:\n  level: info\n"), 0600))
+
+	r, err := NewReloader([]string{"logger"}, buildReloadTestConfig)
+	assert.NoError(t, err)
+	assert.Equal(t, 8080, r.Current().App.HttpPort)
+	assert.Equal(t, "info", r.Current().Logger.Level)
+
+	var received []string
+	assert.NoError(t, r.Subscribe("logger", func(c *reloadTestConfig) { received = append(received, c.Logger.Level) }))
+	assert.Error(t, r.Subscribe("app", func(c *reloadTestConfig) {}))
+
+	// Nothing changed
+	changed, err := r.Reload()
+	assert.NoError(t, err)
+	assert.Empty(t, changed)
+
+	// Reloadable section is applied, other sections are kept as is
+	assert.NoError(t, os.WriteFile(filepath.Join(dir, "app.yaml"), []byte("app:\n  http_port: 9090\nlogger:\n  level: warn\n"), 0600))
+	changed, err = r.Reload()
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"logger"}, changed)
+	assert.Equal(t, []string{"warn"}, received)
+	assert.Equal(t, "warn", r.Current().Logger.Level)
+	assert.Equal(t, 8080, r.Current().App.HttpPort)
+
+	// Invalid config is not applied
+	assert.NoError(t, os.WriteFile(filepath.Join(dir, "app.yaml"), []byte("logger:\n  level: invalid\n"), 0600))
+	_, err = r.Reload()
+	assert.Error(t, err)
+	assert.Equal(t, "warn", r.Current().Logger.Level)
+	assert.Equal(t, []string{"warn"}, received)
+}
+
+func TestWithSection(t *testing.T) {
+	in := map[string]interface{}{"server_config": map[string]interface{}{"servers": "a", "apis": "b"}}
+	from := map[string]interface{}{"server_config": map[string]interface{}{"servers": "c", "apis": "d"}}
+
+	out := withSection(in, from, []string{"server_config", "apis"})
+	assert.Equal(t, map[string]interface{}{"server_config": map[string]interface{}{"servers": "a", "apis": "d"}}, out)
+	assert.Equal(t, "b", sectionValue(in, "server_config.apis"))
+
+	out = withSection(in, nil, []string{"server_config", "apis"})
+	assert.Equal(t, map[string]interface{}{"server_config": map[string]interface{}{"servers": "a"}}, out)
+}
@@ -0,0 +1,168 @@
+package config
+
+import (
+	"context"
+	"github.com/devlibx/gox-base/v2/errors"
+	"log/slog"
+	"sort"
+	"strings"
+	"sync"
+	"sync/atomic"
+)
+
+// SecretScheme is the prefix of a secret reference e.g. secret://file/etc/secrets/db_password
+const SecretScheme = "secret://"
+
+// RedactedValue is used in place of a secret value in logs
+const RedactedValue = "******"
+
+// MinSecretLength is the length of the shortest value which MarkSecret remembers
+const MinSecretLength = 6
+
+// SecretProvider resolves a secret reference. A reference secret://<provider>/<path>#<key> is given to the provider
+// registered as <provider> with path="/<path>" and key="<key>" (key is empty if not given)
+type SecretProvider interface {
+	Resolve(ctx context.Context, path string, key string) (string, error)
+}
+
+var (
+	secretProvidersLock = &sync.RWMutex{}
+	secretProviders     = map[string]SecretProvider{
+		"file":     &fileSecretProvider{},
+		"env-file": &envFileSecretProvider{},
+		"vault":    &httpSecretProvider{},
+	}
+
+	// secretValues holds all secret values resolved so far - these are redacted by Redact with secretReplacer, which
+	// is built again when a value is added
+	secretValuesLock = &sync.RWMutex{}
+	secretValues     = map[string]struct{}{}
+	secretReplacer   atomic.Pointer[strings.Replacer]
+)
+
+// RegisterSecretProvider registers (or replaces) a secret provider with the given name
+func RegisterSecretProvider(name string, provider SecretProvider) {
+	secretProvidersLock.Lock()
+	defer secretProvidersLock.Unlock()
+	secretProviders[name] = provider
+}
+
+// IsSecretReference returns true if the value is a secret reference e.g. secret://vault/db#password
+func IsSecretReference(value string) bool {
+	return strings.HasPrefix(strings.TrimSpace(value), SecretScheme)
+}
+
+// ResolveSecret resolves a secret reference using the registered provider. The resolved value is remembered as a
+// secret, so Redact will never let it go to logs
+func ResolveSecret(ctx context.Context, reference string) (string, error) {
+	name, path, key, err := parseSecretReference(reference)
+	if err != nil {
+		return "", err
+	}
+
+	secretProvidersLock.RLock()
+	provider, ok := secretProviders[name]
+	secretProvidersLock.RUnlock()
+	if !ok {
+		return "", errors.New("no secret provider registered with name [%s]", name)
+	}
+
+	value, err := provider.Resolve(ctx, path, key)
+	if err != nil {
+		return "", errors.Wrap(err, "failed to resolve secret: provider=%s path=%s key=%s", name, path, key)
+	}
+
+	MarkSecret(value)
+	return value, nil
+}
+
+// MarkSecret remembers the value as a secret, so Redact will never let it go to logs. Values shorter than
+// MinSecretLength are not remembered - redacting e.g. every "1" would make the logs useless
+func MarkSecret(value string) {
+	if len(value) < MinSecretLength {
+		return
+	}
+	secretValuesLock.Lock()
+	defer secretValuesLock.Unlock()
+	if _, ok := secretValues[value]; ok {
+		return
+	}
+	secretValues[value] = struct{}{}
+
+	// Replace longer values first, so a secret which contains another secret is fully redacted
+	values := make([]string, 0, len(secretValues))
+	for v := range secretValues {
+		values = append(values, v)
+	}
+	sort.Slice(values, func(i, j int) bool { return len(values[i]) > len(values[j]) })
+	pairs := make([]string, 0, 2*len(values))
+	for _, v := range values {
+		pairs = append(pairs, v, RedactedValue)
+	}
+	secretReplacer.Store(strings.NewReplacer(pairs...))
+}
+
+// IsSecret returns true if the value was resolved from a secret reference or marked as secret
+func IsSecret(value string) bool {
+	secretValuesLock.RLock()
+	defer secretValuesLock.RUnlock()
+	_, ok := secretValues[value]
+	return ok
+}
+
+// Redact replaces all known secret values in the input with RedactedValue. The loggers of the application redact
+// every line with it - see RedactingWriter and RedactingEncoder
+func Redact(input string) string {
+	if replacer := secretReplacer.Load(); replacer != nil {
+		return replacer.Replace(input)
+	}
+	return input
+}
+
+// IsSensitiveName returns true if a key or env var name looks like it holds a secret e.g. DB_PASSWORD, x-access-token
+func IsSensitiveName(name string) bool {
+	name = strings.ToLower(name)
+	for _, marker := range []string{"password", "secret", "token", "credential", "private_key", "api_key"} {
+		if strings.Contains(name, marker) {
+			return true
+		}
+	}
+	return false
+}
+
+// Secret is a string which is never printed - use it for secret fields in config structs. String(), json and slog
+// give RedactedValue, use Value() to get the actual value
+type Secret string
+
+func (s Secret) Value() string {
+	return string(s)
+}
+
+func (s Secret) String() string {
+	if s == "" {
+		return ""
+	}
+	return RedactedValue
+}
+
+func (s Secret) MarshalJSON() ([]byte, error) {
+	return []byte(`"` + s.String() + `"`), nil
+}
+
+func (s Secret) LogValue() slog.Value {
+	return slog.StringValue(s.String())
+}
+
+// parseSecretReference splits secret://<provider>/<path>#<key> into its parts
+func parseSecretReference(reference string) (name string, path string, key string, err error) {
+	rest := strings.TrimPrefix(strings.TrimSpace(reference), SecretScheme)
+	if idx := strings.LastIndex(rest, "#"); idx >= 0 {
+		rest, key = rest[:idx], rest[idx+1:]
+	}
+
+	idx := strings.Index(rest, "/")
+	if idx <= 0 || idx == len(rest)-1 {
+		return "", "", "", errors.New("secret reference must be in secret://<provider>/<path>#<key> format: reference=%s", reference)
+	}
+	return rest[:idx], rest[idx:], key, nil
+}
@@ -0,0 +1,134 @@
+package config
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"github.com/devlibx/gox-base/v2/errors"
+	"io"
+	"net/http"
+	"os"
+	"strings"
+	"time"
+)
+
+// fileSecretProvider reads the secret from a file e.g. secret://file/etc/secrets/db_password. If a key is given
+// (secret://file/etc/secrets/db.json#password) then the file must be a json object and the key is picked from it
+type fileSecretProvider struct {
+}
+
+func (f *fileSecretProvider) Resolve(ctx context.Context, path string, key string) (string, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return "", err
+	}
+	if key == "" {
+		return strings.TrimRight(string(data), "\r\n"), nil
+	}
+	return pickSecretKey(data, key)
+}
+
+// envFileSecretProvider reads a key from a dotenv file (see ParseDotEnv) e.g.
+// secret://env-file/etc/secrets/db.env#DB_PASSWORD
+type envFileSecretProvider struct {
+}
+
+func (e *envFileSecretProvider) Resolve(ctx context.Context, path string, key string) (string, error) {
+	if key == "" {
+		return "", errors.New("key is required for env-file secret e.g. secret://env-file/etc/secrets/db.env#DB_PASSWORD")
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return "", err
+	}
+
+	values, err := ParseDotEnv(path, string(data), os.LookupEnv)
+	if err != nil {
+		return "", err
+	}
+	if value, ok := values[key]; ok {
+		return value, nil
+	}
+	return "", errors.New("key not found in env file: key=%s", key)
+}
+
+// httpSecretProvider reads secrets over http from a vault like server e.g. secret://vault/secret/data/db#password
+// calls GET <VAULT_ADDR>/v1/secret/data/db with X-Vault-Token=<VAULT_TOKEN> and picks "password". The response can be
+// in vault kv v2 ({"data": {"data": {...}}}), kv v1 ({"data": {...}}) or plain json object format, so a local stub
+// server can stand in for vault during development.
+type httpSecretProvider struct {
+	Address string
+	Token   string
+	Client  *http.Client
+}
+
+// NewHttpSecretProvider creates a http secret provider - if address is empty then VAULT_ADDR and VAULT_TOKEN env
+// vars are used
+func NewHttpSecretProvider(address string, token string) SecretProvider {
+	return &httpSecretProvider{Address: address, Token: token}
+}
+
+func (h *httpSecretProvider) Resolve(ctx context.Context, path string, key string) (string, error) {
+	address, token := h.Address, h.Token
+	if address == "" {
+		address, token = os.Getenv("VAULT_ADDR"), os.Getenv("VAULT_TOKEN")
+	}
+	if address == "" {
+		return "", errors.New("VAULT_ADDR is not set - it is needed to resolve vault secrets")
+	}
+	client := h.Client
+	if client == nil {
+		client = &http.Client{Timeout: 5 * time.Second}
+	}
+
+	url := fmt.Sprintf("%s/v1%s", strings.TrimRight(address, "/"), path)
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return "", err
+	}
+	if token != "" {
+		req.Header.Set("X-Vault-Token", token)
+	}
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return "", err
+	}
+	defer resp.Body.Close()
+
+	data, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return "", err
+	}
+	if resp.StatusCode != http.StatusOK {
+		return "", errors.New("secret server returned status=%d for path=%s", resp.StatusCode, path)
+	}
+	if key == "" {
+		return strings.TrimRight(string(data), "\r\n"), nil
+	}
+	return pickSecretKey(data, key)
+}
+
+// pickSecretKey picks a key from a json object. Vault kv v2 ({"data": {"data": {...}}}) and kv v1 ({"data": {...}})
+// responses are also supported
+func pickSecretKey(data []byte, key string) (string, error) {
+	obj := map[string]interface{}{}
+	if err := json.Unmarshal(data, &obj); err != nil {
+		return "", errors.Wrap(err, "secret is not a json object, failed to pick key=%s", key)
+	}
+
+	for _, candidate := range []map[string]interface{}{obj, nestedMap(obj, "data"), nestedMap(nestedMap(obj, "data"), "data")} {
+		if value, ok := candidate[key]; ok && value != nil {
+			return fmt.Sprintf("%v", value), nil
+		}
+	}
+	return "", errors.New("key not found in secret: key=%s", key)
+}
+
+func nestedMap(in map[string]interface{}, key string) map[string]interface{} {
+	if m, ok := in[key].(map[string]interface{}); ok {
+		return m
+	}
+	return map[string]interface{}{}
+}
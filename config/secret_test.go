@@ -0,0 +1,132 @@
+package config
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+)
+
+func TestResolveSecret_File(t *testing.T) {
+	dir := t.TempDir()
+	assert.NoError(t, os.WriteFile(filepath.Join(dir, "db_password"), []byte("Test@123$\n"), 0600))
+	assert.NoError(t, os.WriteFile(filepath.Join(dir, "db.json"), []byte(`{"password": "from-json"}`), 0600))
+	assert.NoError(t, os.WriteFile(filepath.Join(dir, "db.env"), []byte("# comment\nexport DB_PASSWORD=\"from-env-file\"\n"), 0600))
+
+	value, err := ResolveSecret(context.Background(), "secret://file"+filepath.Join(dir, "db_password"))
+	assert.NoError(t, err)
+	assert.Equal(t, "Test@123$", value)
+
+	value, err = ResolveSecret(context.Background(), "secret://file"+filepath.Join(dir, "db.json")+"#password")
+	assert.NoError(t, err)
+	assert.Equal(t, "from-json", value)
+
+	value, err = ResolveSecret(context.Background(), "secret://env-file"+filepath.Join(dir, "db.env")+"#DB_PASSWORD")
+	assert.NoError(t, err)
+	assert.Equal(t, "from-env-file", value)
+
+	_, err = ResolveSecret(context.Background(), "secret://unknown/some/path")
+	assert.Error(t, err)
+}
+
+func TestResolveSecret_Vault(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/v1/secret/data/db" || r.Header.Get("X-Vault-Token") != "test-token" {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		_ = json.NewEncoder(w).Encode(map[string]interface{}{"data": map[string]interface{}{"data": map[string]interface{}{"password": "from-vault"}}})
+	}))
+	defer server.Close()
+
+	t.Setenv("VAULT_ADDR", server.URL)
+	t.Setenv("VAULT_TOKEN", "test-token")
+
+	value, err := ResolveSecret(context.Background(), "secret://vault/secret/data/db#password")
+	assert.NoError(t, err)
+	assert.Equal(t, "from-vault", value)
+
+	_, err = ResolveSecret(context.Background(), "secret://vault/secret/data/missing#password")
+	assert.Error(t, err)
+}
+
+func TestReadParameterizedConfig_WithSecret(t *testing.T) {
+	type testConfig struct {
+		User     string `yaml:"user"`
+		Password string `yaml:"password"`
+	}
+
+	dir := t.TempDir()
+	assert.NoError(t, os.WriteFile(filepath.Join(dir, "db_password"), []byte("super-secret-value"), 0600))
+	t.Setenv("TEST_SECRET_DIR", dir)
+
+	out := testConfig{}
+	err := ReadParameterizedConfig("user: test\npassword: secret://file${TEST_SECRET_DIR}/db_password\n", &out, "")
+	assert.NoError(t, err)
+	assert.Equal(t, "super-secret-value", out.Password)
+	assert.True(t, IsSecret("super-secret-value"))
+	assert.Equal(t, "password=******", Redact("password=super-secret-value"))
+
+	err = ReadParameterizedConfig("password: secret://file${TEST_SECRET_DIR}/missing\n", &out, "")
+	assert.Error(t, err)
+	assert.Contains(t, err.Error(), "password:")
+}
+
+func TestSecret(t *testing.T) {
+	s := Secret("my-password")
+	assert.Equal(t, "my-password", s.Value())
+	assert.Equal(t, RedactedValue, s.String())
+
+	data, err := json.Marshal(map[string]interface{}{"password": s})
+	assert.NoError(t, err)
+	assert.Equal(t, `{"password":"******"}`, string(data))
+}
+
+func TestMarkSecret(t *testing.T) {
+	// Short values are not redacted, they would hide every number or word in the logs
+	MarkSecret("abc12")
+	assert.False(t, IsSecret("abc12"))
+	assert.Equal(t, "code=abc12", Redact("code=abc12"))
+
+	MarkSecret("mark-secret-token")
+	assert.Equal(t, "token=******", Redact("token=mark-secret-token"))
+
+	// A value of a sensitive key is a secret even if it did not come from a secret reference
+	t.Setenv("TEST_SECRET_API_KEY", "from-env-api-key")
+	out := struct {
+		ApiKey string `yaml:"api_key"`
+	}{}
+	assert.NoError(t, ReadParameterizedConfig("api_key: ${TEST_SECRET_API_KEY}\n", &out, ""))
+	assert.True(t, IsSecret("from-env-api-key"))
+}
+
+func TestRedactingLoggers(t *testing.T) {
+	MarkSecret("logged-secret-value")
+
+	out := &bytes.Buffer{}
+	logger := slog.New(slog.NewJSONHandler(RedactingWriter(out), nil))
+	logger.Error("failed to connect", "error", errors.New("bad password logged-secret-value"))
+	assert.NotContains(t, out.String(), "logged-secret-value")
+	assert.Contains(t, out.String(), "bad password ******")
+
+	out.Reset()
+	core := zapcore.NewCore(RedactingEncoder(zapcore.NewJSONEncoder(zap.NewProductionEncoderConfig())), zapcore.AddSync(out), zap.DebugLevel)
+	zap.New(core).With(zap.String("dsn", "user:logged-secret-value@db")).Error("reload failed", zap.Error(errors.New("logged-secret-value")))
+	assert.NotContains(t, out.String(), "logged-secret-value")
+	assert.Contains(t, out.String(), `"dsn":"user:******@db"`)
+
+	err := RedactError(fmt.Errorf("wrapped: %w", os.ErrNotExist))
+	assert.ErrorIs(t, err, os.ErrNotExist)
+	assert.Equal(t, "token ******", RedactError(errors.New("token logged-secret-value")).Error())
+}
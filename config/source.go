@@ -0,0 +1,137 @@
+package config
+
+import (
+	"bytes"
+	"encoding/json"
+	"github.com/TwiN/deepmerge"
+	"github.com/devlibx/gox-base/v2/errors"
+	"gopkg.in/yaml.v3"
+	"path/filepath"
+	"strings"
+)
+
+// Format is the format of a config source
+type Format string
+
+const (
+	FormatYaml Format = "yaml"
+	FormatJson Format = "json"
+)
+
+// Source is a single config file which takes part in the merge
+type Source struct {
+	Name   string
+	Format Format
+	Data   []byte
+}
+
+// NewSource builds a source, format is picked from the file extension (.json is json, everything else is yaml)
+func NewSource(name string, data []byte) Source {
+	format := FormatYaml
+	if strings.EqualFold(filepath.Ext(name), ".json") {
+		format = FormatJson
+	}
+	return Source{Name: name, Format: format, Data: data}
+}
+
+// ToMap parses the source into a map
+func (s Source) ToMap() (map[string]interface{}, error) {
+	out := map[string]interface{}{}
+	switch s.Format {
+	case FormatJson:
+		decoder := json.NewDecoder(bytes.NewReader(s.Data))
+		decoder.UseNumber()
+		if err := decoder.Decode(&out); err != nil {
+			return nil, errors.Wrap(err, "failed to parse json config: name=%s", s.Name)
+		}
+		out = normalizeJsonValue(out).(map[string]interface{})
+	default:
+		if err := yaml.Unmarshal(s.Data, &out); err != nil {
+			return nil, errors.Wrap(err, "failed to parse yaml config: name=%s", s.Name)
+		}
+	}
+	if out == nil {
+		out = map[string]interface{}{}
+	}
+	return out, nil
+}
+
+// MergeSources merges all base sources, and then applies the overlays on top. Base sources must not define the same
+// primitive value twice (same as deepmerge.YAML). Overlays replace values and lists, only maps are merged key by key
+func MergeSources(base []Source, overlays []Source) (map[string]interface{}, error) {
+	out := map[string]interface{}{}
+	for _, s := range base {
+		m, err := s.ToMap()
+		if err != nil {
+			return nil, err
+		}
+		if err = deepmerge.DeepMerge(out, m, deepmerge.Config{PreventMultipleDefinitionsOfKeysWithPrimitiveValue: true}); err != nil {
+			return nil, errors.Wrap(err, "failed to merge config: name=%s", s.Name)
+		}
+	}
+
+	for _, s := range overlays {
+		m, err := s.ToMap()
+		if err != nil {
+			return nil, err
+		}
+		overlayMaps(out, m)
+	}
+	return out, nil
+}
+
+// Render converts the merged config to the given format
+func Render(in map[string]interface{}, format Format) (string, error) {
+	switch format {
+	case FormatJson:
+		if out, err := json.Marshal(in); err != nil {
+			return "", errors.Wrap(err, "failed to render config as json")
+		} else {
+			return string(out), nil
+		}
+	default:
+		if out, err := yaml.Marshal(in); err != nil {
+			return "", errors.Wrap(err, "failed to render config as yaml")
+		} else {
+			return string(out), nil
+		}
+	}
+}
+
+// overlayMaps copies src into dst - nested maps are merged, everything else in src replaces the value in dst
+func overlayMaps(dst, src map[string]interface{}) {
+	for key, srcValue := range src {
+		srcMap, srcIsMap := srcValue.(map[string]interface{})
+		dstMap, dstIsMap := dst[key].(map[string]interface{})
+		if srcIsMap && dstIsMap {
+			overlayMaps(dstMap, srcMap)
+		} else {
+			dst[key] = srcValue
+		}
+	}
+}
+
+// normalizeJsonValue converts json.Number to int or float, so json and yaml sources give the same types after merge
+func normalizeJsonValue(in interface{}) interface{} {
+	switch v := in.(type) {
+	case map[string]interface{}:
+		for key, value := range v {
+			v[key] = normalizeJsonValue(value)
+		}
+		return v
+	case []interface{}:
+		for i, value := range v {
+			v[i] = normalizeJsonValue(value)
+		}
+		return v
+	case json.Number:
+		if i, err := v.Int64(); err == nil {
+			return int(i)
+		} else if f, err := v.Float64(); err == nil {
+			return f
+		}
+		return v.String()
+	default:
+		return v
+	}
+}
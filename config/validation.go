@@ -0,0 +1,63 @@
+package config
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// ValidationErrors collects all problems found while validating a config, so that all of them are reported together
+// instead of failing on the first one
+type ValidationErrors struct {
+	problems []string
+}
+
+// Add records a problem for the given config path e.g. "orders_mysql_config.host"
+func (v *ValidationErrors) Add(path string, format string, args ...interface{}) {
+	v.problems = append(v.problems, fmt.Sprintf("%s: %s", path, fmt.Sprintf(format, args...)))
+}
+
+// Check records a problem if the condition is false
+func (v *ValidationErrors) Check(condition bool, path string, format string, args ...interface{}) {
+	if !condition {
+		v.Add(path, format, args...)
+	}
+}
+
+// RequireString records a problem if the value is empty
+func (v *ValidationErrors) RequireString(path string, value string) {
+	v.Check(strings.TrimSpace(value) != "", path, "must be set (is the env var used for it missing?)")
+}
+
+// RequirePort records a problem if the value is not a valid tcp port
+func (v *ValidationErrors) RequirePort(path string, port int) {
+	v.Check(port > 0 && port <= 65535, path, "must be a valid port between 1 and 65535, got %d", port)
+}
+
+// RequireListenPort records a problem if the value can not be used to listen on - 0 is ok, it picks a free port
+func (v *ValidationErrors) RequireListenPort(path string, port int) {
+	v.Check(port >= 0 && port <= 65535, path, "must be a valid port between 0 and 65535, got %d", port)
+}
+
+// RequireNonNegative records a problem if the value is negative
+func (v *ValidationErrors) RequireNonNegative(path string, value int) {
+	v.Check(value >= 0, path, "must not be negative, got %d", value)
+}
+
+// Problems gives all problems recorded so far
+func (v *ValidationErrors) Problems() []string {
+	return v.problems
+}
+
+// Err returns nil if no problem was found, otherwise it returns itself as error
+func (v *ValidationErrors) Err() error {
+	if len(v.problems) == 0 {
+		return nil
+	}
+	sort.Strings(v.problems)
+	return v
+}
+
+func (v *ValidationErrors) Error() string {
+	return fmt.Sprintf("invalid config, found %d problem(s):\n  - %s", len(v.problems), strings.Join(v.problems, "\n  - "))
+}
@@ -1,27 +1,26 @@
 package go_template_project
 
 import (
-	"bufio"
-	_ "embed"
+	"embed"
 	"fmt"
+	"github.com/devlibx/go-template-project/config"
+	"github.com/devlibx/gox-base/v2/errors"
+	"gopkg.in/yaml.v3"
 	"os"
+	"path"
 	"strings"
 )
 
-//go:embed env/common.env
-var commonEnv string
+// envFiles holds the env files and the profile manifest (env/profiles.yaml)
+//
+//go:embed env/*.env env/profiles.yaml
+var envFiles embed.FS
 
-//go:embed env/dev.env
-var devEnv string
-
-//go:embed env/stage.env
-var stageEnv string
-
-//go:embed env/test.env
-var testEnv string
-
-//go:embed env/e2e_test.env
-var e2eTestEnv string
+// EnvProfile is a named set of env files - the files of the profile it extends are loaded first
+type EnvProfile struct {
+	Extends string   `yaml:"extends"`
+	Files   []string `yaml:"files"`
+}
 
 type EnvSetupFunc func(envs map[string]string)
 
@@ -29,7 +28,12 @@ func DefaultEnvSetupFunc() EnvSetupFunc {
 	return func(envs map[string]string) {
 		for key, value := range envs {
 			_ = os.Setenv(key, value)
-			fmt.Printf("Setting %s to %s\n", key, value)
+			if config.IsSensitiveName(key) && value != "" {
+				config.MarkSecret(value)
+				fmt.Printf("Setting %s to %s\n", key, config.RedactedValue)
+			} else {
+				fmt.Printf("Setting %s to %s\n", key, value)
+			}
 		}
 	}
 }
@@ -48,72 +52,115 @@ func buildFinalEnvAndCall(envs map[string]string, setupFunc EnvSetupFunc) {
 	}
 }
 
-func SetupCommonEnv(envs map[string]string, setupFunc EnvSetupFunc) {
-	scanner := bufio.NewScanner(strings.NewReader(commonEnv))
-	for scanner.Scan() {
-		line := scanner.Text()
-		parts := strings.SplitN(line, "=", 2)
-		if len(parts) == 2 {
-			envs[parts[0]] = parts[1]
+// EnvFile is a dotenv file to load with LoadEnvFiles
+type EnvFile struct {
+	Name    string
+	Content string
+}
+
+// LoadEnvFiles parses the dotenv files (see config.ParseDotEnv) in the given order into envs - a later file overrides
+// an earlier one. A ${VAR} in a file is looked up in the process env first, then in the same file and then in the
+// values of the earlier files. After that setupFunc is called with the final env, where a var already set in the
+// process env wins over the files.
+func LoadEnvFiles(envs map[string]string, setupFunc EnvSetupFunc, files ...EnvFile) error {
+	loaded := func(name string) (string, bool) {
+		value, ok := envs[name]
+		return value, ok
+	}
+
+	for _, file := range files {
+		values, err := config.ParseDotEnvOver(file.Name, file.Content, os.LookupEnv, loaded)
+		if err != nil {
+			return err
+		}
+		for key, value := range values {
+			envs[key] = value
 		}
 	}
 
 	buildFinalEnvAndCall(envs, setupFunc)
+	return nil
 }
 
-func SetupDevEnv(envs map[string]string, setupFunc EnvSetupFunc) {
-	scanner := bufio.NewScanner(strings.NewReader(devEnv))
-	for scanner.Scan() {
-		line := scanner.Text()
-		parts := strings.SplitN(line, "=", 2)
-		if len(parts) == 2 {
-			envs[parts[0]] = parts[1]
-		}
+// ResolveEnvProfile gives the env files of a profile (from env/profiles.yaml) in the order they are loaded - files of
+// the profile it extends (and so on) come first
+func ResolveEnvProfile(name string) ([]EnvFile, error) {
+	data, err := envFiles.ReadFile("env/profiles.yaml")
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to read env profiles")
+	}
+	manifest := struct {
+		Profiles map[string]EnvProfile `yaml:"profiles"`
+	}{}
+	if err = yaml.Unmarshal(data, &manifest); err != nil {
+		return nil, errors.Wrap(err, "failed to parse env profiles")
 	}
 
-	buildFinalEnvAndCall(envs, setupFunc)
-}
+	// Walk up the extends chain, then load from the top most profile
+	var chain []string
+	for current := name; current != ""; current = manifest.Profiles[current].Extends {
+		if _, ok := manifest.Profiles[current]; !ok {
+			return nil, errors.New("env profile not found: profile=%s chain=%s", current, strings.Join(append(chain, current), " -> "))
+		}
+		for _, seen := range chain {
+			if seen == current {
+				return nil, errors.New("env profile extends itself: chain=%s", strings.Join(append(chain, current), " -> "))
+			}
+		}
+		chain = append(chain, current)
+	}
 
-func SetupTestEnv(envs map[string]string, setupFunc EnvSetupFunc) {
-	scanner := bufio.NewScanner(strings.NewReader(testEnv))
-	for scanner.Scan() {
-		line := scanner.Text()
-		parts := strings.SplitN(line, "=", 2)
-		if len(parts) == 2 {
-			envs[parts[0]] = parts[1]
+	var files []EnvFile
+	for i := len(chain) - 1; i >= 0; i-- {
+		for _, file := range manifest.Profiles[chain[i]].Files {
+			name := path.Join("env", file)
+			content, err := envFiles.ReadFile(name)
+			if err != nil {
+				return nil, errors.Wrap(err, "failed to read env file of profile: profile=%s file=%s", chain[i], name)
+			}
+			files = append(files, EnvFile{Name: name, Content: string(content)})
 		}
 	}
+	return files, nil
+}
 
-	buildFinalEnvAndCall(envs, setupFunc)
+// SetupEnvProfile loads the env files of the profile (see ResolveEnvProfile and LoadEnvFiles)
+func SetupEnvProfile(name string, envs map[string]string, setupFunc EnvSetupFunc) error {
+	files, err := ResolveEnvProfile(name)
+	if err != nil {
+		return err
+	}
+	return LoadEnvFiles(envs, setupFunc, files...)
 }
 
-func SetupStageEnv(envs map[string]string, setupFunc EnvSetupFunc) {
-	scanner := bufio.NewScanner(strings.NewReader(stageEnv))
-	for scanner.Scan() {
-		line := scanner.Text()
-		parts := strings.SplitN(line, "=", 2)
-		if len(parts) == 2 {
-			envs[parts[0]] = parts[1]
-		}
+// mustSetupEnvProfile is SetupEnvProfile for the profiles used by tests - a broken profile is a bug, so it panics
+func mustSetupEnvProfile(name string, envs map[string]string, setupFunc EnvSetupFunc) {
+	if err := SetupEnvProfile(name, envs, setupFunc); err != nil {
+		panic(err)
 	}
+}
 
-	buildFinalEnvAndCall(envs, setupFunc)
+// SetupCommonEnv loads the "common" profile - common.env
+func SetupCommonEnv(envs map[string]string, setupFunc EnvSetupFunc) {
+	mustSetupEnvProfile("common", envs, setupFunc)
 }
 
-func SetupE2ETestEnv(envs map[string]string, setupFunc EnvSetupFunc) {
-	SetupCommonEnv(envs, nil)
-	SetupDevEnv(envs, nil)
-	SetupStageEnv(envs, nil)
-	SetupTestEnv(envs, nil)
-
-	scanner := bufio.NewScanner(strings.NewReader(e2eTestEnv))
-	for scanner.Scan() {
-		line := scanner.Text()
-		parts := strings.SplitN(line, "=", 2)
-		if len(parts) == 2 {
-			envs[parts[0]] = parts[1]
-		}
-	}
+// SetupDevEnv loads the "dev" profile - common.env and then dev.env
+func SetupDevEnv(envs map[string]string, setupFunc EnvSetupFunc) {
+	mustSetupEnvProfile("dev", envs, setupFunc)
+}
 
-	buildFinalEnvAndCall(envs, setupFunc)
+// SetupTestEnv loads the "test" profile - common.env and then test.env
+func SetupTestEnv(envs map[string]string, setupFunc EnvSetupFunc) {
+	mustSetupEnvProfile("test", envs, setupFunc)
+}
+
+// SetupStageEnv loads the "stage" profile - common.env and then stage.env
+func SetupStageEnv(envs map[string]string, setupFunc EnvSetupFunc) {
+	mustSetupEnvProfile("stage", envs, setupFunc)
+}
+
+// SetupE2ETestEnv loads the "e2e" profile - common.env, test.env and then e2e_test.env
+func SetupE2ETestEnv(envs map[string]string, setupFunc EnvSetupFunc) {
+	mustSetupEnvProfile("e2e", envs, setupFunc)
 }
@@ -46,3 +46,51 @@ func TestBuildFinalEnvAndCall(t *testing.T) {
 	assert.Equal(t, valSystem, finalEnvs[keyOverride], "Expected system value to take precedence")
 	assert.Equal(t, valNew, finalEnvs[keyNew], "Expected file value to be used when system env is missing")
 }
+
+func TestLoadEnvFiles(t *testing.T) {
+	t.Setenv("TEST_LOAD_ENV_OVERRIDE", "system_value")
+
+	var finalEnvs map[string]string
+	err := LoadEnvFiles(map[string]string{}, func(e map[string]string) { finalEnvs = e },
+		EnvFile{Name: "common.env", Content: "export DB_HOST=localhost\nDB_URL=\"${DB_HOST}:3306\"\nTEST_LOAD_ENV_OVERRIDE=file_value\n"},
+		EnvFile{Name: "dev.env", Content: "# dev overrides\nDB_HOST=dev-host\nDB_PASSWORD='Test@123$'\n"},
+	)
+	assert.NoError(t, err)
+	assert.Equal(t, "dev-host", finalEnvs["DB_HOST"])
+	assert.Equal(t, "localhost:3306", finalEnvs["DB_URL"])
+	assert.Equal(t, "Test@123$", finalEnvs["DB_PASSWORD"])
+	assert.Equal(t, "system_value", finalEnvs["TEST_LOAD_ENV_OVERRIDE"])
+
+	// A later file which redefines a var uses its own value, not the one of the earlier file
+	err = LoadEnvFiles(map[string]string{}, func(e map[string]string) { finalEnvs = e },
+		EnvFile{Name: "a.env", Content: "SCR_HOST=localhost\nSCR_PORT=3306\n"},
+		EnvFile{Name: "b.env", Content: "SCR_HOST=dev-host\nSCR_URL=${SCR_HOST}:${SCR_PORT}\nSCR_PASSWORD=pa$word\n"},
+	)
+	assert.NoError(t, err)
+	assert.Equal(t, "dev-host:3306", finalEnvs["SCR_URL"])
+	assert.Equal(t, "pa$word", finalEnvs["SCR_PASSWORD"])
+
+	err = LoadEnvFiles(map[string]string{}, nil, EnvFile{Name: "bad.env", Content: "A=1\nB=\"not closed\n"})
+	assert.EqualError(t, err, "bad.env:2: quoted value is not closed")
+}
+
+func TestSetupE2ETestEnv_EmbeddedEnvFilesAreValid(t *testing.T) {
+	envs := map[string]string{}
+	assert.NotPanics(t, func() { SetupE2ETestEnv(envs, nil) })
+	assert.Equal(t, "test_me", envs["APP_NAME"])
+	assert.Equal(t, "", envs["DB_PASSWORD_1"])
+}
+
+func TestResolveEnvProfile(t *testing.T) {
+	files, err := ResolveEnvProfile("e2e")
+	assert.NoError(t, err)
+
+	var names []string
+	for _, f := range files {
+		names = append(names, f.Name)
+	}
+	assert.Equal(t, []string{"env/common.env", "env/test.env", "env/e2e_test.env"}, names)
+
+	_, err = ResolveEnvProfile("missing")
+	assert.Error(t, err)
+}
@@ -1,30 +1,49 @@
 package handler
 
 import (
+	"github.com/devlibx/go-template-project/pkg/infra/auth"
+	"github.com/devlibx/go-template-project/pkg/infra/router"
+	"github.com/devlibx/go-template-project/pkg/infra/validation"
 	"github.com/devlibx/go-template-project/pkg/service/post"
 	"github.com/devlibx/gox-base/v2"
 	"github.com/gin-gonic/gin"
 	"github.com/opentracing/opentracing-go"
-	"go.uber.org/fx"
 )
 
 type PostHandler struct {
-	fx.In
 	gox.CrossFunction
 	PostService post.Service
 }
 
-type name struct {
-	name string `json:"name" validate:"required"`
+func NewPostHandler(cf gox.CrossFunction, postService post.Service) *PostHandler {
+	return &PostHandler{CrossFunction: cf, PostService: postService}
+}
+
+func (h *PostHandler) RouteGroup() router.Group {
+	return router.Group{Version: router.V1, Path: "/post", Auth: router.Auth{Scheme: auth.SchemeClient}}
+}
+
+func (h *PostHandler) RegisterRoutes(r gin.IRouter) {
+	r.GET("/:postId", h.GetPost)
+}
+
+type getPostRequest struct {
+	PostId string `uri:"postId" validate:"required,number"`
 }
 
 func (h *PostHandler) GetPost(c *gin.Context) {
 	span, ctx := opentracing.StartSpanFromContext(c, "postHandler.GetPost")
 	defer span.Finish()
 
-	if p, err := h.PostService.GetPost(ctx, c.Param("postId")); err == nil {
+	request := getPostRequest{}
+	if err := validation.Bind(c, &request); err != nil {
+		_ = c.Error(err)
+		return
+	}
+
+	if p, err := h.PostService.GetPost(ctx, request.PostId); err == nil {
 		c.JSON(200, p)
 	} else {
-		c.JSON(500, gin.H{"error": err.Error()})
+		_ = c.Error(err)
 	}
 }
@@ -1,5 +1,11 @@
 package handler
 
-import "go.uber.org/fx"
+import (
+	"github.com/devlibx/go-template-project/pkg/infra/router"
+	"go.uber.org/fx"
+)
 
-var Provider = fx.Options()
+// Provider gives all handlers - each one is a RouteRegistrar, so the server mounts its routes
+var Provider = fx.Options(
+	router.ProvideRouteRegistrar(NewPostHandler, NewUserHandler),
+)
@@ -0,0 +1,47 @@
+package handler
+
+import (
+	"github.com/devlibx/go-template-project/pkg/infra/auth"
+	"github.com/devlibx/go-template-project/pkg/infra/pagination"
+	"github.com/devlibx/go-template-project/pkg/infra/router"
+	"github.com/devlibx/go-template-project/pkg/infra/validation"
+	"github.com/devlibx/go-template-project/pkg/service/user"
+	"github.com/devlibx/gox-base/v2"
+	"github.com/gin-gonic/gin"
+	"github.com/opentracing/opentracing-go"
+)
+
+type UserHandler struct {
+	gox.CrossFunction
+	UserService user.Service
+}
+
+func NewUserHandler(cf gox.CrossFunction, userService user.Service) *UserHandler {
+	return &UserHandler{CrossFunction: cf, UserService: userService}
+}
+
+func (h *UserHandler) RouteGroup() router.Group {
+	return router.Group{Version: router.V1, Path: "/user", Auth: router.Auth{Scheme: auth.SchemeClient}}
+}
+
+func (h *UserHandler) RegisterRoutes(r gin.IRouter) {
+	r.GET("", h.ListUsers)
+}
+
+// ListUsers gives a page of users e.g. GET /api/v1/user?limit=20&cursor=<next_cursor>
+func (h *UserHandler) ListUsers(c *gin.Context) {
+	span, ctx := opentracing.StartSpanFromContext(c, "userHandler.ListUsers")
+	defer span.Finish()
+
+	request := pagination.Request{}
+	if err := validation.Bind(c, &request); err != nil {
+		_ = c.Error(err)
+		return
+	}
+
+	if page, err := h.UserService.ListUsers(ctx, request); err == nil {
+		c.JSON(200, page)
+	} else {
+		_ = c.Error(err)
+	}
+}
@@ -0,0 +1,166 @@
+package apperror
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+)
+
+// Kind is the category of an error - it decides the http status of the response
+type Kind string
+
+const (
+	KindNotFound          Kind = "not_found"
+	KindInvalidArgument   Kind = "invalid_argument"
+	KindConflict          Kind = "conflict"
+	KindUnavailable       Kind = "unavailable"
+	KindDeadlineExceeded  Kind = "deadline_exceeded"
+	KindUnauthorized      Kind = "unauthorized"
+	KindForbidden         Kind = "forbidden"
+	KindResourceExhausted Kind = "resource_exhausted"
+	KindInternal          Kind = "internal"
+)
+
+var statusByKind = map[Kind]int{
+	KindNotFound:          http.StatusNotFound,
+	KindInvalidArgument:   http.StatusBadRequest,
+	KindConflict:          http.StatusConflict,
+	KindUnavailable:       http.StatusServiceUnavailable,
+	KindDeadlineExceeded:  http.StatusGatewayTimeout,
+	KindUnauthorized:      http.StatusUnauthorized,
+	KindForbidden:         http.StatusForbidden,
+	KindResourceExhausted: http.StatusTooManyRequests,
+	KindInternal:          http.StatusInternalServerError,
+}
+
+// Status gives the http status of the kind
+func (k Kind) Status() int {
+	if status, ok := statusByKind[k]; ok {
+		return status
+	}
+	return http.StatusInternalServerError
+}
+
+// Error is a domain error returned by services and data stores. Code and Message are sent to the caller, so they must
+// not have internal details - the cause is only logged
+type Error struct {
+	Kind Kind
+
+	// Code is a stable error code which callers can depend on e.g. "post_not_found"
+	Code    string
+	Message string
+
+	// Err is the cause of the error
+	Err error
+
+	// Fields are the problems with single fields of the request e.g. from validation
+	Fields []FieldError
+}
+
+// FieldError is a problem with one field of a request
+type FieldError struct {
+	// Field is the name of the field in the request e.g. "postId", nested body fields are joined with "."
+	Field string `json:"field"`
+
+	// In is where the field is - path, query, header or body
+	In string `json:"in,omitempty"`
+
+	// Code is a stable code of the problem e.g. "required", mostly the name of the failed validation
+	Code    string `json:"code"`
+	Message string `json:"message"`
+}
+
+func (e *Error) Error() string {
+	if e.Err != nil {
+		return fmt.Sprintf("%s: code=%s, message=%s: %v", e.Kind, e.Code, e.Message, e.Err)
+	}
+	return fmt.Sprintf("%s: code=%s, message=%s", e.Kind, e.Code, e.Message)
+}
+
+func (e *Error) Unwrap() error {
+	return e.Err
+}
+
+// WithCause sets the cause of the error
+func (e *Error) WithCause(err error) *Error {
+	e.Err = err
+	return e
+}
+
+// WithFields adds problems with single fields of the request
+func (e *Error) WithFields(fields ...FieldError) *Error {
+	e.Fields = append(e.Fields, fields...)
+	return e
+}
+
+// New builds an error of the kind. The message is formatted with args
+func New(kind Kind, code string, format string, args ...interface{}) *Error {
+	message := format
+	if len(args) > 0 {
+		message = fmt.Sprintf(format, args...)
+	}
+	return &Error{Kind: kind, Code: code, Message: message}
+}
+
+func NotFound(code string, format string, args ...interface{}) *Error {
+	return New(KindNotFound, code, format, args...)
+}
+
+func InvalidArgument(code string, format string, args ...interface{}) *Error {
+	return New(KindInvalidArgument, code, format, args...)
+}
+
+func Conflict(code string, format string, args ...interface{}) *Error {
+	return New(KindConflict, code, format, args...)
+}
+
+func Unavailable(code string, format string, args ...interface{}) *Error {
+	return New(KindUnavailable, code, format, args...)
+}
+
+func DeadlineExceeded(code string, format string, args ...interface{}) *Error {
+	return New(KindDeadlineExceeded, code, format, args...)
+}
+
+func Unauthorized(code string, format string, args ...interface{}) *Error {
+	return New(KindUnauthorized, code, format, args...)
+}
+
+func Forbidden(code string, format string, args ...interface{}) *Error {
+	return New(KindForbidden, code, format, args...)
+}
+
+func ResourceExhausted(code string, format string, args ...interface{}) *Error {
+	return New(KindResourceExhausted, code, format, args...)
+}
+
+func Internal(code string, format string, args ...interface{}) *Error {
+	return New(KindInternal, code, format, args...)
+}
+
+// From gives the domain error in the chain of err. An error which is not a domain error becomes an internal error, or
+// a deadline exceeded error if a context deadline was hit
+func From(err error) *Error {
+	var e *Error
+	if errors.As(err, &e) {
+		return e
+	}
+	if errors.Is(err, context.DeadlineExceeded) {
+		return DeadlineExceeded("deadline_exceeded", "request took too long").WithCause(err)
+	}
+	return Internal("internal_error", "internal error").WithCause(err)
+}
+
+// KindOf gives the kind of the error, see From
+func KindOf(err error) Kind {
+	if err == nil {
+		return ""
+	}
+	return From(err).Kind
+}
+
+// IsNotFound returns true if the error is a NotFound error
+func IsNotFound(err error) bool {
+	return KindOf(err) == KindNotFound
+}
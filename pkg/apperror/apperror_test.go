@@ -0,0 +1,107 @@
+package apperror
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"github.com/devlibx/go-template-project/pkg/infra/requestid"
+	"github.com/devlibx/gox-base/v2"
+	"github.com/devlibx/gox-http/v4/command"
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/zap"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func newTestEngine(handler gin.HandlerFunc) *gin.Engine {
+	cf := gox.NewCrossFunction(zap.NewNop())
+	engine := gin.New()
+	engine.Use(Handler(cf))
+	engine.GET("/post/:postId", handler)
+	engine.GET("/abort", func(c *gin.Context) {
+		Abort(cf, c, Unauthorized("missing_token", "missing token"))
+	})
+	return engine
+}
+
+func call(engine *gin.Engine, path string) (*httptest.ResponseRecorder, *Problem) {
+	request := httptest.NewRequest(http.MethodGet, path, nil)
+	request.Header.Set(requestid.Header, "req-1")
+	recorder := httptest.NewRecorder()
+	engine.ServeHTTP(recorder, request)
+
+	problem := &Problem{}
+	_ = json.Unmarshal(recorder.Body.Bytes(), problem)
+	return recorder, problem
+}
+
+func TestHandler(t *testing.T) {
+	engine := newTestEngine(func(c *gin.Context) {
+		switch c.Param("postId") {
+		case "1":
+			c.JSON(http.StatusOK, gin.H{"id": 1})
+		case "2":
+			_ = c.Error(fmt.Errorf("wrapped: %w", NotFound("post_not_found", "post %s does not exist", "2").WithCause(fmt.Errorf("secret cause"))))
+		default:
+			_ = c.Error(fmt.Errorf("db password is wrong"))
+		}
+	})
+
+	response, _ := call(engine, "/post/1")
+	assert.Equal(t, http.StatusOK, response.Code)
+
+	response, problem := call(engine, "/post/2")
+	assert.Equal(t, http.StatusNotFound, response.Code)
+	assert.Equal(t, ProblemContentType, response.Header().Get("Content-Type"))
+	assert.Equal(t, &Problem{Type: "about:blank", Title: "Not Found", Status: 404, Detail: "post 2 does not exist", Instance: "/post/2", Code: "post_not_found", RequestId: "req-1"}, problem)
+	assert.NotContains(t, response.Body.String(), "secret cause")
+
+	// Unknown errors are internal errors, without the details of the error
+	response, problem = call(engine, "/post/3")
+	assert.Equal(t, http.StatusInternalServerError, response.Code)
+	assert.Equal(t, "internal_error", problem.Code)
+	assert.NotContains(t, response.Body.String(), "password")
+
+	response, problem = call(engine, "/abort")
+	assert.Equal(t, http.StatusUnauthorized, response.Code)
+	assert.Equal(t, ProblemContentType, response.Header().Get("Content-Type"))
+	assert.Equal(t, "missing_token", problem.Code)
+}
+
+func TestFrom(t *testing.T) {
+	assert.Equal(t, KindConflict, KindOf(fmt.Errorf("wrapped: %w", Conflict("exists", "exists"))))
+	assert.Equal(t, KindDeadlineExceeded, KindOf(fmt.Errorf("wrapped: %w", context.DeadlineExceeded)))
+	assert.Equal(t, KindInternal, KindOf(fmt.Errorf("other")))
+	assert.Equal(t, Kind(""), KindOf(nil))
+	assert.True(t, IsNotFound(NotFound("x", "x")))
+	assert.Equal(t, http.StatusGatewayTimeout, KindDeadlineExceeded.Status())
+	assert.Equal(t, http.StatusInternalServerError, Kind("unknown").Status())
+}
+
+func TestFromHttp(t *testing.T) {
+	tests := []struct {
+		err  error
+		kind Kind
+		code string
+	}{
+		{err: &command.GoxHttpError{StatusCode: 404}, kind: KindNotFound, code: "upstream_not_found"},
+		{err: &command.GoxHttpError{StatusCode: 400}, kind: KindInvalidArgument, code: "upstream_invalid_argument"},
+		{err: &command.GoxHttpError{StatusCode: 409}, kind: KindConflict, code: "upstream_conflict"},
+		{err: &command.GoxHttpError{StatusCode: 401}, kind: KindInternal, code: "upstream_rejected"},
+		{err: &command.GoxHttpError{StatusCode: 500}, kind: KindUnavailable, code: "upstream_unavailable"},
+		{err: &command.GoxHttpError{StatusCode: 504}, kind: KindDeadlineExceeded, code: "upstream_timeout"},
+		{err: &command.GoxHttpError{StatusCode: 400, ErrorCode: "request_failed_on_client"}, kind: KindUnavailable, code: "upstream_unavailable"},
+		{err: &command.GoxHttpError{StatusCode: 408, ErrorCode: "request_timeout_on_client"}, kind: KindDeadlineExceeded, code: "upstream_timeout"},
+		{err: &command.GoxHttpError{ErrorCode: command.ErrorCodeFailedToBuildRequest}, kind: KindInternal, code: "upstream_bad_request"},
+		{err: fmt.Errorf("wrapped: %w", context.DeadlineExceeded), kind: KindDeadlineExceeded, code: "upstream_timeout"},
+		{err: fmt.Errorf("connection refused"), kind: KindUnavailable, code: "upstream_unavailable"},
+	}
+	for _, test := range tests {
+		e := FromHttp("upstream", test.err)
+		assert.Equal(t, test.kind, e.Kind, test.err.Error())
+		assert.Equal(t, test.code, e.Code, test.err.Error())
+	}
+	assert.Nil(t, FromHttp("upstream", nil))
+}
@@ -0,0 +1,45 @@
+package apperror
+
+import (
+	"context"
+	"errors"
+	"github.com/devlibx/gox-http/v4/command"
+	"net/http"
+)
+
+// FromHttp maps the error of an upstream call made with gox-http to a domain error. Codes are prefixed with the
+// upstream name e.g. "jsonplaceholder_not_found". Clients can map a NotFound further e.g. to "post_not_found"
+func FromHttp(upstream string, err error) *Error {
+	if err == nil {
+		return nil
+	}
+
+	var httpErr *command.GoxHttpError
+	if !errors.As(err, &httpErr) {
+		if errors.Is(err, context.DeadlineExceeded) {
+			return DeadlineExceeded(upstream+"_timeout", "%s did not respond in time", upstream).WithCause(err)
+		}
+		return Unavailable(upstream+"_unavailable", "%s is not available", upstream).WithCause(err)
+	}
+
+	// Errors on the client side also have a status code e.g. 400 for a refused connection, so they are checked first
+	switch {
+	case httpErr.IsHystrixTimeoutError() || httpErr.ErrorCode == "request_timeout_on_client" || httpErr.StatusCode == http.StatusGatewayTimeout:
+		return DeadlineExceeded(upstream+"_timeout", "%s did not respond in time", upstream).WithCause(err)
+	case httpErr.IsHystrixError() || httpErr.ErrorCode == "request_failed_on_client" || httpErr.ErrorCode == command.ErrorCodeFailedToRequestServer:
+		return Unavailable(upstream+"_unavailable", "%s is not available", upstream).WithCause(err)
+	case httpErr.ErrorCode == command.ErrorCodeFailedToBuildRequest:
+		return Internal(upstream+"_bad_request", "failed to build request for %s", upstream).WithCause(err)
+	case httpErr.IsNotFound():
+		return NotFound(upstream+"_not_found", "not found in %s", upstream).WithCause(err)
+	case httpErr.IsBadRequest():
+		return InvalidArgument(upstream+"_invalid_argument", "%s rejected the request", upstream).WithCause(err)
+	case httpErr.IsConflict():
+		return Conflict(upstream+"_conflict", "%s reported a conflict", upstream).WithCause(err)
+	case httpErr.Is4xx():
+		// Other 4xx e.g. 401 from upstream are problems of this service, not of the caller
+		return Internal(upstream+"_rejected", "%s rejected the request", upstream).WithCause(err)
+	default:
+		return Unavailable(upstream+"_unavailable", "%s is not available", upstream).WithCause(err)
+	}
+}
@@ -0,0 +1,83 @@
+package apperror
+
+import (
+	"github.com/devlibx/go-template-project/pkg/infra/requestid"
+	"github.com/devlibx/gox-base/v2"
+	"github.com/gin-gonic/gin"
+	"go.uber.org/zap"
+	"net/http"
+)
+
+// ProblemContentType is the content type of an RFC 7807 problem response
+const ProblemContentType = "application/problem+json"
+
+// Problem is the RFC 7807 body of an error response, with the stable error code, the request id and the field errors
+// as extensions
+type Problem struct {
+	Type      string `json:"type"`
+	Title     string `json:"title"`
+	Status    int    `json:"status"`
+	Detail    string `json:"detail,omitempty"`
+	Instance  string `json:"instance,omitempty"`
+	Code      string `json:"code"`
+	RequestId string `json:"request_id,omitempty"`
+
+	// Errors are the problems with single fields of the request
+	Errors []FieldError `json:"errors,omitempty"`
+}
+
+// NewProblem builds the problem for an error, the cause of the error is not in it
+func NewProblem(c *gin.Context, err error) *Problem {
+	e := From(err)
+	status := e.Kind.Status()
+	return &Problem{
+		Type:      "about:blank",
+		Title:     http.StatusText(status),
+		Status:    status,
+		Detail:    e.Message,
+		Instance:  c.Request.URL.Path,
+		Code:      e.Code,
+		RequestId: requestId(c),
+		Errors:    e.Fields,
+	}
+}
+
+// Handler renders the last error added with c.Error() as problem+json, if the handler did not write a response. A
+// handler returns an error with:
+//
+//	_ = c.Error(err)
+//	return
+func Handler(cf gox.CrossFunction) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		c.Next()
+		if len(c.Errors) == 0 || c.Writer.Written() {
+			return
+		}
+		render(cf, c, c.Errors.Last().Err)
+	}
+}
+
+// Abort stops the request, and renders the error as problem+json e.g. from a middleware
+func Abort(cf gox.CrossFunction, c *gin.Context, err error) {
+	c.Abort()
+	render(cf, c, err)
+}
+
+func render(cf gox.CrossFunction, c *gin.Context, err error) {
+	problem := NewProblem(c, err)
+	logger := requestid.Logger(c, cf.Logger())
+	if problem.Status >= http.StatusInternalServerError {
+		logger.Error("request failed", zap.String("path", c.FullPath()), zap.String("code", problem.Code), zap.Error(err))
+	} else {
+		logger.Debug("request rejected", zap.String("path", c.FullPath()), zap.String("code", problem.Code), zap.Error(err))
+	}
+	c.Header("Content-Type", ProblemContentType)
+	c.JSON(problem.Status, problem)
+}
+
+func requestId(c *gin.Context) string {
+	if id := requestid.FromContext(c); id != "" {
+		return id
+	}
+	return c.GetHeader(requestid.Header)
+}
@@ -3,9 +3,16 @@ package base
 import (
 	ordersDataStore "github.com/devlibx/go-template-project/pkg/infra/database/mysql/user/rw"
 	goxHttpApi "github.com/devlibx/gox-http/v4/api"
+	"net"
 )
 
 type ApplicationContext struct {
 	GoxHttpContext  goxHttpApi.GoxHttpContext
 	OrdersDataStore ordersDataStore.Querier
+
+	// ServerAddr is the address the http server is bound to e.g. to find the port picked with http_port=0
+	ServerAddr net.Addr
+
+	// AdminAddr is the address the admin server (metrics, health, pprof) is bound to
+	AdminAddr net.Addr
 }
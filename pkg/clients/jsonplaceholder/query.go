@@ -2,6 +2,7 @@ package jsonplaceholderClient
 
 import (
 	"context"
+	"github.com/devlibx/go-template-project/pkg/apperror"
 	goxHttpApi "github.com/devlibx/gox-http/v4/api"
 	"github.com/devlibx/gox-http/v4/command"
 )
@@ -25,5 +26,9 @@ func (c *client) GetPosts(ctx context.Context, postId string) (*PostDto, error)
 			Title:  httpResponse.Response.Title,
 		}, nil
 	}
-	return nil, err
+	if appErr := apperror.FromHttp("jsonplaceholder", err); appErr.Kind == apperror.KindNotFound {
+		return nil, apperror.NotFound("post_not_found", "post %s does not exist", postId).WithCause(err)
+	} else {
+		return nil, appErr
+	}
 }
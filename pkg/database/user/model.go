@@ -40,8 +40,8 @@ func (u *User) FromOrder(ctx context.Context, in *ordersDataStore.Order) *User {
 		Email:     in.Amount,   // Using Amount field as Email for demo
 		Name:      "User Name", // Placeholder
 		Status:    "active",    // Default status
-		CreatedAt: time.Now(),
-		UpdatedAt: time.Now(),
+		CreatedAt: in.CreatedAt,
+		UpdatedAt: in.UpdatedAt.Time,
 	}
 }
 
@@ -52,8 +52,8 @@ func (u *User) FromOrderRO(ctx context.Context, in *orderRoDataStore.Order) *Use
 		Email:     in.Amount,   // Using Amount field as Email for demo
 		Name:      "User Name", // Placeholder
 		Status:    "active",    // Default status
-		CreatedAt: time.Now(),
-		UpdatedAt: time.Now(),
+		CreatedAt: in.CreatedAt,
+		UpdatedAt: in.UpdatedAt.Time,
 	}
 }
 
@@ -79,7 +79,7 @@ func (o *Order) FromOrder(ctx context.Context, in *ordersDataStore.Order) *Order
 		OrderID:   in.OrderID,
 		OrderQty:  int(in.OrderQty),
 		Amount:    in.Amount,
-		CreatedAt: time.Now(),
-		UpdatedAt: time.Now(),
+		CreatedAt: in.CreatedAt,
+		UpdatedAt: in.UpdatedAt.Time,
 	}
 }
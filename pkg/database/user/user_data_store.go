@@ -2,8 +2,11 @@ package user
 
 import (
 	"context"
+	"github.com/devlibx/go-template-project/pkg/apperror"
+	"github.com/devlibx/go-template-project/pkg/infra/database"
 	orderRoDataStore "github.com/devlibx/go-template-project/pkg/infra/database/mysql/user/ro"
 	ordersDataStore "github.com/devlibx/go-template-project/pkg/infra/database/mysql/user/rw"
+	"github.com/devlibx/go-template-project/pkg/infra/pagination"
 	"github.com/devlibx/gox-base/v2"
 )
 
@@ -16,14 +19,20 @@ type UserDataStore interface {
 
 	// Read operations (use RO connection)
 	GetUserByID(ctx context.Context, userID string) (*User, error)
+	ListUsers(ctx context.Context, page pagination.Request) (*pagination.Page[*User], error)
+
+	// Deprecated: GetAllUsers reads all users into memory, use ListUsers
 	GetAllUsers(ctx context.Context) ([]*User, error)
 }
 
 // OrderDataStore interface defines operations for orders (keeping existing functionality)
 type OrderDataStore interface {
 	CreateOrder(ctx context.Context, arg CreateOrderRequest) error
-	GetAllOrders(ctx context.Context) ([]*Order, error)
 	GetOrderByID(ctx context.Context, orderID string) (*Order, error)
+	ListOrders(ctx context.Context, page pagination.Request) (*pagination.Page[*Order], error)
+
+	// Deprecated: GetAllOrders reads all orders into memory, use ListOrders
+	GetAllOrders(ctx context.Context) ([]*Order, error)
 }
 
 // userDataStoreImpl implements all user operations with both RO and RW connections
@@ -47,11 +56,12 @@ type orderDataStoreImpl struct {
 // Write operations using RW connection
 func (u *userDataStoreImpl) CreateUser(ctx context.Context, arg CreateUserRequest) error {
 	// Using CreateOrder as underlying storage (demo purposes)
-	return u.rwQuerier.CreateOrder(ctx, ordersDataStore.CreateOrderParams{
+	err := u.rwQuerier.CreateOrder(ctx, ordersDataStore.CreateOrderParams{
 		OrderID:  arg.UserID,
 		OrderQty: 1,         // Default value
 		Amount:   arg.Email, // Using Amount field to store email
 	})
+	return database.ToAppError(err, nil)
 }
 
 func (u *userDataStoreImpl) UpdateUser(ctx context.Context, userID string, arg UpdateUserRequest) error {
@@ -71,7 +81,7 @@ func (u *userDataStoreImpl) DeleteUser(ctx context.Context, userID string) error
 // Read operations using RO connection
 func (u *userDataStoreImpl) GetUserByID(ctx context.Context, userID string) (*User, error) {
 	if order, err := u.roQuerier.GetOrderByID(ctx, userID); err != nil {
-		return nil, err
+		return nil, database.ToAppError(err, apperror.NotFound("user_not_found", "user %s does not exist", userID))
 	} else {
 		ret := &User{}
 		ret.FromOrderRO(ctx, order)
@@ -79,9 +89,38 @@ func (u *userDataStoreImpl) GetUserByID(ctx context.Context, userID string) (*Us
 	}
 }
 
+// ListUsers gives a page of users, newest first
+func (u *userDataStoreImpl) ListUsers(ctx context.Context, page pagination.Request) (*pagination.Page[*User], error) {
+	cursor, err := pagination.Decode(page.Cursor)
+	if err != nil {
+		return nil, err
+	}
+
+	// One more row than the page is read, it tells if there is a next page
+	pageSize := page.PageSize()
+	var orders []*orderRoDataStore.Order
+	if cursor == nil {
+		orders, err = u.roQuerier.ListOrders(ctx, int32(pageSize+1))
+	} else {
+		orders, err = u.roQuerier.ListOrdersAfter(ctx, orderRoDataStore.ListOrdersAfterParams{
+			CursorCreatedAt: cursor.CreatedAt,
+			CursorOrderID:   cursor.ID,
+			PageSize:        int32(pageSize + 1),
+		})
+	}
+	if err != nil {
+		return nil, database.ToAppError(err, nil)
+	}
+	return pagination.NewPage(orders, pageSize, func(in *orderRoDataStore.Order) *User {
+		return (&User{}).FromOrderRO(ctx, in)
+	}, func(in *orderRoDataStore.Order) pagination.Cursor {
+		return pagination.Cursor{CreatedAt: in.CreatedAt, ID: in.OrderID}
+	}), nil
+}
+
 func (u *userDataStoreImpl) GetAllUsers(ctx context.Context) ([]*User, error) {
 	if orders, err := u.roQuerier.GetAllOrders(ctx); err != nil {
-		return nil, err
+		return nil, database.ToAppError(err, nil)
 	} else {
 		ret := make([]*User, len(orders))
 		for i, order := range orders {
@@ -94,16 +133,17 @@ func (u *userDataStoreImpl) GetAllUsers(ctx context.Context) ([]*User, error) {
 
 // Order operations (existing functionality)
 func (o *orderDataStoreImpl) CreateOrder(ctx context.Context, arg CreateOrderRequest) error {
-	return o.querier.CreateOrder(ctx, ordersDataStore.CreateOrderParams{
+	err := o.querier.CreateOrder(ctx, ordersDataStore.CreateOrderParams{
 		OrderID:  arg.OrderID,
 		OrderQty: int32(arg.OrderQty),
 		Amount:   arg.Amount,
 	})
+	return database.ToAppError(err, nil)
 }
 
 func (o *orderDataStoreImpl) GetAllOrders(ctx context.Context) ([]*Order, error) {
 	if orders, err := o.querier.GetAllOrders(ctx); err != nil {
-		return nil, err
+		return nil, database.ToAppError(err, nil)
 	} else {
 		ret := make([]*Order, len(orders))
 		for i, order := range orders {
@@ -114,9 +154,38 @@ func (o *orderDataStoreImpl) GetAllOrders(ctx context.Context) ([]*Order, error)
 	}
 }
 
+// ListOrders gives a page of orders, newest first
+func (o *orderDataStoreImpl) ListOrders(ctx context.Context, page pagination.Request) (*pagination.Page[*Order], error) {
+	cursor, err := pagination.Decode(page.Cursor)
+	if err != nil {
+		return nil, err
+	}
+
+	// One more row than the page is read, it tells if there is a next page
+	pageSize := page.PageSize()
+	var orders []*ordersDataStore.Order
+	if cursor == nil {
+		orders, err = o.querier.ListOrders(ctx, int32(pageSize+1))
+	} else {
+		orders, err = o.querier.ListOrdersAfter(ctx, ordersDataStore.ListOrdersAfterParams{
+			CursorCreatedAt: cursor.CreatedAt,
+			CursorOrderID:   cursor.ID,
+			PageSize:        int32(pageSize + 1),
+		})
+	}
+	if err != nil {
+		return nil, database.ToAppError(err, nil)
+	}
+	return pagination.NewPage(orders, pageSize, func(in *ordersDataStore.Order) *Order {
+		return (&Order{}).FromOrder(ctx, in)
+	}, func(in *ordersDataStore.Order) pagination.Cursor {
+		return pagination.Cursor{CreatedAt: in.CreatedAt, ID: in.OrderID}
+	}), nil
+}
+
 func (o *orderDataStoreImpl) GetOrderByID(ctx context.Context, orderID string) (*Order, error) {
 	if order, err := o.querier.GetOrderByID(ctx, orderID); err != nil {
-		return nil, err
+		return nil, database.ToAppError(err, apperror.NotFound("order_not_found", "order %s does not exist", orderID))
 	} else {
 		ret := &Order{}
 		ret.FromOrder(ctx, order)
@@ -0,0 +1,177 @@
+package accesslog
+
+import (
+	"github.com/devlibx/go-template-project/pkg/apperror"
+	"github.com/devlibx/go-template-project/pkg/infra/auth"
+	"github.com/devlibx/go-template-project/pkg/infra/requestid"
+	"github.com/devlibx/go-template-project/pkg/infra/router"
+	"github.com/devlibx/gox-base/v2"
+	goxBaseConfig "github.com/devlibx/gox-base/v2/config"
+	goxHttpApi "github.com/devlibx/gox-http/v4/api"
+	"github.com/gin-gonic/gin"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+	"math/rand"
+	"net/http"
+	"strings"
+	"sync/atomic"
+	"time"
+)
+
+// Config controls the access log, which has one record for each API request
+type Config struct {
+	Enabled bool `yaml:"enabled"`
+
+	// Level of the record of a request, "info" by default. ErrorLevel is used for 5xx responses, "error" by default
+	Level      string `yaml:"level"`
+	ErrorLevel string `yaml:"error_level"`
+
+	// SampleRate is the fraction of requests which are logged e.g. 0.1 for 10%, all by default. 5xx responses and
+	// requests slower than SlowRequestMs are always logged
+	SampleRate    *float64 `yaml:"sample_rate"`
+	SlowRequestMs int      `yaml:"slow_request_ms"`
+
+	// SkipRoutes are route templates without the app name prefix which are not logged e.g. /api/v1/ping
+	SkipRoutes []string `yaml:"skip_routes"`
+
+	// Headers adds the request headers to the record - the ignore_request_headers of
+	// gox_http_request_response_security_config are dropped
+	Headers bool `yaml:"headers"`
+}
+
+// headersAlwaysDropped are never logged, even if they are not in ignore_request_headers
+var headersAlwaysDropped = []string{"Authorization", "Proxy-Authorization", "Cookie"}
+
+// Logger writes the access log as gin middleware
+type Logger struct {
+	gox.CrossFunction
+	logger         *zap.Logger
+	routes         router.RouteTemplates
+	securityConfig func() *goxHttpApi.RequestResponseSecurityConfig
+	config         atomic.Pointer[settings]
+}
+
+type settings struct {
+	Config
+	level, errorLevel zapcore.Level
+	sampleRate        float64
+	slowRequest       time.Duration
+	skipRoutes        map[string]bool
+}
+
+// NewLogger builds the access log. securityConfig gives the current gox_http_request_response_security_config, so a
+// reload of the ignored headers is used on the next request
+func NewLogger(cf gox.CrossFunction, config *Config, app *goxBaseConfig.App, securityConfig func() *goxHttpApi.RequestResponseSecurityConfig) *Logger {
+	l := &Logger{
+		CrossFunction:  cf,
+		logger:         cf.Logger().Named("access"),
+		routes:         router.NewRouteTemplates(app.AppName),
+		securityConfig: securityConfig,
+	}
+	l.Update(config)
+	return l
+}
+
+// Update replaces the config e.g. on config reload
+func (l *Logger) Update(config *Config) {
+	s := &settings{level: zapcore.InfoLevel, errorLevel: zapcore.ErrorLevel, sampleRate: 1, skipRoutes: map[string]bool{}}
+	if config != nil {
+		s.Config = *config
+	}
+	if level, err := zapcore.ParseLevel(s.Level); err == nil && s.Level != "" {
+		s.level = level
+	}
+	if level, err := zapcore.ParseLevel(s.ErrorLevel); err == nil && s.ErrorLevel != "" {
+		s.errorLevel = level
+	}
+	if s.SampleRate != nil {
+		s.sampleRate = *s.SampleRate
+	}
+	s.slowRequest = time.Duration(s.SlowRequestMs) * time.Millisecond
+	for _, route := range s.SkipRoutes {
+		s.skipRoutes[route] = true
+	}
+	l.config.Store(s)
+}
+
+// Middleware logs each request after it is done. It must run before apperror.Handler to see the status of errors
+func (l *Logger) Middleware() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		s := l.config.Load()
+		if !s.Enabled {
+			c.Next()
+			return
+		}
+
+		start := time.Now()
+		c.Next()
+		latency := time.Since(start)
+
+		route := c.FullPath()
+		if s.skipRoutes[l.routes.Of(c)] {
+			return
+		}
+
+		status := c.Writer.Status()
+		level := s.level
+		if status >= http.StatusInternalServerError {
+			level = s.errorLevel
+		} else if (s.slowRequest <= 0 || latency < s.slowRequest) && !sampled(s.sampleRate) {
+			return
+		}
+		if ce := l.logger.Check(level, "request"); ce != nil {
+			ce.Write(l.fields(c, s, route, status, latency)...)
+		}
+	}
+}
+
+func (l *Logger) fields(c *gin.Context, s *settings, route string, status int, latency time.Duration) []zap.Field {
+	bytesOut := c.Writer.Size()
+	if bytesOut < 0 {
+		bytesOut = 0
+	}
+	fields := []zap.Field{
+		zap.String("method", c.Request.Method),
+		zap.String("route", route),
+		zap.Int("status", status),
+		zap.Duration("latency", latency),
+		zap.Int64("bytes_in", max(c.Request.ContentLength, 0)),
+		zap.Int("bytes_out", bytesOut),
+		zap.String("client_id", auth.CallerID(c)),
+		zap.String(requestid.LogField, requestid.FromContext(c)),
+		zap.String("user_agent", c.Request.UserAgent()),
+		zap.String("client_ip", c.ClientIP()),
+	}
+	if len(c.Errors) > 0 {
+		fields = append(fields, zap.String("error_code", apperror.From(c.Errors.Last().Err).Code))
+	}
+	if s.Headers {
+		fields = append(fields, zap.Any("headers", l.headers(c.Request.Header)))
+	}
+	return fields
+}
+
+// headers gives the request headers without the ignored ones, multiple values are joined with ","
+func (l *Logger) headers(header http.Header) map[string]string {
+	dropped := map[string]bool{}
+	for _, name := range headersAlwaysDropped {
+		dropped[http.CanonicalHeaderKey(name)] = true
+	}
+	if config := l.securityConfig(); config != nil {
+		for _, name := range config.IgnoreRequestHeaders {
+			dropped[http.CanonicalHeaderKey(name)] = true
+		}
+	}
+
+	out := make(map[string]string, len(header))
+	for name, values := range header {
+		if !dropped[http.CanonicalHeaderKey(name)] {
+			out[name] = strings.Join(values, ",")
+		}
+	}
+	return out
+}
+
+func sampled(rate float64) bool {
+	return rate >= 1 || (rate > 0 && rand.Float64() < rate)
+}
@@ -0,0 +1,112 @@
+package accesslog
+
+import (
+	"github.com/devlibx/go-template-project/pkg/apperror"
+	"github.com/devlibx/go-template-project/pkg/infra/auth"
+	"github.com/devlibx/go-template-project/pkg/infra/requestid"
+	"github.com/devlibx/gox-base/v2"
+	goxBaseConfig "github.com/devlibx/gox-base/v2/config"
+	goxHttpApi "github.com/devlibx/gox-http/v4/api"
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+	"go.uber.org/zap/zaptest/observer"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func newTestEngine(config *Config, securityConfig *goxHttpApi.RequestResponseSecurityConfig) (*gin.Engine, *Logger, *observer.ObservedLogs) {
+	core, logs := observer.New(zapcore.DebugLevel)
+	cf := gox.NewCrossFunction(zap.New(core))
+	logger := NewLogger(cf, config, &goxBaseConfig.App{AppName: "app"}, func() *goxHttpApi.RequestResponseSecurityConfig {
+		return securityConfig
+	})
+
+	engine := gin.New()
+	group := engine.Group("/app")
+	group.Use(requestid.Middleware(), logger.Middleware(), apperror.Handler(cf))
+	group.Use(func(c *gin.Context) {
+		c.Request = c.Request.WithContext(auth.WithClient(c.Request.Context(), &auth.Client{ID: "c1"}))
+	})
+	group.GET("/api/v1/post/:postId", func(c *gin.Context) {
+		if c.Param("postId") == "0" {
+			_ = c.Error(apperror.Unavailable("upstream_unavailable", "upstream is not available"))
+			return
+		}
+		c.String(http.StatusOK, "post")
+	})
+	group.GET("/api/v1/ping", func(c *gin.Context) { c.Status(http.StatusOK) })
+	return engine, logger, logs
+}
+
+func call(engine *gin.Engine, path string) {
+	request := httptest.NewRequest(http.MethodGet, path, nil)
+	request.Header.Set("User-Agent", "test")
+	request.Header.Set(requestid.Header, "req-1")
+	request.Header.Set("X-Tenant-ID", "t1")
+	request.Header.Set("Authorization", "Bearer secret")
+	request.Header.Set("Accept", "text/plain")
+	engine.ServeHTTP(httptest.NewRecorder(), request)
+}
+
+func accessLogs(logs *observer.ObservedLogs) []observer.LoggedEntry {
+	return logs.Filter(func(e observer.LoggedEntry) bool { return e.LoggerName == "access" }).AllUntimed()
+}
+
+func TestLogger(t *testing.T) {
+	engine, _, logs := newTestEngine(
+		&Config{Enabled: true, Headers: true, SkipRoutes: []string{"/api/v1/ping"}},
+		&goxHttpApi.RequestResponseSecurityConfig{IgnoreRequestHeaders: []string{"x-tenant-id"}},
+	)
+
+	call(engine, "/app/api/v1/post/1")
+	call(engine, "/app/api/v1/ping")
+	call(engine, "/app/api/v1/post/0")
+
+	entries := accessLogs(logs)
+	assert.Len(t, entries, 2)
+
+	fields := entries[0].ContextMap()
+	assert.Equal(t, zapcore.InfoLevel, entries[0].Level)
+	assert.Equal(t, "GET", fields["method"])
+	assert.Equal(t, "/app/api/v1/post/:postId", fields["route"])
+	assert.Equal(t, int64(200), fields["status"])
+	assert.Equal(t, int64(4), fields["bytes_out"])
+	assert.Equal(t, "c1", fields["client_id"])
+	assert.Equal(t, "req-1", fields[requestid.LogField])
+	assert.Equal(t, "test", fields["user_agent"])
+	assert.Contains(t, fields, "latency")
+	assert.Equal(t, map[string]string{"Accept": "text/plain", "User-Agent": "test", "X-Request-Id": "req-1"}, fields["headers"])
+
+	// The status of the error response is logged at the error level
+	assert.Equal(t, zapcore.ErrorLevel, entries[1].Level)
+	assert.Equal(t, int64(503), entries[1].ContextMap()["status"])
+	assert.Equal(t, "upstream_unavailable", entries[1].ContextMap()["error_code"])
+}
+
+func TestLogger_SamplingAndLevels(t *testing.T) {
+	sampleRate := 0.0
+	engine, logger, logs := newTestEngine(&Config{Enabled: true, Level: "debug", ErrorLevel: "warn", SampleRate: &sampleRate}, nil)
+
+	// Nothing is sampled, but errors are always logged
+	call(engine, "/app/api/v1/post/1")
+	call(engine, "/app/api/v1/post/0")
+	entries := accessLogs(logs)
+	assert.Len(t, entries, 1)
+	assert.Equal(t, zapcore.WarnLevel, entries[0].Level)
+	assert.NotContains(t, entries[0].ContextMap(), "headers")
+
+	// Slow requests are always logged
+	logger.Update(&Config{Enabled: true, Level: "debug", SampleRate: &sampleRate, SlowRequestMs: 1})
+	logger.config.Load().slowRequest = 1 // 1ns, so every request is slow
+	call(engine, "/app/api/v1/post/1")
+	entries = accessLogs(logs)
+	assert.Len(t, entries, 2)
+	assert.Equal(t, zapcore.DebugLevel, entries[1].Level)
+
+	logger.Update(&Config{Enabled: false})
+	call(engine, "/app/api/v1/post/0")
+	assert.Len(t, accessLogs(logs), 2)
+}
@@ -0,0 +1,131 @@
+package admin
+
+import (
+	"crypto/subtle"
+	"github.com/devlibx/go-template-project/pkg/infra/health"
+	"github.com/devlibx/go-template-project/pkg/infra/httpserver"
+	"github.com/devlibx/gox-base/v2"
+	goxBaseConfig "github.com/devlibx/gox-base/v2/config"
+	stats "github.com/devlibx/gox-metrics/v2/common"
+	"github.com/gin-gonic/gin"
+	"go.uber.org/fx"
+	"net/http"
+	"net/http/pprof"
+)
+
+// RoutesGroup is the fx value group of Routes added to the admin server
+const RoutesGroup = "admin_routes"
+
+// Routes adds operational endpoints to the admin router e.g.
+//
+//	fx.Provide(fx.Annotate(func() admin.Routes { ... }, fx.ResultTags(`group:"admin_routes"`)))
+type Routes func(router gin.IRouter)
+
+// Config is the configuration of the admin server. It runs on its own port, so operational endpoints are never
+// reachable through the public ingress
+type Config struct {
+	HttpPort  int              `yaml:"http_port"`
+	BasicAuth *BasicAuthConfig `yaml:"basic_auth"`
+}
+
+// BasicAuthConfig protects all admin endpoints with basic-auth, it is off if the username is empty
+type BasicAuthConfig struct {
+	Username string `yaml:"username"`
+	Password string `yaml:"password"`
+}
+
+func (b *BasicAuthConfig) Enabled() bool {
+	return b != nil && b.Username != ""
+}
+
+// Server is the admin http server, it serves metrics, health, pprof (if app.enable_pprof is set) and build info
+type Server struct {
+	httpserver.Server
+}
+
+type ServerParams struct {
+	fx.In
+	gox.CrossFunction
+	Config         *Config
+	App            *goxBaseConfig.App
+	MetricHandler  *stats.MetricHandler
+	HealthRegistry *health.Registry
+	Routes         []Routes `group:"admin_routes"`
+}
+
+func NewServer(params ServerParams) (*Server, error) {
+	s, err := httpserver.NewServer(params.CrossFunction, &goxBaseConfig.App{
+		AppName:                     params.App.AppName + "-admin",
+		Environment:                 params.App.Environment,
+		HttpPort:                    params.Config.HttpPort,
+		RequestReadTimeoutMs:        params.App.RequestReadTimeoutMs,
+		RequestWriteTimeoutMs:       params.App.RequestWriteTimeoutMs,
+		OutstandingRequestTimeoutMs: params.App.OutstandingRequestTimeoutMs,
+		IdleTimeoutMs:               params.App.IdleTimeoutMs,
+		Properties:                  gox.StringObjectMap{"server-time-logging-enabled": false},
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	router := s.GetRouter()
+	if params.Config.BasicAuth.Enabled() {
+		router.Use(basicAuth(params.Config.BasicAuth))
+	}
+
+	router.GET("/metrics", gin.WrapH(params.MetricHandler))
+	router.GET("/health", liveness)
+	router.GET("/health/live", liveness)
+	router.GET("/health/ready", readiness(params.HealthRegistry))
+	router.GET("/build-info", buildInfo(params.App))
+	if params.App.EnablePProf {
+		setupPprof(router)
+	}
+	for _, routes := range params.Routes {
+		routes(router)
+	}
+	return &Server{Server: s}, nil
+}
+
+func liveness(c *gin.Context) {
+	c.JSON(http.StatusOK, map[string]interface{}{"status": "ok"})
+}
+
+// readiness runs all readiness checkers - 503 is returned if the server is not ready or any check fails
+func readiness(registry *health.Registry) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		report := registry.Check(c.Request.Context())
+		status := http.StatusOK
+		if report.Status != health.StatusUp {
+			status = http.StatusServiceUnavailable
+		}
+		c.JSON(status, report)
+	}
+}
+
+func setupPprof(router gin.IRouter) {
+	group := router.Group("/debug/pprof")
+	group.GET("/", gin.WrapF(pprof.Index))
+	group.GET("/cmdline", gin.WrapF(pprof.Cmdline))
+	group.GET("/profile", gin.WrapF(pprof.Profile))
+	group.POST("/symbol", gin.WrapF(pprof.Symbol))
+	group.GET("/symbol", gin.WrapF(pprof.Symbol))
+	group.GET("/trace", gin.WrapF(pprof.Trace))
+	for _, profile := range []string{"allocs", "block", "goroutine", "heap", "mutex", "threadcreate"} {
+		group.GET("/"+profile, gin.WrapH(pprof.Handler(profile)))
+	}
+}
+
+func basicAuth(config *BasicAuthConfig) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		username, password, ok := c.Request.BasicAuth()
+		if !ok ||
+			subtle.ConstantTimeCompare([]byte(username), []byte(config.Username)) != 1 ||
+			subtle.ConstantTimeCompare([]byte(password), []byte(config.Password)) != 1 {
+			c.Header("WWW-Authenticate", `Basic realm="admin"`)
+			c.AbortWithStatus(http.StatusUnauthorized)
+			return
+		}
+		c.Next()
+	}
+}
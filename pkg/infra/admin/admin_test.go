@@ -0,0 +1,73 @@
+package admin
+
+import (
+	"encoding/json"
+	"github.com/devlibx/go-template-project/pkg/infra/health"
+	"github.com/devlibx/gox-base/v2"
+	goxBaseConfig "github.com/devlibx/gox-base/v2/config"
+	stats "github.com/devlibx/gox-metrics/v2/common"
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/zap"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func newTestServer(t *testing.T, config *Config, enablePprof bool) *Server {
+	s, err := NewServer(ServerParams{
+		CrossFunction:  gox.NewCrossFunction(zap.NewNop()),
+		Config:         config,
+		App:            &goxBaseConfig.App{AppName: "test", Environment: "test", EnablePProf: enablePprof},
+		MetricHandler:  &stats.MetricHandler{},
+		HealthRegistry: health.NewRegistry(health.RegistryParams{}),
+		Routes: []Routes{func(router gin.IRouter) {
+			router.GET("/custom", func(c *gin.Context) { c.String(http.StatusOK, "custom") })
+		}},
+	})
+	assert.NoError(t, err)
+	return s
+}
+
+func serve(s *Server, path string, username string, password string) *httptest.ResponseRecorder {
+	request := httptest.NewRequest(http.MethodGet, path, nil)
+	if username != "" {
+		request.SetBasicAuth(username, password)
+	}
+	recorder := httptest.NewRecorder()
+	s.GetRouter().ServeHTTP(recorder, request)
+	return recorder
+}
+
+func TestServer_Routes(t *testing.T) {
+	s := newTestServer(t, &Config{}, false)
+
+	assert.Equal(t, http.StatusOK, serve(s, "/metrics", "", "").Code)
+	assert.Equal(t, http.StatusOK, serve(s, "/health/live", "", "").Code)
+	assert.Equal(t, "custom", serve(s, "/custom", "", "").Body.String())
+
+	// Not marked ready yet
+	assert.Equal(t, http.StatusServiceUnavailable, serve(s, "/health/ready", "", "").Code)
+
+	info := &BuildInfo{}
+	response := serve(s, "/build-info", "", "")
+	assert.NoError(t, json.Unmarshal(response.Body.Bytes(), info))
+	assert.Equal(t, "test", info.App)
+	assert.Equal(t, Version, info.Version)
+
+	// pprof is only served if it is enabled
+	assert.Equal(t, http.StatusNotFound, serve(s, "/debug/pprof/heap", "", "").Code)
+	s = newTestServer(t, &Config{}, true)
+	assert.Equal(t, http.StatusOK, serve(s, "/debug/pprof/heap", "", "").Code)
+}
+
+func TestServer_BasicAuth(t *testing.T) {
+	s := newTestServer(t, &Config{BasicAuth: &BasicAuthConfig{Username: "admin", Password: "secret"}}, false)
+
+	response := serve(s, "/health/live", "", "")
+	assert.Equal(t, http.StatusUnauthorized, response.Code)
+	assert.Contains(t, response.Header().Get("WWW-Authenticate"), "Basic")
+
+	assert.Equal(t, http.StatusUnauthorized, serve(s, "/metrics", "admin", "wrong").Code)
+	assert.Equal(t, http.StatusOK, serve(s, "/metrics", "admin", "secret").Code)
+}
@@ -0,0 +1,55 @@
+package admin
+
+import (
+	goxBaseConfig "github.com/devlibx/gox-base/v2/config"
+	"github.com/gin-gonic/gin"
+	"net/http"
+	"runtime/debug"
+	"time"
+)
+
+// Version is the version of the service, it can be set at build time e.g.
+//
+//	go build -ldflags "-X github.com/devlibx/go-template-project/pkg/infra/admin.Version=1.2.3"
+var Version = "dev"
+
+var startedAt = time.Now()
+
+// BuildInfo tells what is running - the vcs fields are filled by the go toolchain when built from a git checkout
+type BuildInfo struct {
+	App         string    `json:"app"`
+	Environment string    `json:"env"`
+	Version     string    `json:"version"`
+	GoVersion   string    `json:"go_version"`
+	Module      string    `json:"module"`
+	Revision    string    `json:"vcs_revision,omitempty"`
+	CommitTime  string    `json:"vcs_time,omitempty"`
+	Modified    bool      `json:"vcs_modified,omitempty"`
+	StartedAt   time.Time `json:"started_at"`
+}
+
+func NewBuildInfo(app *goxBaseConfig.App) *BuildInfo {
+	info := &BuildInfo{App: app.AppName, Environment: app.Environment, Version: Version, StartedAt: startedAt}
+	if bi, ok := debug.ReadBuildInfo(); ok {
+		info.GoVersion = bi.GoVersion
+		info.Module = bi.Main.Path
+		for _, setting := range bi.Settings {
+			switch setting.Key {
+			case "vcs.revision":
+				info.Revision = setting.Value
+			case "vcs.time":
+				info.CommitTime = setting.Value
+			case "vcs.modified":
+				info.Modified = setting.Value == "true"
+			}
+		}
+	}
+	return info
+}
+
+func buildInfo(app *goxBaseConfig.App) gin.HandlerFunc {
+	info := NewBuildInfo(app)
+	return func(c *gin.Context) {
+		c.JSON(http.StatusOK, info)
+	}
+}
@@ -0,0 +1,268 @@
+package auth
+
+import (
+	"context"
+	"github.com/devlibx/go-template-project/pkg/apperror"
+	"github.com/devlibx/go-template-project/pkg/infra/router"
+	"github.com/devlibx/gox-base/v2"
+	"github.com/devlibx/gox-base/v2/errors"
+	"github.com/gin-gonic/gin"
+	"github.com/golang-jwt/jwt/v5"
+	"go.uber.org/zap"
+	"strings"
+	"time"
+)
+
+// SchemeBearer is the auth scheme of route groups which are called with "Authorization: Bearer <jwt>"
+const SchemeBearer = "bearer"
+
+// JwtAuthConfig configures the verification of bearer tokens. The JWKS is read from jwks_file, or from jwks_url
+type JwtAuthConfig struct {
+	Enabled               bool         `yaml:"enabled"`
+	JwksFile              string       `yaml:"jwks_file"`
+	JwksUrl               string       `yaml:"jwks_url"`
+	JwksRefreshIntervalMs int          `yaml:"jwks_refresh_interval_ms"`
+	Issuer                string       `yaml:"issuer"`
+	Audience              string       `yaml:"audience"`
+	Algorithms            []string     `yaml:"algorithms"`
+	LeewayMs              int          `yaml:"leeway_ms"`
+	Claims                ClaimsConfig `yaml:"claims"`
+}
+
+// ClaimsConfig gives the claim used for each field of the Principal. A dotted name reads a nested claim e.g.
+// "realm_access.roles"
+type ClaimsConfig struct {
+	Subject  string `yaml:"subject"`
+	ClientID string `yaml:"client_id"`
+	Name     string `yaml:"name"`
+	Email    string `yaml:"email"`
+	Scopes   string `yaml:"scopes"`
+	Roles    string `yaml:"roles"`
+}
+
+func (c *JwtAuthConfig) SetupDefaults() {
+	if c.JwksRefreshIntervalMs <= 0 {
+		c.JwksRefreshIntervalMs = 300000
+	}
+	if len(c.Algorithms) == 0 {
+		c.Algorithms = []string{"RS256", "ES256"}
+	}
+	defaultString(&c.Claims.Subject, "sub")
+	defaultString(&c.Claims.ClientID, "azp")
+	defaultString(&c.Claims.Name, "name")
+	defaultString(&c.Claims.Email, "email")
+	defaultString(&c.Claims.Scopes, "scope")
+	defaultString(&c.Claims.Roles, "roles")
+}
+
+func defaultString(value *string, defaultValue string) {
+	if *value == "" {
+		*value = defaultValue
+	}
+}
+
+// Principal is the caller authenticated by a bearer token
+type Principal struct {
+	Subject   string    `json:"subject"`
+	Issuer    string    `json:"issuer"`
+	ClientID  string    `json:"client_id,omitempty"`
+	Name      string    `json:"name,omitempty"`
+	Email     string    `json:"email,omitempty"`
+	Scopes    []string  `json:"scopes,omitempty"`
+	Roles     []string  `json:"roles,omitempty"`
+	ExpiresAt time.Time `json:"expires_at"`
+
+	// Claims has all claims of the token
+	Claims map[string]interface{} `json:"-"`
+}
+
+func (p *Principal) HasScope(scope string) bool {
+	return contains(p.Scopes, scope)
+}
+
+func (p *Principal) HasRole(role string) bool {
+	return contains(p.Roles, role)
+}
+
+func contains(values []string, value string) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
+
+type principalContextKey struct{}
+
+// WithPrincipal returns a context which carries the authenticated principal
+func WithPrincipal(ctx context.Context, principal *Principal) context.Context {
+	return context.WithValue(ctx, principalContextKey{}, principal)
+}
+
+// PrincipalFromContext gives the principal authenticated by the BearerAuthenticator. It works with the request
+// context, and with a *gin.Context which is passed as context by the handlers
+func PrincipalFromContext(ctx context.Context) (*Principal, bool) {
+	if c, ok := ctx.(*gin.Context); ok && c.Request != nil {
+		ctx = c.Request.Context()
+	}
+	principal, ok := ctx.Value(principalContextKey{}).(*Principal)
+	return principal, ok && principal != nil
+}
+
+// BearerAuthenticator verifies the signature of a JWT against the JWKS, checks issuer, audience and expiry, and puts
+// the Principal into the request context - see PrincipalFromContext
+type BearerAuthenticator struct {
+	gox.CrossFunction
+	config *JwtAuthConfig
+	keySet *KeySet
+	parser *jwt.Parser
+}
+
+// NewBearerAuthenticator loads the JWKS if bearer auth is enabled. If it is disabled, route groups with the bearer
+// scheme reject all requests
+func NewBearerAuthenticator(cf gox.CrossFunction, config *JwtAuthConfig) (*BearerAuthenticator, error) {
+	a := &BearerAuthenticator{CrossFunction: cf, config: config}
+	if !config.Enabled {
+		return a, nil
+	}
+
+	keySet, err := NewKeySet(context.Background(), config)
+	if err != nil {
+		return nil, err
+	}
+	a.keySet = keySet
+
+	options := []jwt.ParserOption{
+		jwt.WithValidMethods(config.Algorithms),
+		jwt.WithExpirationRequired(),
+		jwt.WithIssuedAt(),
+		jwt.WithLeeway(time.Duration(config.LeewayMs) * time.Millisecond),
+	}
+	if config.Issuer != "" {
+		options = append(options, jwt.WithIssuer(config.Issuer))
+	}
+	if config.Audience != "" {
+		options = append(options, jwt.WithAudience(config.Audience))
+	}
+	a.parser = jwt.NewParser(options...)
+	return a, nil
+}
+
+func (a *BearerAuthenticator) Scheme() string {
+	return SchemeBearer
+}
+
+// Middleware rejects a request with 401 if the token is missing or not valid, and with 403 if the client id of the
+// token is not in the clients allowed by the route group
+func (a *BearerAuthenticator) Middleware(auth router.Auth) gin.HandlerFunc {
+	allowed := map[string]bool{}
+	for _, id := range auth.Clients {
+		allowed[id] = true
+	}
+
+	return func(c *gin.Context) {
+		if a.keySet == nil {
+			a.Logger().Error("bearer auth is used by a route group but is not enabled - set jwt_auth.enabled", zap.String("path", c.FullPath()))
+			a.reject(c, apperror.Unauthorized("bearer_auth_disabled", "bearer auth is not enabled"))
+			return
+		}
+
+		token, ok := bearerToken(c.GetHeader("Authorization"))
+		if !ok {
+			a.reject(c, apperror.Unauthorized("missing_bearer_token", "missing bearer token"))
+			return
+		}
+
+		principal, err := a.Verify(c.Request.Context(), token)
+		if err != nil {
+			a.reject(c, apperror.Unauthorized("invalid_bearer_token", "invalid bearer token").WithCause(err))
+			return
+		}
+		if len(allowed) > 0 && !allowed[principal.ClientID] {
+			a.reject(c, apperror.Forbidden("client_not_allowed", "client is not allowed to call this api"))
+			return
+		}
+
+		c.Request = c.Request.WithContext(WithPrincipal(c.Request.Context(), principal))
+		c.Next()
+	}
+}
+
+// Verify checks the token, and maps its claims to a Principal
+func (a *BearerAuthenticator) Verify(ctx context.Context, token string) (*Principal, error) {
+	claims := jwt.MapClaims{}
+	_, err := a.parser.ParseWithClaims(token, claims, func(t *jwt.Token) (interface{}, error) {
+		kid, _ := t.Header["kid"].(string)
+		return a.keySet.Key(ctx, kid)
+	})
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to verify bearer token")
+	}
+
+	principal := &Principal{
+		Subject:  claimString(claims, a.config.Claims.Subject),
+		ClientID: claimString(claims, a.config.Claims.ClientID),
+		Name:     claimString(claims, a.config.Claims.Name),
+		Email:    claimString(claims, a.config.Claims.Email),
+		Scopes:   claimStrings(claims, a.config.Claims.Scopes),
+		Roles:    claimStrings(claims, a.config.Claims.Roles),
+		Claims:   claims,
+	}
+	principal.Issuer, _ = claims.GetIssuer()
+	if exp, _ := claims.GetExpirationTime(); exp != nil {
+		principal.ExpiresAt = exp.Time
+	}
+	return principal, nil
+}
+
+func (a *BearerAuthenticator) reject(c *gin.Context, err *apperror.Error) {
+	if err.Kind == apperror.KindUnauthorized {
+		c.Header("WWW-Authenticate", `Bearer error="invalid_token"`)
+	}
+	apperror.Abort(a, c, err)
+}
+
+func bearerToken(header string) (string, bool) {
+	scheme, token, found := strings.Cut(header, " ")
+	if !found || !strings.EqualFold(scheme, "Bearer") {
+		return "", false
+	}
+	token = strings.TrimSpace(token)
+	return token, token != ""
+}
+
+// claim reads a claim, a dotted name reads a nested claim
+func claim(claims map[string]interface{}, name string) interface{} {
+	var value interface{} = claims
+	for _, part := range strings.Split(name, ".") {
+		m, ok := value.(map[string]interface{})
+		if !ok {
+			return nil
+		}
+		value = m[part]
+	}
+	return value
+}
+
+func claimString(claims map[string]interface{}, name string) string {
+	value, _ := claim(claims, name).(string)
+	return value
+}
+
+// claimStrings reads a list claim - a string claim is split by space, as used by the "scope" claim
+func claimStrings(claims map[string]interface{}, name string) []string {
+	switch value := claim(claims, name).(type) {
+	case string:
+		return strings.Fields(value)
+	case []interface{}:
+		values := make([]string, 0, len(value))
+		for _, v := range value {
+			if s, ok := v.(string); ok {
+				values = append(values, s)
+			}
+		}
+		return values
+	}
+	return nil
+}
@@ -0,0 +1,281 @@
+package auth
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/rsa"
+	"encoding/base64"
+	"encoding/json"
+	"github.com/devlibx/go-template-project/pkg/infra/router"
+	"github.com/devlibx/gox-base/v2"
+	"github.com/gin-gonic/gin"
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/zap"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func rsaJwk(kid string, key *rsa.PrivateKey) map[string]string {
+	return map[string]string{
+		"kty": "RSA", "kid": kid, "use": "sig",
+		"n": base64.RawURLEncoding.EncodeToString(key.N.Bytes()),
+		"e": base64.RawURLEncoding.EncodeToString(big.NewInt(int64(key.E)).Bytes()),
+	}
+}
+
+func ecJwk(kid string, key *ecdsa.PrivateKey) map[string]string {
+	return map[string]string{
+		"kty": "EC", "kid": kid, "crv": "P-256",
+		"x": base64.RawURLEncoding.EncodeToString(key.X.Bytes()),
+		"y": base64.RawURLEncoding.EncodeToString(key.Y.Bytes()),
+	}
+}
+
+func jwksJson(t *testing.T, keys ...map[string]string) []byte {
+	data, err := json.Marshal(map[string]interface{}{"keys": keys})
+	assert.NoError(t, err)
+	return data
+}
+
+func sign(t *testing.T, method jwt.SigningMethod, kid string, key interface{}, claims jwt.MapClaims) string {
+	token := jwt.NewWithClaims(method, claims)
+	token.Header["kid"] = kid
+	signed, err := token.SignedString(key)
+	assert.NoError(t, err)
+	return signed
+}
+
+func validClaims() jwt.MapClaims {
+	return jwt.MapClaims{
+		"iss": "https://idp.local", "aud": "orders", "sub": "user-1", "azp": "web",
+		"exp": time.Now().Add(time.Hour).Unix(), "iat": time.Now().Unix(),
+		"email": "user@example.com", "scope": "orders.read orders.write",
+		"realm_access": map[string]interface{}{"roles": []string{"admin"}},
+	}
+}
+
+func newBearerEngine(t *testing.T, config *JwtAuthConfig, allowed ...string) *gin.Engine {
+	config.SetupDefaults()
+	authenticator, err := NewBearerAuthenticator(gox.NewCrossFunction(zap.NewNop()), config)
+	assert.NoError(t, err)
+
+	engine := gin.New()
+	engine.Use(authenticator.Middleware(router.Auth{Scheme: SchemeBearer, Clients: allowed}))
+	engine.GET("/", func(c *gin.Context) {
+		principal, _ := PrincipalFromContext(c)
+		c.JSON(http.StatusOK, principal)
+	})
+	return engine
+}
+
+func callWithToken(engine *gin.Engine, token string) *httptest.ResponseRecorder {
+	request := httptest.NewRequest(http.MethodGet, "/", nil)
+	if token != "" {
+		request.Header.Set("Authorization", "Bearer "+token)
+	}
+	recorder := httptest.NewRecorder()
+	engine.ServeHTTP(recorder, request)
+	return recorder
+}
+
+func TestBearerAuthenticator(t *testing.T) {
+	rsaKey, _ := rsa.GenerateKey(rand.Reader, 2048)
+	ecKey, _ := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	otherKey, _ := rsa.GenerateKey(rand.Reader, 2048)
+
+	jwksFile := filepath.Join(t.TempDir(), "jwks.json")
+	assert.NoError(t, os.WriteFile(jwksFile, jwksJson(t, rsaJwk("rsa-1", rsaKey), ecJwk("ec-1", ecKey)), 0600))
+	config := &JwtAuthConfig{
+		Enabled: true, JwksFile: jwksFile, Issuer: "https://idp.local", Audience: "orders",
+		Claims: ClaimsConfig{Roles: "realm_access.roles"},
+	}
+	engine := newBearerEngine(t, config)
+
+	response := callWithToken(engine, sign(t, jwt.SigningMethodRS256, "rsa-1", rsaKey, validClaims()))
+	assert.Equal(t, http.StatusOK, response.Code)
+	principal := &Principal{}
+	assert.NoError(t, json.Unmarshal(response.Body.Bytes(), principal))
+	assert.Equal(t, "user-1", principal.Subject)
+	assert.Equal(t, "web", principal.ClientID)
+	assert.Equal(t, "https://idp.local", principal.Issuer)
+	assert.Equal(t, "user@example.com", principal.Email)
+	assert.Equal(t, []string{"orders.read", "orders.write"}, principal.Scopes)
+	assert.True(t, principal.HasRole("admin"))
+
+	assert.Equal(t, http.StatusOK, callWithToken(engine, sign(t, jwt.SigningMethodES256, "ec-1", ecKey, validClaims())).Code)
+
+	// Rejected tokens
+	claims := validClaims()
+	claims["exp"] = time.Now().Add(-time.Hour).Unix()
+	assert.Equal(t, http.StatusUnauthorized, callWithToken(engine, sign(t, jwt.SigningMethodRS256, "rsa-1", rsaKey, claims)).Code)
+
+	claims = validClaims()
+	delete(claims, "exp")
+	assert.Equal(t, http.StatusUnauthorized, callWithToken(engine, sign(t, jwt.SigningMethodRS256, "rsa-1", rsaKey, claims)).Code)
+
+	claims = validClaims()
+	claims["iss"] = "https://other.local"
+	assert.Equal(t, http.StatusUnauthorized, callWithToken(engine, sign(t, jwt.SigningMethodRS256, "rsa-1", rsaKey, claims)).Code)
+
+	claims = validClaims()
+	claims["aud"] = "payments"
+	assert.Equal(t, http.StatusUnauthorized, callWithToken(engine, sign(t, jwt.SigningMethodRS256, "rsa-1", rsaKey, claims)).Code)
+
+	assert.Equal(t, http.StatusUnauthorized, callWithToken(engine, sign(t, jwt.SigningMethodRS256, "rsa-1", otherKey, validClaims())).Code)
+	assert.Equal(t, http.StatusUnauthorized, callWithToken(engine, sign(t, jwt.SigningMethodHS256, "rsa-1", []byte("secret"), validClaims())).Code)
+
+	response = callWithToken(engine, "")
+	assert.Equal(t, http.StatusUnauthorized, response.Code)
+	assert.Contains(t, response.Header().Get("WWW-Authenticate"), "Bearer")
+
+	// Only the "batch" client may call this group
+	engine = newBearerEngine(t, config, "batch")
+	assert.Equal(t, http.StatusForbidden, callWithToken(engine, sign(t, jwt.SigningMethodRS256, "rsa-1", rsaKey, validClaims())).Code)
+}
+
+func TestBearerAuthenticator_Disabled(t *testing.T) {
+	engine := newBearerEngine(t, &JwtAuthConfig{})
+	assert.Equal(t, http.StatusUnauthorized, callWithToken(engine, "token").Code)
+}
+
+func TestKeySet_Url(t *testing.T) {
+	key1, _ := rsa.GenerateKey(rand.Reader, 2048)
+	key2, _ := rsa.GenerateKey(rand.Reader, 2048)
+
+	var jwks atomic.Value
+	jwks.Store(jwksJson(t, rsaJwk("k1", key1)))
+	var loads int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&loads, 1)
+		_, _ = w.Write(jwks.Load().([]byte))
+	}))
+	defer server.Close()
+
+	keySet, err := NewKeySet(context.Background(), &JwtAuthConfig{JwksUrl: server.URL, JwksRefreshIntervalMs: 300000})
+	assert.NoError(t, err)
+	_, err = keySet.Key(context.Background(), "k1")
+	assert.NoError(t, err)
+	_, err = keySet.Key(context.Background(), "")
+	assert.NoError(t, err)
+	assert.Equal(t, int32(1), atomic.LoadInt32(&loads))
+
+	// A rotated key is loaded when a token uses it, but not more often than minRefreshInterval
+	jwks.Store(jwksJson(t, rsaJwk("k1", key1), rsaJwk("k2", key2)))
+	_, err = keySet.Key(context.Background(), "k2")
+	assert.Error(t, err)
+	assert.Equal(t, int32(1), atomic.LoadInt32(&loads))
+
+	keySet.minRefreshInterval = 0
+	_, err = keySet.Key(context.Background(), "k2")
+	assert.NoError(t, err)
+	assert.Equal(t, int32(2), atomic.LoadInt32(&loads))
+}
+
+func TestKeySet_ConcurrentRefresh(t *testing.T) {
+	key1, _ := rsa.GenerateKey(rand.Reader, 2048)
+	key2, _ := rsa.GenerateKey(rand.Reader, 2048)
+
+	var jwks atomic.Value
+	jwks.Store(jwksJson(t, rsaJwk("k1", key1)))
+	var loads int32
+	release := make(chan struct{})
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if atomic.AddInt32(&loads, 1) > 1 {
+			<-release
+		}
+		_, _ = w.Write(jwks.Load().([]byte))
+	}))
+	defer server.Close()
+
+	keySet, err := NewKeySet(context.Background(), &JwtAuthConfig{JwksUrl: server.URL})
+	assert.NoError(t, err)
+	keySet.minRefreshInterval = 0
+	jwks.Store(jwksJson(t, rsaJwk("k1", key1), rsaJwk("k2", key2)))
+
+	// Requests for the rotated key wait for one load
+	var wg sync.WaitGroup
+	errs := make(chan error, 10)
+	for i := 0; i < 10; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			_, err := keySet.Key(context.Background(), "k2")
+			errs <- err
+		}()
+	}
+
+	// A known key is found while the JWKS is loading
+	assert.Eventually(t, func() bool { return atomic.LoadInt32(&loads) == 2 }, time.Second, time.Millisecond)
+	_, err = keySet.Key(context.Background(), "k1")
+	assert.NoError(t, err)
+
+	close(release)
+	wg.Wait()
+	close(errs)
+	for err := range errs {
+		assert.NoError(t, err)
+	}
+	assert.LessOrEqual(t, atomic.LoadInt32(&loads), int32(3))
+}
+
+func TestKeySet_EndpointDown(t *testing.T) {
+	key1, _ := rsa.GenerateKey(rand.Reader, 2048)
+	jwks := jwksJson(t, rsaJwk("k1", key1))
+	var loads int32
+	var down atomic.Bool
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&loads, 1)
+		if down.Load() {
+			time.Sleep(200 * time.Millisecond)
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		_, _ = w.Write(jwks)
+	}))
+	defer server.Close()
+
+	keySet, err := NewKeySet(context.Background(), &JwtAuthConfig{JwksUrl: server.URL, JwksRefreshIntervalMs: 1})
+	assert.NoError(t, err)
+	keySet.minRefreshInterval = 50 * time.Millisecond
+	down.Store(true)
+	time.Sleep(60 * time.Millisecond)
+
+	// The refresh interval passed - a known key is given at once while the refresh fails in the background
+	for i := 0; i < 20; i++ {
+		start := time.Now()
+		_, err = keySet.Key(context.Background(), "k1")
+		assert.NoError(t, err)
+		assert.Less(t, time.Since(start), 100*time.Millisecond)
+	}
+	assert.Eventually(t, func() bool { return atomic.LoadInt32(&loads) == 2 }, time.Second, time.Millisecond)
+
+	// An unknown key id does not call the endpoint again until minRefreshInterval passed since the failed load
+	time.Sleep(250 * time.Millisecond)
+	keySet.minRefreshInterval = time.Hour
+	_, err = keySet.Key(context.Background(), "k2")
+	assert.Error(t, err)
+	_, err = keySet.Key(context.Background(), "k1")
+	assert.NoError(t, err)
+	assert.Equal(t, int32(2), atomic.LoadInt32(&loads))
+}
+
+func TestParseJwks(t *testing.T) {
+	_, err := parseJwks([]byte(`{"keys": [{"kty": "oct", "k": "c2VjcmV0"}]}`))
+	assert.Error(t, err)
+
+	_, err = parseJwks([]byte(`{"keys": [{"kty": "RSA", "kid": "k1", "n": "!!", "e": "AQAB"}]}`))
+	assert.Error(t, err)
+
+	_, err = parseJwks([]byte(`not json`))
+	assert.Error(t, err)
+}
@@ -0,0 +1,80 @@
+package auth
+
+import (
+	"context"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/hex"
+	"github.com/devlibx/gox-base/v2/errors"
+	"github.com/gin-gonic/gin"
+	"strings"
+)
+
+// Headers used by a client to call a route group with the "client" auth scheme
+const (
+	ClientIdHeader    = "x-client-id"
+	AccessTokenHeader = "x-access-token"
+)
+
+// ErrClientNotFound is returned by a ClientStore if the client id is not registered
+var ErrClientNotFound = errors.New("client not found")
+
+// Client is a caller of the protected APIs
+type Client struct {
+	ID       string `json:"id"`
+	Name     string `json:"name"`
+	Disabled bool   `json:"disabled"`
+
+	// TokenSha256 is the hex sha256 digest of the access token - the token itself is never stored
+	TokenSha256 string `json:"-"`
+}
+
+// TokenMatches checks the token against the stored digest in constant time
+func (c *Client) TokenMatches(token string) bool {
+	return subtle.ConstantTimeCompare([]byte(HashToken(token)), []byte(strings.ToLower(c.TokenSha256))) == 1
+}
+
+// HashToken gives the digest of an access token which is stored in the client registry e.g.
+//
+//	echo -n "<token>" | sha256sum
+func HashToken(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
+}
+
+// ClientStore is the registry of clients. It returns ErrClientNotFound if the client is not registered
+type ClientStore interface {
+	GetClient(ctx context.Context, clientID string) (*Client, error)
+}
+
+type clientContextKey struct{}
+
+// WithClient returns a context which carries the authenticated client
+func WithClient(ctx context.Context, client *Client) context.Context {
+	return context.WithValue(ctx, clientContextKey{}, client)
+}
+
+// ClientFromContext gives the client authenticated by the ClientAuthenticator. It works with the request context, and
+// with a *gin.Context which is passed as context by the handlers
+func ClientFromContext(ctx context.Context) (*Client, bool) {
+	if c, ok := ctx.(*gin.Context); ok && c.Request != nil {
+		ctx = c.Request.Context()
+	}
+	client, ok := ctx.Value(clientContextKey{}).(*Client)
+	return client, ok && client != nil
+}
+
+// CallerID gives the client id from client or bearer auth - the subject of the token if it has no client id - or ""
+// if the route is public
+func CallerID(ctx context.Context) string {
+	if client, ok := ClientFromContext(ctx); ok {
+		return client.ID
+	}
+	if principal, ok := PrincipalFromContext(ctx); ok {
+		if principal.ClientID != "" {
+			return principal.ClientID
+		}
+		return principal.Subject
+	}
+	return ""
+}
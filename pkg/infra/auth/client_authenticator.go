@@ -0,0 +1,68 @@
+package auth
+
+import (
+	"github.com/devlibx/go-template-project/pkg/apperror"
+	"github.com/devlibx/go-template-project/pkg/infra/router"
+	"github.com/devlibx/gox-base/v2"
+	"github.com/gin-gonic/gin"
+)
+
+// SchemeClient is the auth scheme of route groups which are called with a client id and access token
+const SchemeClient = "client"
+
+// ClientAuthenticator checks the x-client-id and x-access-token headers against the ClientStore, and puts the client
+// into the request context - see ClientFromContext
+type ClientAuthenticator struct {
+	gox.CrossFunction
+	store ClientStore
+}
+
+func NewClientAuthenticator(cf gox.CrossFunction, store ClientStore) *ClientAuthenticator {
+	return &ClientAuthenticator{CrossFunction: cf, store: store}
+}
+
+func (a *ClientAuthenticator) Scheme() string {
+	return SchemeClient
+}
+
+// Middleware rejects a request with 401 if the credentials are missing or wrong, and with 403 if the client is not in
+// the clients allowed by the route group
+func (a *ClientAuthenticator) Middleware(auth router.Auth) gin.HandlerFunc {
+	allowed := map[string]bool{}
+	for _, id := range auth.Clients {
+		allowed[id] = true
+	}
+
+	return func(c *gin.Context) {
+		clientID, token := c.GetHeader(ClientIdHeader), c.GetHeader(AccessTokenHeader)
+		if clientID == "" || token == "" {
+			a.reject(c, apperror.Unauthorized("missing_client_credentials", "missing client credentials"))
+			return
+		}
+
+		client, err := a.store.GetClient(c.Request.Context(), clientID)
+		if err == ErrClientNotFound {
+			a.reject(c, apperror.Unauthorized("invalid_client_credentials", "invalid client credentials"))
+			return
+		} else if err != nil {
+			a.reject(c, apperror.Unavailable("client_registry_unavailable", "client registry is not available").WithCause(err))
+			return
+		}
+
+		if client.Disabled || !client.TokenMatches(token) {
+			a.reject(c, apperror.Unauthorized("invalid_client_credentials", "invalid client credentials"))
+			return
+		}
+		if len(allowed) > 0 && !allowed[client.ID] {
+			a.reject(c, apperror.Forbidden("client_not_allowed", "client is not allowed to call this api"))
+			return
+		}
+
+		c.Request = c.Request.WithContext(WithClient(c.Request.Context(), client))
+		c.Next()
+	}
+}
+
+func (a *ClientAuthenticator) reject(c *gin.Context, err *apperror.Error) {
+	apperror.Abort(a, c, err)
+}
@@ -0,0 +1,116 @@
+package auth
+
+import (
+	"context"
+	"database/sql"
+	orderRoDataStore "github.com/devlibx/go-template-project/pkg/infra/database/mysql/user/ro"
+	"github.com/devlibx/gox-base/v2/errors"
+	"sync"
+	"time"
+)
+
+// Client registry backends
+const (
+	StoreConfig = "config"
+	StoreMySql  = "mysql"
+)
+
+// ClientAuthConfig selects the client registry. With the "config" store the clients are listed in the config, with
+// the "mysql" store they are read from the api_clients table and cached for cache_ttl_ms
+type ClientAuthConfig struct {
+	Store      string         `yaml:"store"`
+	CacheTtlMs int            `yaml:"cache_ttl_ms"`
+	Clients    []ClientConfig `yaml:"clients"`
+}
+
+// ClientConfig is a client of the "config" store. A client with an empty id is ignored, so a client given by env
+// vars can be left unset in an environment
+type ClientConfig struct {
+	ID          string `yaml:"id"`
+	Name        string `yaml:"name"`
+	TokenSha256 string `yaml:"token_sha256"`
+	Disabled    bool   `yaml:"disabled"`
+}
+
+func (c *ClientAuthConfig) SetupDefaults() {
+	if c.Store == "" {
+		c.Store = StoreConfig
+	}
+	if c.CacheTtlMs <= 0 {
+		c.CacheTtlMs = 60000
+	}
+}
+
+// NewClientStore builds the store selected by the config
+func NewClientStore(config *ClientAuthConfig, querier orderRoDataStore.Querier) (ClientStore, error) {
+	switch config.Store {
+	case StoreConfig:
+		return NewConfigClientStore(config), nil
+	case StoreMySql:
+		return NewMySqlClientStore(querier, time.Duration(config.CacheTtlMs)*time.Millisecond), nil
+	default:
+		return nil, errors.New("unknown client auth store: store=%s", config.Store)
+	}
+}
+
+type configClientStore struct {
+	clients map[string]*Client
+}
+
+// NewConfigClientStore gives a store with the clients listed in the config
+func NewConfigClientStore(config *ClientAuthConfig) ClientStore {
+	s := &configClientStore{clients: map[string]*Client{}}
+	for _, c := range config.Clients {
+		if c.ID != "" {
+			s.clients[c.ID] = &Client{ID: c.ID, Name: c.Name, Disabled: c.Disabled, TokenSha256: c.TokenSha256}
+		}
+	}
+	return s
+}
+
+func (s *configClientStore) GetClient(ctx context.Context, clientID string) (*Client, error) {
+	if client, ok := s.clients[clientID]; ok {
+		return client, nil
+	}
+	return nil, ErrClientNotFound
+}
+
+type cachedClient struct {
+	client    *Client
+	expiresAt time.Time
+}
+
+type mySqlClientStore struct {
+	querier orderRoDataStore.Querier
+	ttl     time.Duration
+	lock    sync.RWMutex
+	cache   map[string]cachedClient
+}
+
+// NewMySqlClientStore gives a store which reads clients from the api_clients table. A found client is cached for ttl,
+// so a disabled or rotated client is picked up after at most ttl
+func NewMySqlClientStore(querier orderRoDataStore.Querier, ttl time.Duration) ClientStore {
+	return &mySqlClientStore{querier: querier, ttl: ttl, cache: map[string]cachedClient{}}
+}
+
+func (s *mySqlClientStore) GetClient(ctx context.Context, clientID string) (*Client, error) {
+	s.lock.RLock()
+	cached, ok := s.cache[clientID]
+	s.lock.RUnlock()
+	if ok && time.Now().Before(cached.expiresAt) {
+		return cached.client, nil
+	}
+
+	row, err := s.querier.GetApiClient(ctx, clientID)
+	if err == sql.ErrNoRows {
+		return nil, ErrClientNotFound
+	} else if err != nil {
+		return nil, errors.Wrap(err, "failed to read client: clientId=%s", clientID)
+	}
+
+	client := &Client{ID: row.ClientID, Name: row.Name, Disabled: row.Disabled, TokenSha256: row.TokenSha256}
+	s.lock.Lock()
+	s.cache[clientID] = cachedClient{client: client, expiresAt: time.Now().Add(s.ttl)}
+	s.lock.Unlock()
+	return client, nil
+}
@@ -0,0 +1,114 @@
+package auth
+
+import (
+	"context"
+	"database/sql"
+	orderRoDataStore "github.com/devlibx/go-template-project/pkg/infra/database/mysql/user/ro"
+	"github.com/devlibx/go-template-project/pkg/infra/router"
+	"github.com/devlibx/gox-base/v2"
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/zap"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func newTestEngine(store ClientStore, allowed ...string) *gin.Engine {
+	authenticator := NewClientAuthenticator(gox.NewCrossFunction(zap.NewNop()), store)
+	engine := gin.New()
+	engine.Use(authenticator.Middleware(router.Auth{Scheme: SchemeClient, Clients: allowed}))
+	engine.GET("/", func(c *gin.Context) {
+		client, _ := ClientFromContext(c)
+		c.String(http.StatusOK, client.ID)
+	})
+	return engine
+}
+
+func call(engine *gin.Engine, clientID string, token string) *httptest.ResponseRecorder {
+	request := httptest.NewRequest(http.MethodGet, "/", nil)
+	request.Header.Set(ClientIdHeader, clientID)
+	request.Header.Set(AccessTokenHeader, token)
+	recorder := httptest.NewRecorder()
+	engine.ServeHTTP(recorder, request)
+	return recorder
+}
+
+func TestClientAuthenticator(t *testing.T) {
+	store := NewConfigClientStore(&ClientAuthConfig{Clients: []ClientConfig{
+		{ID: "c1", TokenSha256: HashToken("t1")},
+		{ID: "c2", TokenSha256: HashToken("t2")},
+		{ID: "c3", TokenSha256: HashToken("t3"), Disabled: true},
+		{ID: "", TokenSha256: HashToken("")},
+	}})
+	engine := newTestEngine(store)
+
+	response := call(engine, "c1", "t1")
+	assert.Equal(t, http.StatusOK, response.Code)
+	assert.Equal(t, "c1", response.Body.String())
+
+	assert.Equal(t, http.StatusUnauthorized, call(engine, "", "").Code)
+	assert.Equal(t, http.StatusUnauthorized, call(engine, "c1", "").Code)
+	assert.Equal(t, http.StatusUnauthorized, call(engine, "c1", "t2").Code)
+	assert.Equal(t, http.StatusUnauthorized, call(engine, "unknown", "t1").Code)
+	assert.Equal(t, http.StatusUnauthorized, call(engine, "c3", "t3").Code)
+
+	// Only c2 may call this group
+	engine = newTestEngine(store, "c2")
+	assert.Equal(t, http.StatusForbidden, call(engine, "c1", "t1").Code)
+	assert.Equal(t, http.StatusOK, call(engine, "c2", "t2").Code)
+}
+
+type testQuerier struct {
+	orderRoDataStore.Querier
+	clients map[string]*orderRoDataStore.ApiClient
+	calls   int
+	err     error
+}
+
+func (q *testQuerier) GetApiClient(ctx context.Context, clientID string) (*orderRoDataStore.ApiClient, error) {
+	q.calls++
+	if q.err != nil {
+		return nil, q.err
+	}
+	if client, ok := q.clients[clientID]; ok {
+		return client, nil
+	}
+	return nil, sql.ErrNoRows
+}
+
+func TestMySqlClientStore(t *testing.T) {
+	querier := &testQuerier{clients: map[string]*orderRoDataStore.ApiClient{
+		"c1": {ClientID: "c1", Name: "one", TokenSha256: HashToken("t1")},
+	}}
+	store := NewMySqlClientStore(querier, time.Minute)
+
+	client, err := store.GetClient(context.Background(), "c1")
+	assert.NoError(t, err)
+	assert.Equal(t, "one", client.Name)
+	assert.True(t, client.TokenMatches("t1"))
+
+	// Served from the cache
+	_, err = store.GetClient(context.Background(), "c1")
+	assert.NoError(t, err)
+	assert.Equal(t, 1, querier.calls)
+
+	_, err = store.GetClient(context.Background(), "unknown")
+	assert.Equal(t, ErrClientNotFound, err)
+
+	// A database error is not a rejected client
+	querier.err = sql.ErrConnDone
+	engine := newTestEngine(NewMySqlClientStore(querier, time.Minute))
+	assert.Equal(t, http.StatusServiceUnavailable, call(engine, "c1", "t1").Code)
+}
+
+func TestNewClientStore(t *testing.T) {
+	_, err := NewClientStore(&ClientAuthConfig{Store: "redis"}, nil)
+	assert.Error(t, err)
+
+	store, err := NewClientStore(&ClientAuthConfig{Store: StoreConfig}, nil)
+	assert.NoError(t, err)
+	_, err = store.GetClient(context.Background(), "c1")
+	assert.Equal(t, ErrClientNotFound, err)
+}
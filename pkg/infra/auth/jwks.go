@@ -0,0 +1,240 @@
+package auth
+
+import (
+	"context"
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rsa"
+	"encoding/base64"
+	"encoding/json"
+	"github.com/devlibx/gox-base/v2/errors"
+	"golang.org/x/sync/singleflight"
+	"io"
+	"math/big"
+	"net/http"
+	"os"
+	"sync/atomic"
+	"time"
+)
+
+// KeySet holds the public keys of a JWKS loaded from a file or a http endpoint. Keys are loaded again after the
+// refresh interval in the background, or when a token is signed with an unknown key id. A load is tried at most once
+// in minRefreshInterval, whether the last one worked or not - so a JWKS endpoint which is down is not called on every
+// request, and requests with a known key keep using the loaded keys. Requests read the keys without a lock - a load
+// replaces them, and requests which need a load at the same time share it
+type KeySet struct {
+	load               func(ctx context.Context) ([]byte, error)
+	refreshInterval    time.Duration
+	minRefreshInterval time.Duration
+
+	keys        atomic.Pointer[loadedKeys]
+	loads       singleflight.Group
+	lastAttempt atomic.Int64
+}
+
+// loadedKeys are the keys of one load of the JWKS
+type loadedKeys struct {
+	byKid    map[string]crypto.PublicKey
+	loadedAt time.Time
+}
+
+// NewKeySet loads the JWKS - it fails if the JWKS can not be read or has no usable key
+func NewKeySet(ctx context.Context, config *JwtAuthConfig) (*KeySet, error) {
+	k := &KeySet{
+		refreshInterval:    time.Duration(config.JwksRefreshIntervalMs) * time.Millisecond,
+		minRefreshInterval: 10 * time.Second,
+	}
+	if config.JwksFile != "" {
+		k.load = func(ctx context.Context) ([]byte, error) {
+			return os.ReadFile(config.JwksFile)
+		}
+	} else {
+		client := &http.Client{Timeout: 5 * time.Second}
+		k.load = func(ctx context.Context) ([]byte, error) {
+			return fetchJwks(ctx, client, config.JwksUrl)
+		}
+	}
+
+	if _, err := k.refresh(ctx); err != nil {
+		return nil, err
+	}
+	return k, nil
+}
+
+// Key gives the key with the key id. An empty kid is allowed if the JWKS has a single key. Only a request with an
+// unknown key id waits for a load
+func (k *KeySet) Key(ctx context.Context, kid string) (crypto.PublicKey, error) {
+	current := k.keys.Load()
+	key, found := current.find(kid)
+	if found {
+		if k.refreshInterval > 0 && time.Since(current.loadedAt) >= k.refreshInterval && k.mayRefresh() {
+			go func() { _, _ = k.refresh(context.WithoutCancel(ctx)) }()
+		}
+		return key, nil
+	}
+
+	loaded, err := k.refresh(ctx)
+	if err != nil {
+		return nil, err
+	} else if key, found = loaded.find(kid); !found {
+		return nil, errors.New("no key in jwks: kid=%s", kid)
+	}
+	return key, nil
+}
+
+// mayRefresh is true if minRefreshInterval passed since the last load was tried
+func (k *KeySet) mayRefresh() bool {
+	return time.Since(time.Unix(0, k.lastAttempt.Load())) >= k.minRefreshInterval
+}
+
+func (l *loadedKeys) find(kid string) (crypto.PublicKey, bool) {
+	if kid == "" && len(l.byKid) == 1 {
+		for _, key := range l.byKid {
+			return key, true
+		}
+	}
+	key, ok := l.byKid[kid]
+	return key, ok
+}
+
+// refresh loads the JWKS once for all requests which ask at the same time, and gives the current keys without a load
+// if a load was tried in minRefreshInterval. The load is not cancelled with the context of the request which started
+// it, as other requests wait for it too - the http client has its own timeout
+func (k *KeySet) refresh(ctx context.Context) (*loadedKeys, error) {
+	loaded, err, _ := k.loads.Do("jwks", func() (interface{}, error) {
+		if current := k.keys.Load(); current != nil && !k.mayRefresh() {
+			return current, nil
+		}
+		k.lastAttempt.Store(time.Now().UnixNano())
+		data, err := k.load(context.WithoutCancel(ctx))
+		if err != nil {
+			return nil, errors.Wrap(err, "failed to load jwks")
+		}
+		keys, err := parseJwks(data)
+		if err != nil {
+			return nil, err
+		}
+		loaded := &loadedKeys{byKid: keys, loadedAt: time.Now()}
+		k.keys.Store(loaded)
+		return loaded, nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return loaded.(*loadedKeys), nil
+}
+
+func fetchJwks(ctx context.Context, client *http.Client, url string) ([]byte, error) {
+	request, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return nil, err
+	}
+	response, err := client.Do(request)
+	if err != nil {
+		return nil, err
+	}
+	defer response.Body.Close()
+	if response.StatusCode != http.StatusOK {
+		return nil, errors.New("unexpected status from jwks endpoint: url=%s, status=%d", url, response.StatusCode)
+	}
+	return io.ReadAll(io.LimitReader(response.Body, 1<<20))
+}
+
+type jsonWebKey struct {
+	Kty string `json:"kty"`
+	Kid string `json:"kid"`
+	Use string `json:"use"`
+	N   string `json:"n"`
+	E   string `json:"e"`
+	Crv string `json:"crv"`
+	X   string `json:"x"`
+	Y   string `json:"y"`
+}
+
+// parseJwks reads the RSA and EC signing keys of a JWKS, other keys are skipped
+func parseJwks(data []byte) (map[string]crypto.PublicKey, error) {
+	jwks := struct {
+		Keys []jsonWebKey `json:"keys"`
+	}{}
+	if err := json.Unmarshal(data, &jwks); err != nil {
+		return nil, errors.Wrap(err, "failed to parse jwks")
+	}
+
+	keys := map[string]crypto.PublicKey{}
+	for _, jwk := range jwks.Keys {
+		if jwk.Use != "" && jwk.Use != "sig" {
+			continue
+		}
+		var key crypto.PublicKey
+		var err error
+		switch jwk.Kty {
+		case "RSA":
+			key, err = jwk.rsaKey()
+		case "EC":
+			key, err = jwk.ecKey()
+		default:
+			continue
+		}
+		if err != nil {
+			return nil, errors.Wrap(err, "bad key in jwks: kid=%s", jwk.Kid)
+		}
+		keys[jwk.Kid] = key
+	}
+	if len(keys) == 0 {
+		return nil, errors.New("jwks has no RSA or EC signing key")
+	}
+	return keys, nil
+}
+
+func (j jsonWebKey) rsaKey() (*rsa.PublicKey, error) {
+	n, err := decodeBigInt(j.N)
+	if err != nil {
+		return nil, err
+	}
+	e, err := decodeBigInt(j.E)
+	if err != nil {
+		return nil, err
+	}
+	if !e.IsInt64() || e.Int64() > 1<<31-1 {
+		return nil, errors.New("bad rsa exponent")
+	}
+	return &rsa.PublicKey{N: n, E: int(e.Int64())}, nil
+}
+
+func (j jsonWebKey) ecKey() (*ecdsa.PublicKey, error) {
+	var curve elliptic.Curve
+	switch j.Crv {
+	case "P-256":
+		curve = elliptic.P256()
+	case "P-384":
+		curve = elliptic.P384()
+	case "P-521":
+		curve = elliptic.P521()
+	default:
+		return nil, errors.New("unsupported curve: crv=%s", j.Crv)
+	}
+	x, err := decodeBigInt(j.X)
+	if err != nil {
+		return nil, err
+	}
+	y, err := decodeBigInt(j.Y)
+	if err != nil {
+		return nil, err
+	}
+	if !curve.IsOnCurve(x, y) {
+		return nil, errors.New("point is not on curve: crv=%s", j.Crv)
+	}
+	return &ecdsa.PublicKey{Curve: curve, X: x, Y: y}, nil
+}
+
+func decodeBigInt(value string) (*big.Int, error) {
+	if value == "" {
+		return nil, errors.New("missing key parameter")
+	}
+	data, err := base64.RawURLEncoding.DecodeString(value)
+	if err != nil {
+		return nil, errors.Wrap(err, "bad base64url key parameter")
+	}
+	return new(big.Int).SetBytes(data), nil
+}
@@ -0,0 +1,12 @@
+package auth
+
+import (
+	"github.com/devlibx/go-template-project/pkg/infra/router"
+	"go.uber.org/fx"
+)
+
+// Provider gives the authenticators for the "client" and "bearer" auth schemes of route groups
+var Provider = fx.Options(
+	fx.Provide(NewClientStore),
+	router.ProvideAuthenticator(NewClientAuthenticator, NewBearerAuthenticator),
+)
@@ -0,0 +1,201 @@
+package capture
+
+import (
+	"context"
+	consumers "github.com/devlibx/go-template-project/pkg/infra/messaging"
+	"github.com/devlibx/go-template-project/pkg/infra/requestid"
+	"github.com/devlibx/go-template-project/pkg/infra/router"
+	"github.com/devlibx/go-template-project/pkg/infra/shutdown"
+	"github.com/devlibx/gox-base/v2"
+	goxBaseConfig "github.com/devlibx/gox-base/v2/config"
+	goxHttpApi "github.com/devlibx/gox-http/v4/api"
+	goxMessaging "github.com/devlibx/gox-messaging/v2"
+	"github.com/gin-gonic/gin"
+	"go.uber.org/fx"
+	"go.uber.org/zap"
+	"sync"
+	"time"
+)
+
+// Config selects the routes whose requests and responses are captured. Capture is turned on by enable_request_logging
+// (publish to the producer) and enable_request_logging_to_console of gox_http_request_response_security_config
+type Config struct {
+	// Routes are route templates without the app name prefix e.g. /api/v1/post/:postId - only these are captured
+	Routes []string `yaml:"routes"`
+
+	// Producer is the messaging producer the records are published to
+	Producer string `yaml:"producer"`
+
+	// MaxBodyBytes is the size a body is cut to after masking. A body larger than MaxCaptureBytes can not be masked,
+	// so it is not captured at all - only its size is sent
+	MaxBodyBytes    int `yaml:"max_body_bytes"`
+	MaxCaptureBytes int `yaml:"max_capture_bytes"`
+
+	// QueueSize is the number of records which wait to be published - a record is dropped if the queue is full
+	QueueSize int `yaml:"queue_size"`
+}
+
+func (c *Config) SetupDefaults() {
+	if c.Producer == "" {
+		c.Producer = "requestResponseLogging"
+	}
+	if c.MaxBodyBytes <= 0 {
+		c.MaxBodyBytes = 4096
+	}
+	if c.MaxCaptureBytes <= 0 {
+		c.MaxCaptureBytes = 1024 * 1024
+	}
+	if c.MaxCaptureBytes < c.MaxBodyBytes {
+		c.MaxCaptureBytes = c.MaxBodyBytes
+	}
+	if c.QueueSize <= 0 {
+		c.QueueSize = 1000
+	}
+}
+
+// Capturer captures the requests and responses of the configured routes as gin middleware, and publishes them from a
+// background worker - the request does not wait for masking or publishing
+type Capturer struct {
+	gox.CrossFunction
+	config         *Config
+	templates      router.RouteTemplates
+	routes         map[string]bool
+	securityConfig func() *goxHttpApi.RequestResponseSecurityConfig
+	factory        goxMessaging.Factory
+	logger         *zap.Logger
+
+	queue    chan *capture
+	stop     chan struct{}
+	done     chan struct{}
+	stopOnce sync.Once
+	producer goxMessaging.Producer
+}
+
+// NewCapturer builds the capturer. securityConfig gives the current gox_http_request_response_security_config, so a
+// reload of the flags and the masked keys is used on the next request
+func NewCapturer(cf gox.CrossFunction, config *Config, app *goxBaseConfig.App, securityConfig func() *goxHttpApi.RequestResponseSecurityConfig, factory consumers.MessagingFactory) *Capturer {
+	c := &Capturer{
+		CrossFunction:  cf,
+		config:         config,
+		templates:      router.NewRouteTemplates(app.AppName),
+		routes:         map[string]bool{},
+		securityConfig: securityConfig,
+		factory:        factory,
+		logger:         cf.Logger().Named("capture"),
+		queue:          make(chan *capture, config.QueueSize),
+		stop:           make(chan struct{}),
+		done:           make(chan struct{}),
+	}
+	for _, route := range config.Routes {
+		c.routes[route] = true
+	}
+	return c
+}
+
+// NewCapturerLifecycle starts the worker with the application. The queue is drained in the consumers shutdown stage,
+// which runs after the http server is drained and before the producers are flushed
+func NewCapturerLifecycle(lifecycle fx.Lifecycle, coordinator *shutdown.Coordinator, c *Capturer) error {
+	lifecycle.Append(fx.Hook{
+		OnStart: func(ctx context.Context) error {
+			go c.work()
+			return nil
+		},
+	})
+	return coordinator.Register(shutdown.StageConsumers, "request_capture", c.Stop)
+}
+
+// Middleware captures the request of a configured route if request logging is enabled
+func (c *Capturer) Middleware() gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		securityConfig := c.securityConfig()
+		if securityConfig == nil || (!securityConfig.EnableRequestLogging && !securityConfig.EnableRequestLoggingToConsole) ||
+			!c.routes[c.templates.Of(ctx)] {
+			ctx.Next()
+			return
+		}
+
+		start := time.Now()
+		requestBody := &buffer{limit: c.config.MaxCaptureBytes}
+		if ctx.Request.Body != nil {
+			ctx.Request.Body = &teeBody{ReadCloser: ctx.Request.Body, buffer: requestBody}
+		}
+		writer := &responseWriter{ResponseWriter: ctx.Writer, buffer: &buffer{limit: c.config.MaxCaptureBytes}}
+		ctx.Writer = writer
+
+		ctx.Next()
+
+		captured := newCapture(ctx, securityConfig, start, requestBody, writer)
+		select {
+		case c.queue <- captured:
+		default:
+			c.Metric().Tagged(map[string]string{"status": "dropped"}).Counter("request_capture").Inc(1)
+		}
+	}
+}
+
+// Stop publishes the records in the queue, and stops the worker
+func (c *Capturer) Stop(ctx context.Context) error {
+	c.stopOnce.Do(func() { close(c.stop) })
+	select {
+	case <-c.done:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+func (c *Capturer) work() {
+	defer close(c.done)
+	for {
+		select {
+		case captured := <-c.queue:
+			c.publish(captured)
+		case <-c.stop:
+			for {
+				select {
+				case captured := <-c.queue:
+					c.publish(captured)
+				default:
+					return
+				}
+			}
+		}
+	}
+}
+
+func (c *Capturer) publish(captured *capture) {
+	record := captured.toRecord(c.config.MaxBodyBytes)
+	ctx, cancel := context.WithTimeout(requestid.WithRequestId(context.Background(), record.RequestId), 5*time.Second)
+	defer cancel()
+	logger := requestid.Logger(ctx, c.logger)
+
+	if captured.securityConfig.EnableRequestLoggingToConsole {
+		logger.Info("request captured", zap.Any("record", record))
+	}
+	if !captured.securityConfig.EnableRequestLogging {
+		return
+	}
+
+	if c.producer == nil {
+		producer, err := c.factory.GetProducer(c.config.Producer)
+		if err != nil {
+			logger.Debug("request capture producer is not available", zap.String("producer", c.config.Producer), zap.Error(err))
+			c.Metric().Tagged(map[string]string{"status": "failed"}).Counter("request_capture").Inc(1)
+			return
+		}
+		c.producer = producer
+	}
+
+	select {
+	case response := <-c.producer.Send(ctx, &goxMessaging.Message{Key: record.RequestId, Payload: record}):
+		if response != nil && response.Err != nil {
+			logger.Debug("failed to publish request capture", zap.String("producer", c.config.Producer), zap.Error(response.Err))
+			c.Metric().Tagged(map[string]string{"status": "failed"}).Counter("request_capture").Inc(1)
+			return
+		}
+	case <-ctx.Done():
+		c.Metric().Tagged(map[string]string{"status": "failed"}).Counter("request_capture").Inc(1)
+		return
+	}
+	c.Metric().Tagged(map[string]string{"status": "published"}).Counter("request_capture").Inc(1)
+}
@@ -0,0 +1,167 @@
+package capture
+
+import (
+	"context"
+	"encoding/json"
+	"github.com/devlibx/go-template-project/pkg/infra/requestid"
+	"github.com/devlibx/gox-base/v2"
+	goxBaseConfig "github.com/devlibx/gox-base/v2/config"
+	"github.com/devlibx/gox-base/v2/errors"
+	goxHttpApi "github.com/devlibx/gox-http/v4/api"
+	goxMessaging "github.com/devlibx/gox-messaging/v2"
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+	"go.uber.org/zap/zaptest/observer"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+type testFactory struct {
+	goxMessaging.Factory
+	producer *testProducer
+}
+
+func (f *testFactory) GetProducer(name string) (goxMessaging.Producer, error) {
+	if name != "requestResponseLogging" {
+		return nil, errors.New("producer not found: name=%s", name)
+	}
+	return f.producer, nil
+}
+
+type testProducer struct {
+	lock    sync.Mutex
+	records []*Record
+}
+
+func (p *testProducer) Send(ctx context.Context, message *goxMessaging.Message) chan *goxMessaging.Response {
+	p.lock.Lock()
+	defer p.lock.Unlock()
+	p.records = append(p.records, message.Payload.(*Record))
+	response := make(chan *goxMessaging.Response, 1)
+	response <- &goxMessaging.Response{}
+	return response
+}
+
+func (p *testProducer) Stop() error {
+	return nil
+}
+
+func newTestEngine(config *Config, securityConfig *goxHttpApi.RequestResponseSecurityConfig) (*gin.Engine, *Capturer, *testProducer, *observer.ObservedLogs) {
+	core, logs := observer.New(zapcore.DebugLevel)
+	producer := &testProducer{}
+	config.SetupDefaults()
+	capturer := NewCapturer(gox.NewCrossFunction(zap.New(core)), config, &goxBaseConfig.App{AppName: "app"},
+		func() *goxHttpApi.RequestResponseSecurityConfig { return securityConfig },
+		&testFactory{producer: producer},
+	)
+
+	engine := gin.New()
+	group := engine.Group("/app")
+	group.Use(requestid.Middleware(), capturer.Middleware())
+	group.POST("/api/v1/order", func(c *gin.Context) {
+		body, _ := io.ReadAll(c.Request.Body)
+		c.Header("X-Secret", "s1")
+		c.Data(http.StatusCreated, "application/json", body)
+	})
+	group.GET("/api/v1/other", func(c *gin.Context) { c.Status(http.StatusOK) })
+	return engine, capturer, producer, logs
+}
+
+func call(engine *gin.Engine, method string, path string, body string) {
+	request := httptest.NewRequest(method, path, strings.NewReader(body))
+	request.Header.Set(requestid.Header, "req-1")
+	request.Header.Set("X-Tenant-ID", "t1")
+	request.Header.Set("Authorization", "Bearer secret")
+	engine.ServeHTTP(httptest.NewRecorder(), request)
+}
+
+func stop(t *testing.T, capturer *Capturer) {
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+	assert.NoError(t, capturer.Stop(ctx))
+}
+
+func TestCapturer(t *testing.T) {
+	engine, capturer, producer, logs := newTestEngine(&Config{Routes: []string{"/api/v1/order"}, MaxBodyBytes: 100}, &goxHttpApi.RequestResponseSecurityConfig{
+		EnableRequestLogging:  true,
+		IgnoreRequestHeaders:  []string{"x-tenant-id"},
+		IgnoreResponseHeaders: []string{"X-Secret"},
+		IgnoreKeysInRequest:   []string{"account_no"},
+		IgnoreKeysInResponse:  []string{"mid"},
+	})
+	go capturer.work()
+
+	call(engine, http.MethodPost, "/app/api/v1/order?account_no=1&q=2", `{"account_no": "a1", "mid": "m1", "items": [{"account_no": "a2", "qty": 12345678901234567890}]}`)
+	call(engine, http.MethodPost, "/app/api/v1/order", strings.Repeat("x", 150))
+	call(engine, http.MethodGet, "/app/api/v1/other", "")
+	stop(t, capturer)
+
+	assert.Len(t, producer.records, 2)
+	record := producer.records[0]
+	assert.Equal(t, "req-1", record.RequestId)
+	assert.Equal(t, http.MethodPost, record.Method)
+	assert.Equal(t, "/app/api/v1/order", record.Route)
+	assert.Equal(t, "/app/api/v1/order?account_no=%2A%2A%2A%2A&q=2", record.Url)
+	assert.Equal(t, http.StatusCreated, record.Status)
+	assert.Equal(t, "****", record.RequestHeaders["X-Tenant-Id"])
+	assert.Equal(t, "****", record.RequestHeaders["Authorization"])
+	assert.Equal(t, "req-1", record.RequestHeaders["X-Request-Id"])
+	assert.Equal(t, "****", record.ResponseHeaders["X-Secret"])
+
+	// Keys are masked at any depth, and only the keys of the request or the response
+	var request, response map[string]interface{}
+	assert.NoError(t, json.Unmarshal([]byte(record.Request.Body), &request))
+	assert.NoError(t, json.Unmarshal([]byte(record.Response.Body), &response))
+	assert.Equal(t, "****", request["account_no"])
+	assert.Equal(t, "m1", request["mid"])
+	assert.Equal(t, "****", request["items"].([]interface{})[0].(map[string]interface{})["account_no"])
+	assert.Contains(t, record.Request.Body, "12345678901234567890")
+	assert.Equal(t, "a1", response["account_no"])
+	assert.Equal(t, "****", response["mid"])
+
+	// Large bodies are cut
+	assert.Len(t, producer.records[1].Request.Body, 100)
+	assert.True(t, producer.records[1].Request.Truncated)
+	assert.Equal(t, 150, producer.records[1].Request.Size)
+
+	// Nothing is logged to the console
+	assert.Equal(t, 0, logs.FilterMessage("request captured").Len())
+}
+
+func TestCapturer_TooLargeToMask(t *testing.T) {
+	engine, capturer, producer, _ := newTestEngine(&Config{Routes: []string{"/api/v1/order"}, MaxBodyBytes: 10, MaxCaptureBytes: 20}, &goxHttpApi.RequestResponseSecurityConfig{
+		EnableRequestLogging: true,
+		IgnoreKeysInRequest:  []string{"account_no"},
+	})
+	go capturer.work()
+
+	call(engine, http.MethodPost, "/app/api/v1/order", `{"account_no": "a1", "name": "n1"}`)
+	stop(t, capturer)
+
+	assert.Equal(t, Body{Size: 34, Truncated: true}, producer.records[0].Request)
+	assert.Equal(t, Body{Size: 34, Truncated: true}, producer.records[0].Response)
+}
+
+func TestCapturer_Flags(t *testing.T) {
+	securityConfig := &goxHttpApi.RequestResponseSecurityConfig{EnableRequestLoggingToConsole: true}
+	engine, capturer, producer, logs := newTestEngine(&Config{Routes: []string{"/api/v1/order"}}, securityConfig)
+	go capturer.work()
+
+	// Only logged to the console
+	call(engine, http.MethodPost, "/app/api/v1/order", `{}`)
+
+	// Nothing is captured if both flags are off
+	securityConfig.EnableRequestLoggingToConsole = false
+	call(engine, http.MethodPost, "/app/api/v1/order", `{}`)
+	stop(t, capturer)
+
+	assert.Empty(t, producer.records)
+	assert.Equal(t, 1, logs.FilterMessage("request captured").Len())
+}
@@ -0,0 +1,225 @@
+package capture
+
+import (
+	"bytes"
+	"encoding/json"
+	"github.com/devlibx/go-template-project/pkg/infra/auth"
+	"github.com/devlibx/go-template-project/pkg/infra/requestid"
+	goxHttpApi "github.com/devlibx/gox-http/v4/api"
+	"github.com/gin-gonic/gin"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+)
+
+// defaultMask replaces masked values if mask_string is not set
+const defaultMask = "****"
+
+// headersAlwaysMasked are masked even if they are not in ignore_request_headers / ignore_response_headers
+var headersAlwaysMasked = []string{"Authorization", "Proxy-Authorization", "Cookie", "Set-Cookie"}
+
+// Record is the captured request and response which is published
+type Record struct {
+	RequestId       string            `json:"request_id,omitempty"`
+	Timestamp       time.Time         `json:"timestamp"`
+	Method          string            `json:"method"`
+	Route           string            `json:"route"`
+	Url             string            `json:"url"`
+	Status          int               `json:"status"`
+	LatencyMs       int64             `json:"latency_ms"`
+	ClientId        string            `json:"client_id,omitempty"`
+	RequestHeaders  map[string]string `json:"request_headers"`
+	ResponseHeaders map[string]string `json:"response_headers"`
+	Request         Body              `json:"request"`
+	Response        Body              `json:"response"`
+}
+
+// Body is a masked body. Truncated is set if the body was cut, or not captured because it was too large to mask
+type Body struct {
+	Body      string `json:"body,omitempty"`
+	Size      int    `json:"size"`
+	Truncated bool   `json:"truncated,omitempty"`
+}
+
+// capture is the raw data of a request, it is masked by the worker
+type capture struct {
+	securityConfig  *goxHttpApi.RequestResponseSecurityConfig
+	record          *Record
+	url             *url.URL
+	requestHeaders  http.Header
+	responseHeaders http.Header
+	requestBody     *buffer
+	responseBody    *buffer
+}
+
+func newCapture(c *gin.Context, securityConfig *goxHttpApi.RequestResponseSecurityConfig, start time.Time, requestBody *buffer, writer *responseWriter) *capture {
+	// The config is copied, so the worker uses the flags which were set when the request was captured
+	u, config := *c.Request.URL, *securityConfig
+	return &capture{
+		securityConfig: &config,
+		record: &Record{
+			RequestId: requestid.FromContext(c),
+			Timestamp: start,
+			Method:    c.Request.Method,
+			Route:     c.FullPath(),
+			Status:    writer.Status(),
+			LatencyMs: time.Since(start).Milliseconds(),
+			ClientId:  auth.CallerID(c),
+		},
+		url:             &u,
+		requestHeaders:  c.Request.Header.Clone(),
+		responseHeaders: writer.Header().Clone(),
+		requestBody:     requestBody,
+		responseBody:    writer.buffer,
+	}
+}
+
+// toRecord masks the headers, query params and JSON keys given in the security config, and cuts the bodies to maxBodyBytes
+func (c *capture) toRecord(maxBodyBytes int) *Record {
+	mask := c.securityConfig.MaskString
+	if mask == "" {
+		mask = defaultMask
+	}
+	requestKeys, responseKeys := toLowerSet(c.securityConfig.IgnoreKeysInRequest), toLowerSet(c.securityConfig.IgnoreKeysInResponse)
+
+	record := c.record
+	record.RequestHeaders = maskHeaders(c.requestHeaders, c.securityConfig.IgnoreRequestHeaders, mask)
+	record.ResponseHeaders = maskHeaders(c.responseHeaders, c.securityConfig.IgnoreResponseHeaders, mask)
+	record.Request = maskBody(c.requestBody, requestKeys, mask, maxBodyBytes)
+	record.Response = maskBody(c.responseBody, responseKeys, mask, maxBodyBytes)
+
+	query := c.url.Query()
+	for key := range query {
+		if requestKeys[strings.ToLower(key)] {
+			query.Set(key, mask)
+		}
+	}
+	c.url.RawQuery = query.Encode()
+	record.Url = c.url.RequestURI()
+	return record
+}
+
+func maskHeaders(header http.Header, ignored []string, mask string) map[string]string {
+	masked := map[string]bool{}
+	for _, name := range headersAlwaysMasked {
+		masked[http.CanonicalHeaderKey(name)] = true
+	}
+	for _, name := range ignored {
+		masked[http.CanonicalHeaderKey(name)] = true
+	}
+	out := make(map[string]string, len(header))
+	for name, values := range header {
+		if masked[http.CanonicalHeaderKey(name)] {
+			out[name] = mask
+		} else {
+			out[name] = strings.Join(values, ",")
+		}
+	}
+	return out
+}
+
+// maskBody masks the keys in a JSON body at any depth. A body which is not JSON is sent as it is
+func maskBody(b *buffer, keys map[string]bool, mask string, maxBodyBytes int) Body {
+	body := Body{Size: b.size}
+	if b.overflow {
+		body.Truncated = true
+		return body
+	}
+
+	data := b.Bytes()
+	if len(data) == 0 {
+		return body
+	}
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	decoder.UseNumber()
+	var value interface{}
+	if err := decoder.Decode(&value); err == nil && len(keys) > 0 {
+		if masked, err := json.Marshal(maskValue(value, keys, mask)); err == nil {
+			data = masked
+		}
+	}
+
+	if len(data) > maxBodyBytes {
+		data = data[:maxBodyBytes]
+		body.Truncated = true
+	}
+	body.Body = strings.ToValidUTF8(string(data), "")
+	return body
+}
+
+func maskValue(value interface{}, keys map[string]bool, mask string) interface{} {
+	switch v := value.(type) {
+	case map[string]interface{}:
+		for key, nested := range v {
+			if keys[strings.ToLower(key)] {
+				v[key] = mask
+			} else {
+				v[key] = maskValue(nested, keys, mask)
+			}
+		}
+	case []interface{}:
+		for i, nested := range v {
+			v[i] = maskValue(nested, keys, mask)
+		}
+	}
+	return value
+}
+
+func toLowerSet(values []string) map[string]bool {
+	set := map[string]bool{}
+	for _, v := range values {
+		set[strings.ToLower(v)] = true
+	}
+	return set
+}
+
+// buffer keeps up to limit bytes - a body which is larger is only counted
+type buffer struct {
+	bytes.Buffer
+	limit    int
+	size     int
+	overflow bool
+}
+
+func (b *buffer) capture(p []byte) {
+	b.size += len(p)
+	if b.overflow {
+		return
+	}
+	if b.size > b.limit {
+		b.overflow = true
+		b.Reset()
+		return
+	}
+	b.Write(p)
+}
+
+// teeBody captures the request body while the handler reads it
+type teeBody struct {
+	io.ReadCloser
+	buffer *buffer
+}
+
+func (t *teeBody) Read(p []byte) (int, error) {
+	n, err := t.ReadCloser.Read(p)
+	t.buffer.capture(p[:n])
+	return n, err
+}
+
+// responseWriter captures the response body
+type responseWriter struct {
+	gin.ResponseWriter
+	buffer *buffer
+}
+
+func (w *responseWriter) Write(p []byte) (int, error) {
+	w.buffer.capture(p)
+	return w.ResponseWriter.Write(p)
+}
+
+func (w *responseWriter) WriteString(s string) (int, error) {
+	w.buffer.capture([]byte(s))
+	return w.ResponseWriter.WriteString(s)
+}
@@ -6,10 +6,12 @@ import (
 	"fmt"
 	orderRoDataStore "github.com/devlibx/go-template-project/pkg/infra/database/mysql/user/ro"
 	ordersDataStore "github.com/devlibx/go-template-project/pkg/infra/database/mysql/user/rw"
+	"github.com/devlibx/go-template-project/pkg/infra/shutdown"
 	"github.com/devlibx/gox-base/v2"
 	"github.com/devlibx/gox-base/v2/errors"
 	_ "github.com/go-sql-driver/mysql"
 	"go.uber.org/fx"
+	"io"
 	"time"
 )
 
@@ -61,8 +63,34 @@ var Provider = fx.Options(
 		q, err := orderRoDataStore.Prepare(context.Background(), dbConnections.OrdersSqlDbConnection)
 		return q, q, err
 	}),
+
+	// Close prepared statements and connections in the last stage of shutdown
+	fx.Invoke(registerShutdown),
 )
 
+func registerShutdown(coordinator *shutdown.Coordinator, dbConnections *DbConnections, ordersQueries *ordersDataStore.Queries, orderRoQueries *orderRoDataStore.Queries) error {
+	if err := coordinator.Register(shutdown.StageDatabase, "mysql.orders", func(ctx context.Context) error {
+		return closeDatabase(ordersQueries, dbConnections.OrdersSqlDbConnection)
+	}); err != nil {
+		return err
+	}
+	return coordinator.Register(shutdown.StageDatabase, "mysql.orders_ro", func(ctx context.Context) error {
+		return closeDatabase(orderRoQueries, dbConnections.OrderRoSqlDbConnection)
+	})
+}
+
+// closeDatabase closes the prepared statements and then the connection pool - both are closed even if one fails
+func closeDatabase(queries io.Closer, db *sql.DB) error {
+	queriesErr := queries.Close()
+	if err := db.Close(); err != nil {
+		return errors.Wrap(err, "failed to close database connection")
+	}
+	if queriesErr != nil {
+		return errors.Wrap(queriesErr, "failed to close prepared statements")
+	}
+	return nil
+}
+
 func buildDatabaseConnection(configProvider ConfigProvider) (*sql.DB, error) {
 	// Setup default values if missing
 	configProvider.SetupDefault()
@@ -0,0 +1,43 @@
+package database
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"errors"
+	"github.com/devlibx/go-template-project/pkg/apperror"
+	"github.com/go-sql-driver/mysql"
+	"net"
+)
+
+// mysqlDuplicateEntry is the MySQL error number of a duplicate key
+const mysqlDuplicateEntry = 1062
+
+// ToAppError maps a database error to a domain error. notFound is returned for sql.ErrNoRows, so the data store gives
+// the code e.g. apperror.NotFound("user_not_found", "user %s does not exist", userID)
+func ToAppError(err error, notFound *apperror.Error) error {
+	if err == nil {
+		return nil
+	}
+
+	var appErr *apperror.Error
+	var mysqlErr *mysql.MySQLError
+	var netErr net.Error
+	switch {
+	case errors.As(err, &appErr):
+		return err
+	case errors.Is(err, sql.ErrNoRows):
+		if notFound == nil {
+			notFound = apperror.NotFound("not_found", "not found")
+		}
+		return notFound.WithCause(err)
+	case errors.As(err, &mysqlErr) && mysqlErr.Number == mysqlDuplicateEntry:
+		return apperror.Conflict("already_exists", "already exists").WithCause(err)
+	case errors.Is(err, context.DeadlineExceeded) || (errors.As(err, &netErr) && netErr.Timeout()):
+		return apperror.DeadlineExceeded("database_timeout", "database did not respond in time").WithCause(err)
+	case errors.Is(err, driver.ErrBadConn) || errors.Is(err, sql.ErrConnDone) || errors.As(err, &netErr):
+		return apperror.Unavailable("database_unavailable", "database is not available").WithCause(err)
+	default:
+		return apperror.Internal("database_error", "database error").WithCause(err)
+	}
+}
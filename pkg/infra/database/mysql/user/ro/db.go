@@ -27,12 +27,21 @@ func Prepare(ctx context.Context, db DBTX) (*Queries, error) {
 	if q.getAllOrdersStmt, err = db.PrepareContext(ctx, getAllOrders); err != nil {
 		return nil, fmt.Errorf("error preparing query GetAllOrders: %w", err)
 	}
+	if q.getApiClientStmt, err = db.PrepareContext(ctx, getApiClient); err != nil {
+		return nil, fmt.Errorf("error preparing query GetApiClient: %w", err)
+	}
 	if q.getOrderByIDStmt, err = db.PrepareContext(ctx, getOrderByID); err != nil {
 		return nil, fmt.Errorf("error preparing query GetOrderByID: %w", err)
 	}
 	if q.getOrderByIdNewStmt, err = db.PrepareContext(ctx, getOrderByIdNew); err != nil {
 		return nil, fmt.Errorf("error preparing query GetOrderByIdNew: %w", err)
 	}
+	if q.listOrdersStmt, err = db.PrepareContext(ctx, listOrders); err != nil {
+		return nil, fmt.Errorf("error preparing query ListOrders: %w", err)
+	}
+	if q.listOrdersAfterStmt, err = db.PrepareContext(ctx, listOrdersAfter); err != nil {
+		return nil, fmt.Errorf("error preparing query ListOrdersAfter: %w", err)
+	}
 	return &q, nil
 }
 
@@ -43,6 +52,11 @@ func (q *Queries) Close() error {
 			err = fmt.Errorf("error closing getAllOrdersStmt: %w", cerr)
 		}
 	}
+	if q.getApiClientStmt != nil {
+		if cerr := q.getApiClientStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing getApiClientStmt: %w", cerr)
+		}
+	}
 	if q.getOrderByIDStmt != nil {
 		if cerr := q.getOrderByIDStmt.Close(); cerr != nil {
 			err = fmt.Errorf("error closing getOrderByIDStmt: %w", cerr)
@@ -53,6 +67,16 @@ func (q *Queries) Close() error {
 			err = fmt.Errorf("error closing getOrderByIdNewStmt: %w", cerr)
 		}
 	}
+	if q.listOrdersStmt != nil {
+		if cerr := q.listOrdersStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing listOrdersStmt: %w", cerr)
+		}
+	}
+	if q.listOrdersAfterStmt != nil {
+		if cerr := q.listOrdersAfterStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing listOrdersAfterStmt: %w", cerr)
+		}
+	}
 	return err
 }
 
@@ -93,8 +117,11 @@ type Queries struct {
 	db                  DBTX
 	tx                  *sql.Tx
 	getAllOrdersStmt    *sql.Stmt
+	getApiClientStmt    *sql.Stmt
 	getOrderByIDStmt    *sql.Stmt
 	getOrderByIdNewStmt *sql.Stmt
+	listOrdersStmt      *sql.Stmt
+	listOrdersAfterStmt *sql.Stmt
 }
 
 func (q *Queries) WithTx(tx *sql.Tx) *Queries {
@@ -102,7 +129,10 @@ func (q *Queries) WithTx(tx *sql.Tx) *Queries {
 		db:                  tx,
 		tx:                  tx,
 		getAllOrdersStmt:    q.getAllOrdersStmt,
+		getApiClientStmt:    q.getApiClientStmt,
 		getOrderByIDStmt:    q.getOrderByIDStmt,
 		getOrderByIdNewStmt: q.getOrderByIdNewStmt,
+		listOrdersStmt:      q.listOrdersStmt,
+		listOrdersAfterStmt: q.listOrdersAfterStmt,
 	}
 }
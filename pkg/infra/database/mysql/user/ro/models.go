@@ -6,12 +6,35 @@ package orderRoDataStore
 
 import (
 	"database/sql"
+	"time"
 )
 
+type ApiClient struct {
+	ClientID    string       `json:"client_id"`
+	Name        string       `json:"name"`
+	TokenSha256 string       `json:"token_sha256"`
+	Disabled    bool         `json:"disabled"`
+	CreatedAt   sql.NullTime `json:"created_at"`
+	UpdatedAt   sql.NullTime `json:"updated_at"`
+}
+
+type IdempotencyKey struct {
+	Scope           string         `json:"scope"`
+	IdempotencyKey  string         `json:"idempotency_key"`
+	RequestSha256   string         `json:"request_sha256"`
+	StatusCode      int32          `json:"status_code"`
+	LockedUntil     time.Time      `json:"locked_until"`
+	ResponseHeaders sql.NullString `json:"response_headers"`
+	ResponseBody    []byte         `json:"response_body"`
+	ExpiresAt       time.Time      `json:"expires_at"`
+	CreatedAt       sql.NullTime   `json:"created_at"`
+	UpdatedAt       sql.NullTime   `json:"updated_at"`
+}
+
 type Order struct {
 	OrderID   string       `json:"order_id"`
 	OrderQty  int32        `json:"order_qty"`
 	Amount    string       `json:"amount"`
-	CreatedAt sql.NullTime `json:"created_at"`
+	CreatedAt time.Time    `json:"created_at"`
 	UpdatedAt sql.NullTime `json:"updated_at"`
 }
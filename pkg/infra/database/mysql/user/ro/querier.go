@@ -15,6 +15,12 @@ type Querier interface {
 	//  FROM orders
 	//  ORDER BY created_at DESC
 	GetAllOrders(ctx context.Context) ([]*Order, error)
+	//GetApiClient
+	//
+	//  SELECT client_id, name, token_sha256, disabled, created_at, updated_at
+	//  FROM api_clients
+	//  WHERE client_id = ?
+	GetApiClient(ctx context.Context, clientID string) (*ApiClient, error)
 	//GetOrderByID
 	//
 	//  SELECT order_id, order_qty, amount, created_at, updated_at
@@ -27,6 +33,21 @@ type Querier interface {
 	//  FROM orders
 	//  WHERE order_id = ?
 	GetOrderByIdNew(ctx context.Context, orderID string) (*GetOrderByIdNewRow, error)
+	//ListOrders
+	//
+	//  SELECT order_id, order_qty, amount, created_at, updated_at
+	//  FROM orders
+	//  ORDER BY created_at DESC, order_id DESC
+	//  LIMIT ?
+	ListOrders(ctx context.Context, pageSize int32) ([]*Order, error)
+	//ListOrdersAfter
+	//
+	//  SELECT order_id, order_qty, amount, created_at, updated_at
+	//  FROM orders
+	//  WHERE created_at < ? OR (created_at = ? AND order_id < ?)
+	//  ORDER BY created_at DESC, order_id DESC
+	//  LIMIT ?
+	ListOrdersAfter(ctx context.Context, arg ListOrdersAfterParams) ([]*Order, error)
 }
 
 var _ Querier = (*Queries)(nil)
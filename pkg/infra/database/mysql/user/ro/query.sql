@@ -14,4 +14,22 @@ WHERE order_id = ?;
 -- name: GetAllOrders :many
 SELECT order_id, order_qty, amount, created_at, updated_at
 FROM orders
-ORDER BY created_at DESC;
\ No newline at end of file
+ORDER BY created_at DESC;
+
+-- name: GetApiClient :one
+SELECT client_id, name, token_sha256, disabled, created_at, updated_at
+FROM api_clients
+WHERE client_id = ?;
+
+-- name: ListOrders :many
+SELECT order_id, order_qty, amount, created_at, updated_at
+FROM orders
+ORDER BY created_at DESC, order_id DESC
+LIMIT sqlc.arg(page_size);
+
+-- name: ListOrdersAfter :many
+SELECT order_id, order_qty, amount, created_at, updated_at
+FROM orders
+WHERE created_at < sqlc.arg(cursor_created_at) OR (created_at = sqlc.arg(cursor_created_at) AND order_id < sqlc.arg(cursor_order_id))
+ORDER BY created_at DESC, order_id DESC
+LIMIT sqlc.arg(page_size);
\ No newline at end of file
@@ -7,6 +7,7 @@ package orderRoDataStore
 
 import (
 	"context"
+	"time"
 )
 
 const getAllOrders = `-- name: GetAllOrders :many
@@ -49,6 +50,31 @@ func (q *Queries) GetAllOrders(ctx context.Context) ([]*Order, error) {
 	return items, nil
 }
 
+const getApiClient = `-- name: GetApiClient :one
+SELECT client_id, name, token_sha256, disabled, created_at, updated_at
+FROM api_clients
+WHERE client_id = ?
+`
+
+// GetApiClient
+//
+//	SELECT client_id, name, token_sha256, disabled, created_at, updated_at
+//	FROM api_clients
+//	WHERE client_id = ?
+func (q *Queries) GetApiClient(ctx context.Context, clientID string) (*ApiClient, error) {
+	row := q.queryRow(ctx, q.getApiClientStmt, getApiClient, clientID)
+	var i ApiClient
+	err := row.Scan(
+		&i.ClientID,
+		&i.Name,
+		&i.TokenSha256,
+		&i.Disabled,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+	)
+	return &i, err
+}
+
 const getOrderByID = `-- name: GetOrderByID :one
 SELECT order_id, order_qty, amount, created_at, updated_at
 FROM orders
@@ -95,3 +121,100 @@ func (q *Queries) GetOrderByIdNew(ctx context.Context, orderID string) (*GetOrde
 	err := row.Scan(&i.OrderID, &i.OrderQty)
 	return &i, err
 }
+
+const listOrders = `-- name: ListOrders :many
+SELECT order_id, order_qty, amount, created_at, updated_at
+FROM orders
+ORDER BY created_at DESC, order_id DESC
+LIMIT ?
+`
+
+// ListOrders
+//
+//	SELECT order_id, order_qty, amount, created_at, updated_at
+//	FROM orders
+//	ORDER BY created_at DESC, order_id DESC
+//	LIMIT ?
+func (q *Queries) ListOrders(ctx context.Context, pageSize int32) ([]*Order, error) {
+	rows, err := q.query(ctx, q.listOrdersStmt, listOrders, pageSize)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	items := []*Order{}
+	for rows.Next() {
+		var i Order
+		if err := rows.Scan(
+			&i.OrderID,
+			&i.OrderQty,
+			&i.Amount,
+			&i.CreatedAt,
+			&i.UpdatedAt,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, &i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listOrdersAfter = `-- name: ListOrdersAfter :many
+SELECT order_id, order_qty, amount, created_at, updated_at
+FROM orders
+WHERE created_at < ? OR (created_at = ? AND order_id < ?)
+ORDER BY created_at DESC, order_id DESC
+LIMIT ?
+`
+
+type ListOrdersAfterParams struct {
+	CursorCreatedAt time.Time `json:"cursor_created_at"`
+	CursorOrderID   string    `json:"cursor_order_id"`
+	PageSize        int32     `json:"page_size"`
+}
+
+// ListOrdersAfter
+//
+//	SELECT order_id, order_qty, amount, created_at, updated_at
+//	FROM orders
+//	WHERE created_at < ? OR (created_at = ? AND order_id < ?)
+//	ORDER BY created_at DESC, order_id DESC
+//	LIMIT ?
+func (q *Queries) ListOrdersAfter(ctx context.Context, arg ListOrdersAfterParams) ([]*Order, error) {
+	rows, err := q.query(ctx, q.listOrdersAfterStmt, listOrdersAfter,
+		arg.CursorCreatedAt,
+		arg.CursorCreatedAt,
+		arg.CursorOrderID,
+		arg.PageSize,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	items := []*Order{}
+	for rows.Next() {
+		var i Order
+		if err := rows.Scan(
+			&i.OrderID,
+			&i.OrderQty,
+			&i.Amount,
+			&i.CreatedAt,
+			&i.UpdatedAt,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, &i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
@@ -24,35 +24,99 @@ func New(db DBTX) *Queries {
 func Prepare(ctx context.Context, db DBTX) (*Queries, error) {
 	q := Queries{db: db}
 	var err error
+	if q.completeIdempotencyKeyStmt, err = db.PrepareContext(ctx, completeIdempotencyKey); err != nil {
+		return nil, fmt.Errorf("error preparing query CompleteIdempotencyKey: %w", err)
+	}
+	if q.createIdempotencyKeyStmt, err = db.PrepareContext(ctx, createIdempotencyKey); err != nil {
+		return nil, fmt.Errorf("error preparing query CreateIdempotencyKey: %w", err)
+	}
 	if q.createOrderStmt, err = db.PrepareContext(ctx, createOrder); err != nil {
 		return nil, fmt.Errorf("error preparing query CreateOrder: %w", err)
 	}
+	if q.deleteExpiredIdempotencyKeysStmt, err = db.PrepareContext(ctx, deleteExpiredIdempotencyKeys); err != nil {
+		return nil, fmt.Errorf("error preparing query DeleteExpiredIdempotencyKeys: %w", err)
+	}
+	if q.deleteIdempotencyKeyStmt, err = db.PrepareContext(ctx, deleteIdempotencyKey); err != nil {
+		return nil, fmt.Errorf("error preparing query DeleteIdempotencyKey: %w", err)
+	}
+	if q.deleteStaleIdempotencyKeyStmt, err = db.PrepareContext(ctx, deleteStaleIdempotencyKey); err != nil {
+		return nil, fmt.Errorf("error preparing query DeleteStaleIdempotencyKey: %w", err)
+	}
 	if q.getAllOrdersStmt, err = db.PrepareContext(ctx, getAllOrders); err != nil {
 		return nil, fmt.Errorf("error preparing query GetAllOrders: %w", err)
 	}
+	if q.getIdempotencyKeyStmt, err = db.PrepareContext(ctx, getIdempotencyKey); err != nil {
+		return nil, fmt.Errorf("error preparing query GetIdempotencyKey: %w", err)
+	}
 	if q.getOrderByIDStmt, err = db.PrepareContext(ctx, getOrderByID); err != nil {
 		return nil, fmt.Errorf("error preparing query GetOrderByID: %w", err)
 	}
+	if q.listOrdersStmt, err = db.PrepareContext(ctx, listOrders); err != nil {
+		return nil, fmt.Errorf("error preparing query ListOrders: %w", err)
+	}
+	if q.listOrdersAfterStmt, err = db.PrepareContext(ctx, listOrdersAfter); err != nil {
+		return nil, fmt.Errorf("error preparing query ListOrdersAfter: %w", err)
+	}
 	return &q, nil
 }
 
 func (q *Queries) Close() error {
 	var err error
+	if q.completeIdempotencyKeyStmt != nil {
+		if cerr := q.completeIdempotencyKeyStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing completeIdempotencyKeyStmt: %w", cerr)
+		}
+	}
+	if q.createIdempotencyKeyStmt != nil {
+		if cerr := q.createIdempotencyKeyStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing createIdempotencyKeyStmt: %w", cerr)
+		}
+	}
 	if q.createOrderStmt != nil {
 		if cerr := q.createOrderStmt.Close(); cerr != nil {
 			err = fmt.Errorf("error closing createOrderStmt: %w", cerr)
 		}
 	}
+	if q.deleteExpiredIdempotencyKeysStmt != nil {
+		if cerr := q.deleteExpiredIdempotencyKeysStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing deleteExpiredIdempotencyKeysStmt: %w", cerr)
+		}
+	}
+	if q.deleteIdempotencyKeyStmt != nil {
+		if cerr := q.deleteIdempotencyKeyStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing deleteIdempotencyKeyStmt: %w", cerr)
+		}
+	}
+	if q.deleteStaleIdempotencyKeyStmt != nil {
+		if cerr := q.deleteStaleIdempotencyKeyStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing deleteStaleIdempotencyKeyStmt: %w", cerr)
+		}
+	}
 	if q.getAllOrdersStmt != nil {
 		if cerr := q.getAllOrdersStmt.Close(); cerr != nil {
 			err = fmt.Errorf("error closing getAllOrdersStmt: %w", cerr)
 		}
 	}
+	if q.getIdempotencyKeyStmt != nil {
+		if cerr := q.getIdempotencyKeyStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing getIdempotencyKeyStmt: %w", cerr)
+		}
+	}
 	if q.getOrderByIDStmt != nil {
 		if cerr := q.getOrderByIDStmt.Close(); cerr != nil {
 			err = fmt.Errorf("error closing getOrderByIDStmt: %w", cerr)
 		}
 	}
+	if q.listOrdersStmt != nil {
+		if cerr := q.listOrdersStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing listOrdersStmt: %w", cerr)
+		}
+	}
+	if q.listOrdersAfterStmt != nil {
+		if cerr := q.listOrdersAfterStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing listOrdersAfterStmt: %w", cerr)
+		}
+	}
 	return err
 }
 
@@ -90,19 +154,35 @@ func (q *Queries) queryRow(ctx context.Context, stmt *sql.Stmt, query string, ar
 }
 
 type Queries struct {
-	db               DBTX
-	tx               *sql.Tx
-	createOrderStmt  *sql.Stmt
-	getAllOrdersStmt *sql.Stmt
-	getOrderByIDStmt *sql.Stmt
+	db                               DBTX
+	tx                               *sql.Tx
+	completeIdempotencyKeyStmt       *sql.Stmt
+	createIdempotencyKeyStmt         *sql.Stmt
+	createOrderStmt                  *sql.Stmt
+	deleteExpiredIdempotencyKeysStmt *sql.Stmt
+	deleteIdempotencyKeyStmt         *sql.Stmt
+	deleteStaleIdempotencyKeyStmt    *sql.Stmt
+	getAllOrdersStmt                 *sql.Stmt
+	getIdempotencyKeyStmt            *sql.Stmt
+	getOrderByIDStmt                 *sql.Stmt
+	listOrdersStmt                   *sql.Stmt
+	listOrdersAfterStmt              *sql.Stmt
 }
 
 func (q *Queries) WithTx(tx *sql.Tx) *Queries {
 	return &Queries{
-		db:               tx,
-		tx:               tx,
-		createOrderStmt:  q.createOrderStmt,
-		getAllOrdersStmt: q.getAllOrdersStmt,
-		getOrderByIDStmt: q.getOrderByIDStmt,
+		db:                               tx,
+		tx:                               tx,
+		completeIdempotencyKeyStmt:       q.completeIdempotencyKeyStmt,
+		createIdempotencyKeyStmt:         q.createIdempotencyKeyStmt,
+		createOrderStmt:                  q.createOrderStmt,
+		deleteExpiredIdempotencyKeysStmt: q.deleteExpiredIdempotencyKeysStmt,
+		deleteIdempotencyKeyStmt:         q.deleteIdempotencyKeyStmt,
+		deleteStaleIdempotencyKeyStmt:    q.deleteStaleIdempotencyKeyStmt,
+		getAllOrdersStmt:                 q.getAllOrdersStmt,
+		getIdempotencyKeyStmt:            q.getIdempotencyKeyStmt,
+		getOrderByIDStmt:                 q.getOrderByIDStmt,
+		listOrdersStmt:                   q.listOrdersStmt,
+		listOrdersAfterStmt:              q.listOrdersAfterStmt,
 	}
 }
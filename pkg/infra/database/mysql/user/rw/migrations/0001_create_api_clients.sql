@@ -0,0 +1,11 @@
+-- Adds the api_clients table (see schema.sql) to a database which was created before it. The user package prepares
+-- its statements on startup, so this must be applied before a build which has the client registry is deployed
+
+CREATE TABLE IF NOT EXISTS api_clients (
+    client_id VARCHAR(64) PRIMARY KEY,
+    name VARCHAR(255) NOT NULL,
+    token_sha256 CHAR(64) NOT NULL,
+    disabled BOOLEAN NOT NULL DEFAULT FALSE,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP ON UPDATE CURRENT_TIMESTAMP
+);
@@ -0,0 +1,17 @@
+-- Adds the idempotency_keys table (see schema.sql) to a database which was created before it. The user package
+-- prepares its statements on startup, so this must be applied before a build which has the idempotency guard is deployed
+
+CREATE TABLE IF NOT EXISTS idempotency_keys (
+    scope VARCHAR(255) NOT NULL,
+    idempotency_key VARCHAR(128) NOT NULL,
+    request_sha256 CHAR(64) NOT NULL,
+    status_code INT NOT NULL DEFAULT 0,
+    locked_until TIMESTAMP NOT NULL,
+    response_headers TEXT,
+    response_body MEDIUMBLOB,
+    expires_at TIMESTAMP NOT NULL,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP ON UPDATE CURRENT_TIMESTAMP,
+    PRIMARY KEY (scope, idempotency_key),
+    KEY idx_idempotency_keys_expires_at (expires_at)
+);
@@ -0,0 +1,16 @@
+-- Orders are listed with a (created_at, order_id) cursor, which needs a created_at in every row. Rows without one get
+-- their updated_at (or the current time), then created_at is made NOT NULL and the index of the list queries is added
+-- if it is not there yet
+
+UPDATE orders SET created_at = COALESCE(updated_at, CURRENT_TIMESTAMP) WHERE created_at IS NULL;
+
+ALTER TABLE orders MODIFY created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP;
+
+SET @create_index = (
+    SELECT IF(COUNT(*) = 0, 'CREATE INDEX idx_orders_created_at_order_id ON orders (created_at, order_id)', 'DO 0')
+    FROM information_schema.statistics
+    WHERE table_schema = DATABASE() AND table_name = 'orders' AND index_name = 'idx_orders_created_at_order_id'
+);
+PREPARE create_index FROM @create_index;
+EXECUTE create_index;
+DEALLOCATE PREPARE create_index;
@@ -0,0 +1,12 @@
+-- Adds lock_id (see schema.sql) to an idempotency_keys table which was created without it. The request which takes a
+-- key writes its lock_id, and completes or releases the key only if it still has that lock_id - a key which was taken
+-- over after its locked_until is not changed by the request which had it before
+
+SET @add_lock_id = (
+    SELECT IF(COUNT(*) = 0, 'ALTER TABLE idempotency_keys ADD COLUMN lock_id VARCHAR(36) NOT NULL DEFAULT '''' AFTER request_sha256', 'DO 0')
+    FROM information_schema.columns
+    WHERE table_schema = DATABASE() AND table_name = 'idempotency_keys' AND column_name = 'lock_id'
+);
+PREPARE add_lock_id FROM @add_lock_id;
+EXECUTE add_lock_id;
+DEALLOCATE PREPARE add_lock_id;
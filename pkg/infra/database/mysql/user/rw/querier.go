@@ -9,23 +9,71 @@ import (
 )
 
 type Querier interface {
+	//CompleteIdempotencyKey
+	//
+	//  UPDATE idempotency_keys
+	//  SET status_code = ?, response_headers = ?, response_body = ?
+	//  WHERE scope = ? AND idempotency_key = ? AND lock_id = ?
+	CompleteIdempotencyKey(ctx context.Context, arg CompleteIdempotencyKeyParams) (int64, error)
+	//CreateIdempotencyKey
+	//
+	//  INSERT INTO idempotency_keys (scope, idempotency_key, request_sha256, lock_id, locked_until, expires_at)
+	//  VALUES (?, ?, ?, ?, ?, ?)
+	CreateIdempotencyKey(ctx context.Context, arg CreateIdempotencyKeyParams) error
 	//CreateOrder
 	//
 	//  INSERT INTO orders (order_id, order_qty, amount)
 	//  VALUES (?, ?, ?)
 	CreateOrder(ctx context.Context, arg CreateOrderParams) error
+	//DeleteExpiredIdempotencyKeys
+	//
+	//  DELETE FROM idempotency_keys
+	//  WHERE expires_at < ?
+	//  LIMIT ?
+	DeleteExpiredIdempotencyKeys(ctx context.Context, arg DeleteExpiredIdempotencyKeysParams) (int64, error)
+	//DeleteIdempotencyKey
+	//
+	//  DELETE FROM idempotency_keys
+	//  WHERE scope = ? AND idempotency_key = ? AND lock_id = ?
+	DeleteIdempotencyKey(ctx context.Context, arg DeleteIdempotencyKeyParams) (int64, error)
+	//DeleteStaleIdempotencyKey
+	//
+	//  DELETE FROM idempotency_keys
+	//  WHERE scope = ? AND idempotency_key = ? AND (expires_at < ? OR (status_code = 0 AND locked_until < ?))
+	DeleteStaleIdempotencyKey(ctx context.Context, arg DeleteStaleIdempotencyKeyParams) (int64, error)
 	//GetAllOrders
 	//
 	//  SELECT order_id, order_qty, amount, created_at, updated_at
 	//  FROM orders
 	//  ORDER BY created_at DESC
 	GetAllOrders(ctx context.Context) ([]*Order, error)
+	//GetIdempotencyKey
+	//
+	//  SELECT scope, idempotency_key, request_sha256, lock_id, status_code, locked_until, response_headers, response_body, expires_at, created_at, updated_at
+	//  FROM idempotency_keys
+	//  WHERE scope = ? AND idempotency_key = ?
+	GetIdempotencyKey(ctx context.Context, arg GetIdempotencyKeyParams) (*IdempotencyKey, error)
 	//GetOrderByID
 	//
 	//  SELECT order_id, order_qty, amount, created_at, updated_at
 	//  FROM orders
 	//  WHERE order_id = ?
 	GetOrderByID(ctx context.Context, orderID string) (*Order, error)
+	//ListOrders
+	//
+	//  SELECT order_id, order_qty, amount, created_at, updated_at
+	//  FROM orders
+	//  ORDER BY created_at DESC, order_id DESC
+	//  LIMIT ?
+	ListOrders(ctx context.Context, pageSize int32) ([]*Order, error)
+	//ListOrdersAfter
+	//
+	//  SELECT order_id, order_qty, amount, created_at, updated_at
+	//  FROM orders
+	//  WHERE created_at < ? OR (created_at = ? AND order_id < ?)
+	//  ORDER BY created_at DESC, order_id DESC
+	//  LIMIT ?
+	ListOrdersAfter(ctx context.Context, arg ListOrdersAfterParams) ([]*Order, error)
 }
 
 var _ Querier = (*Queries)(nil)
@@ -10,4 +10,44 @@ WHERE order_id = ?;
 -- name: GetAllOrders :many
 SELECT order_id, order_qty, amount, created_at, updated_at
 FROM orders
-ORDER BY created_at DESC;
\ No newline at end of file
+ORDER BY created_at DESC;
+
+-- name: CreateIdempotencyKey :exec
+INSERT INTO idempotency_keys (scope, idempotency_key, request_sha256, lock_id, locked_until, expires_at)
+VALUES (?, ?, ?, ?, ?, ?);
+
+-- name: GetIdempotencyKey :one
+SELECT scope, idempotency_key, request_sha256, lock_id, status_code, locked_until, response_headers, response_body, expires_at, created_at, updated_at
+FROM idempotency_keys
+WHERE scope = ? AND idempotency_key = ?;
+
+-- name: CompleteIdempotencyKey :execrows
+UPDATE idempotency_keys
+SET status_code = ?, response_headers = ?, response_body = ?
+WHERE scope = ? AND idempotency_key = ? AND lock_id = ?;
+
+-- name: DeleteIdempotencyKey :execrows
+DELETE FROM idempotency_keys
+WHERE scope = ? AND idempotency_key = ? AND lock_id = ?;
+
+-- name: DeleteStaleIdempotencyKey :execrows
+DELETE FROM idempotency_keys
+WHERE scope = ? AND idempotency_key = ? AND (expires_at < sqlc.arg(now) OR (status_code = 0 AND locked_until < sqlc.arg(now)));
+
+-- name: DeleteExpiredIdempotencyKeys :execrows
+DELETE FROM idempotency_keys
+WHERE expires_at < ?
+LIMIT ?;
+
+-- name: ListOrders :many
+SELECT order_id, order_qty, amount, created_at, updated_at
+FROM orders
+ORDER BY created_at DESC, order_id DESC
+LIMIT sqlc.arg(page_size);
+
+-- name: ListOrdersAfter :many
+SELECT order_id, order_qty, amount, created_at, updated_at
+FROM orders
+WHERE created_at < sqlc.arg(cursor_created_at) OR (created_at = sqlc.arg(cursor_created_at) AND order_id < sqlc.arg(cursor_order_id))
+ORDER BY created_at DESC, order_id DESC
+LIMIT sqlc.arg(page_size);
\ No newline at end of file
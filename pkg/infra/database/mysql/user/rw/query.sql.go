@@ -7,8 +7,75 @@ package ordersDataStore
 
 import (
 	"context"
+	"database/sql"
+	"time"
 )
 
+const completeIdempotencyKey = `-- name: CompleteIdempotencyKey :execrows
+UPDATE idempotency_keys
+SET status_code = ?, response_headers = ?, response_body = ?
+WHERE scope = ? AND idempotency_key = ? AND lock_id = ?
+`
+
+type CompleteIdempotencyKeyParams struct {
+	StatusCode      int32          `json:"status_code"`
+	ResponseHeaders sql.NullString `json:"response_headers"`
+	ResponseBody    []byte         `json:"response_body"`
+	Scope           string         `json:"scope"`
+	IdempotencyKey  string         `json:"idempotency_key"`
+	LockID          string         `json:"lock_id"`
+}
+
+// CompleteIdempotencyKey
+//
+//	UPDATE idempotency_keys
+//	SET status_code = ?, response_headers = ?, response_body = ?
+//	WHERE scope = ? AND idempotency_key = ? AND lock_id = ?
+func (q *Queries) CompleteIdempotencyKey(ctx context.Context, arg CompleteIdempotencyKeyParams) (int64, error) {
+	result, err := q.exec(ctx, q.completeIdempotencyKeyStmt, completeIdempotencyKey,
+		arg.StatusCode,
+		arg.ResponseHeaders,
+		arg.ResponseBody,
+		arg.Scope,
+		arg.IdempotencyKey,
+		arg.LockID,
+	)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
+
+const createIdempotencyKey = `-- name: CreateIdempotencyKey :exec
+INSERT INTO idempotency_keys (scope, idempotency_key, request_sha256, lock_id, locked_until, expires_at)
+VALUES (?, ?, ?, ?, ?, ?)
+`
+
+type CreateIdempotencyKeyParams struct {
+	Scope          string    `json:"scope"`
+	IdempotencyKey string    `json:"idempotency_key"`
+	RequestSha256  string    `json:"request_sha256"`
+	LockID         string    `json:"lock_id"`
+	LockedUntil    time.Time `json:"locked_until"`
+	ExpiresAt      time.Time `json:"expires_at"`
+}
+
+// CreateIdempotencyKey
+//
+//	INSERT INTO idempotency_keys (scope, idempotency_key, request_sha256, lock_id, locked_until, expires_at)
+//	VALUES (?, ?, ?, ?, ?, ?)
+func (q *Queries) CreateIdempotencyKey(ctx context.Context, arg CreateIdempotencyKeyParams) error {
+	_, err := q.exec(ctx, q.createIdempotencyKeyStmt, createIdempotencyKey,
+		arg.Scope,
+		arg.IdempotencyKey,
+		arg.RequestSha256,
+		arg.LockID,
+		arg.LockedUntil,
+		arg.ExpiresAt,
+	)
+	return err
+}
+
 const createOrder = `-- name: CreateOrder :exec
 INSERT INTO orders (order_id, order_qty, amount)
 VALUES (?, ?, ?)
@@ -29,6 +96,81 @@ func (q *Queries) CreateOrder(ctx context.Context, arg CreateOrderParams) error
 	return err
 }
 
+const deleteExpiredIdempotencyKeys = `-- name: DeleteExpiredIdempotencyKeys :execrows
+DELETE FROM idempotency_keys
+WHERE expires_at < ?
+LIMIT ?
+`
+
+type DeleteExpiredIdempotencyKeysParams struct {
+	ExpiresAt time.Time `json:"expires_at"`
+	Limit     int32     `json:"limit"`
+}
+
+// DeleteExpiredIdempotencyKeys
+//
+//	DELETE FROM idempotency_keys
+//	WHERE expires_at < ?
+//	LIMIT ?
+func (q *Queries) DeleteExpiredIdempotencyKeys(ctx context.Context, arg DeleteExpiredIdempotencyKeysParams) (int64, error) {
+	result, err := q.exec(ctx, q.deleteExpiredIdempotencyKeysStmt, deleteExpiredIdempotencyKeys, arg.ExpiresAt, arg.Limit)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
+
+const deleteIdempotencyKey = `-- name: DeleteIdempotencyKey :execrows
+DELETE FROM idempotency_keys
+WHERE scope = ? AND idempotency_key = ? AND lock_id = ?
+`
+
+type DeleteIdempotencyKeyParams struct {
+	Scope          string `json:"scope"`
+	IdempotencyKey string `json:"idempotency_key"`
+	LockID         string `json:"lock_id"`
+}
+
+// DeleteIdempotencyKey
+//
+//	DELETE FROM idempotency_keys
+//	WHERE scope = ? AND idempotency_key = ? AND lock_id = ?
+func (q *Queries) DeleteIdempotencyKey(ctx context.Context, arg DeleteIdempotencyKeyParams) (int64, error) {
+	result, err := q.exec(ctx, q.deleteIdempotencyKeyStmt, deleteIdempotencyKey, arg.Scope, arg.IdempotencyKey, arg.LockID)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
+
+const deleteStaleIdempotencyKey = `-- name: DeleteStaleIdempotencyKey :execrows
+DELETE FROM idempotency_keys
+WHERE scope = ? AND idempotency_key = ? AND (expires_at < ? OR (status_code = 0 AND locked_until < ?))
+`
+
+type DeleteStaleIdempotencyKeyParams struct {
+	Scope          string    `json:"scope"`
+	IdempotencyKey string    `json:"idempotency_key"`
+	Now            time.Time `json:"now"`
+}
+
+// DeleteStaleIdempotencyKey
+//
+//	DELETE FROM idempotency_keys
+//	WHERE scope = ? AND idempotency_key = ? AND (expires_at < ? OR (status_code = 0 AND locked_until < ?))
+func (q *Queries) DeleteStaleIdempotencyKey(ctx context.Context, arg DeleteStaleIdempotencyKeyParams) (int64, error) {
+	result, err := q.exec(ctx, q.deleteStaleIdempotencyKeyStmt, deleteStaleIdempotencyKey,
+		arg.Scope,
+		arg.IdempotencyKey,
+		arg.Now,
+		arg.Now,
+	)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
+
 const getAllOrders = `-- name: GetAllOrders :many
 SELECT order_id, order_qty, amount, created_at, updated_at
 FROM orders
@@ -69,6 +211,41 @@ func (q *Queries) GetAllOrders(ctx context.Context) ([]*Order, error) {
 	return items, nil
 }
 
+const getIdempotencyKey = `-- name: GetIdempotencyKey :one
+SELECT scope, idempotency_key, request_sha256, lock_id, status_code, locked_until, response_headers, response_body, expires_at, created_at, updated_at
+FROM idempotency_keys
+WHERE scope = ? AND idempotency_key = ?
+`
+
+type GetIdempotencyKeyParams struct {
+	Scope          string `json:"scope"`
+	IdempotencyKey string `json:"idempotency_key"`
+}
+
+// GetIdempotencyKey
+//
+//	SELECT scope, idempotency_key, request_sha256, lock_id, status_code, locked_until, response_headers, response_body, expires_at, created_at, updated_at
+//	FROM idempotency_keys
+//	WHERE scope = ? AND idempotency_key = ?
+func (q *Queries) GetIdempotencyKey(ctx context.Context, arg GetIdempotencyKeyParams) (*IdempotencyKey, error) {
+	row := q.queryRow(ctx, q.getIdempotencyKeyStmt, getIdempotencyKey, arg.Scope, arg.IdempotencyKey)
+	var i IdempotencyKey
+	err := row.Scan(
+		&i.Scope,
+		&i.IdempotencyKey,
+		&i.RequestSha256,
+		&i.LockID,
+		&i.StatusCode,
+		&i.LockedUntil,
+		&i.ResponseHeaders,
+		&i.ResponseBody,
+		&i.ExpiresAt,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+	)
+	return &i, err
+}
+
 const getOrderByID = `-- name: GetOrderByID :one
 SELECT order_id, order_qty, amount, created_at, updated_at
 FROM orders
@@ -92,3 +269,100 @@ func (q *Queries) GetOrderByID(ctx context.Context, orderID string) (*Order, err
 	)
 	return &i, err
 }
+
+const listOrders = `-- name: ListOrders :many
+SELECT order_id, order_qty, amount, created_at, updated_at
+FROM orders
+ORDER BY created_at DESC, order_id DESC
+LIMIT ?
+`
+
+// ListOrders
+//
+//	SELECT order_id, order_qty, amount, created_at, updated_at
+//	FROM orders
+//	ORDER BY created_at DESC, order_id DESC
+//	LIMIT ?
+func (q *Queries) ListOrders(ctx context.Context, pageSize int32) ([]*Order, error) {
+	rows, err := q.query(ctx, q.listOrdersStmt, listOrders, pageSize)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	items := []*Order{}
+	for rows.Next() {
+		var i Order
+		if err := rows.Scan(
+			&i.OrderID,
+			&i.OrderQty,
+			&i.Amount,
+			&i.CreatedAt,
+			&i.UpdatedAt,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, &i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listOrdersAfter = `-- name: ListOrdersAfter :many
+SELECT order_id, order_qty, amount, created_at, updated_at
+FROM orders
+WHERE created_at < ? OR (created_at = ? AND order_id < ?)
+ORDER BY created_at DESC, order_id DESC
+LIMIT ?
+`
+
+type ListOrdersAfterParams struct {
+	CursorCreatedAt time.Time `json:"cursor_created_at"`
+	CursorOrderID   string    `json:"cursor_order_id"`
+	PageSize        int32     `json:"page_size"`
+}
+
+// ListOrdersAfter
+//
+//	SELECT order_id, order_qty, amount, created_at, updated_at
+//	FROM orders
+//	WHERE created_at < ? OR (created_at = ? AND order_id < ?)
+//	ORDER BY created_at DESC, order_id DESC
+//	LIMIT ?
+func (q *Queries) ListOrdersAfter(ctx context.Context, arg ListOrdersAfterParams) ([]*Order, error) {
+	rows, err := q.query(ctx, q.listOrdersAfterStmt, listOrdersAfter,
+		arg.CursorCreatedAt,
+		arg.CursorCreatedAt,
+		arg.CursorOrderID,
+		arg.PageSize,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	items := []*Order{}
+	for rows.Next() {
+		var i Order
+		if err := rows.Scan(
+			&i.OrderID,
+			&i.OrderQty,
+			&i.Amount,
+			&i.CreatedAt,
+			&i.UpdatedAt,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, &i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
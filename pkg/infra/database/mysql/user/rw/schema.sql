@@ -5,6 +5,43 @@ CREATE TABLE orders (
     order_id VARCHAR(36) PRIMARY KEY,
     order_qty INT NOT NULL,
     amount DECIMAL(10,2) NOT NULL,
+    created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP ON UPDATE CURRENT_TIMESTAMP
+);
+
+-- Orders are listed newest first with a (created_at, order_id) cursor, see ListOrdersAfter
+CREATE INDEX idx_orders_created_at_order_id ON orders (created_at, order_id);
+
+-- Table: api_clients
+-- Clients which may call the protected APIs. The token is stored as a sha256 hex digest, never in plain text
+
+CREATE TABLE api_clients (
+    client_id VARCHAR(64) PRIMARY KEY,
+    name VARCHAR(255) NOT NULL,
+    token_sha256 CHAR(64) NOT NULL,
+    disabled BOOLEAN NOT NULL DEFAULT FALSE,
     created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
     updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP ON UPDATE CURRENT_TIMESTAMP
+);
+
+-- Table: idempotency_keys
+-- Requests sent with an Idempotency-Key header, and their response. status_code is 0 while the first request is in
+-- progress - if it is still 0 after locked_until (e.g. the process died) the next request with the key takes it over.
+-- lock_id is written by the request which took the key, only that request may complete or release it.
+-- A record is not used after expires_at, and is deleted by the idempotency cleanup
+
+CREATE TABLE idempotency_keys (
+    scope VARCHAR(255) NOT NULL,
+    idempotency_key VARCHAR(128) NOT NULL,
+    request_sha256 CHAR(64) NOT NULL,
+    lock_id VARCHAR(36) NOT NULL DEFAULT '',
+    status_code INT NOT NULL DEFAULT 0,
+    locked_until TIMESTAMP NOT NULL,
+    response_headers TEXT,
+    response_body MEDIUMBLOB,
+    expires_at TIMESTAMP NOT NULL,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP ON UPDATE CURRENT_TIMESTAMP,
+    PRIMARY KEY (scope, idempotency_key),
+    KEY idx_idempotency_keys_expires_at (expires_at)
 );
\ No newline at end of file
@@ -0,0 +1,146 @@
+package health
+
+import (
+	"context"
+	"fmt"
+	"github.com/devlibx/go-template-project/pkg/infra/database"
+	consumers "github.com/devlibx/go-template-project/pkg/infra/messaging"
+	"github.com/devlibx/gox-base/v2/errors"
+	goxHttp "github.com/devlibx/gox-http/v4/command"
+	goxMessaging "github.com/devlibx/gox-messaging/v2"
+	goxCadence "github.com/devlibx/gox-workfkow/workflow/framework/cadence"
+	"go.uber.org/fx"
+	"net"
+	"sort"
+	"strings"
+)
+
+// Provider gives the Registry and the checkers of all infra dependencies used by this service. Other modules can add
+// their own checkers with ProvideCheckers
+var Provider = fx.Options(
+	fx.Provide(NewRegistry),
+	ProvideCheckers(
+		NewDatabaseCheckers,
+		NewMessagingCheckers,
+		NewCadenceCheckers,
+		NewHttpServerCheckers,
+	),
+)
+
+// NewDatabaseCheckers pings both the RW and RO database pools
+func NewDatabaseCheckers(dbConnections *database.DbConnections) []Checker {
+	return []Checker{
+		NewChecker("mysql.orders", dbConnections.OrdersSqlDbConnection.PingContext),
+		NewChecker("mysql.orders_ro", dbConnections.OrderRoSqlDbConnection.PingContext),
+	}
+}
+
+// NewMessagingCheckers checks that every enabled producer is running in the messaging factory, and that the kafka
+// brokers used by it can be reached
+func NewMessagingCheckers(configuration *goxMessaging.Configuration, factory consumers.MessagingFactory) []Checker {
+	if configuration == nil || !configuration.Enabled {
+		return nil
+	}
+
+	var checkers []Checker
+	for _, name := range sortedKeys(configuration.Producers) {
+		name, config := name, configuration.Producers[name]
+		if !config.Enabled {
+			continue
+		}
+		checkers = append(checkers, NewChecker("messaging.producer."+name, func(ctx context.Context) error {
+			if _, err := factory.GetProducer(name); err != nil {
+				return errors.Wrap(err, "producer is not running: name=%s", name)
+			}
+			if config.Type != "kafka" {
+				return nil
+			}
+			return dialAny(ctx, strings.Split(config.Endpoint, ","))
+		}))
+	}
+	return checkers
+}
+
+// NewCadenceCheckers checks that the cadence server of every enabled worker group can be reached
+func NewCadenceCheckers(config *goxCadence.Config) []Checker {
+	if config == nil || config.Disabled {
+		return nil
+	}
+
+	var checkers []Checker
+	for _, name := range sortedKeys(config.WorkerGroups) {
+		group := config.WorkerGroups[name]
+		if group.Disabled {
+			continue
+		}
+		hostPort := group.HostPort
+		checkers = append(checkers, NewChecker("cadence."+name, func(ctx context.Context) error {
+			return dial(ctx, hostPort)
+		}))
+	}
+	return checkers
+}
+
+// NewHttpServerCheckers checks that the gox-http servers listed in health.http_servers can be reached. Other upstream
+// servers are not checked
+func NewHttpServerCheckers(config *goxHttp.Config, healthConfig *Config) ([]Checker, error) {
+	if len(healthConfig.HttpServers) == 0 {
+		return nil, nil
+	}
+
+	var checkers []Checker
+	for _, name := range healthConfig.HttpServers {
+		var server *goxHttp.Server
+		if config != nil {
+			server = config.Servers[name]
+		}
+		if server == nil {
+			return nil, errors.New("health check server is not in server_config.servers: name=%s", name)
+		}
+		address := serverAddress(server)
+		checkers = append(checkers, NewChecker("http."+name, func(ctx context.Context) error {
+			return dial(ctx, address)
+		}))
+	}
+	return checkers, nil
+}
+
+// serverAddress gives host:port of a gox-http server - port -1 means the default port of http or https
+func serverAddress(server *goxHttp.Server) string {
+	port := server.Port
+	if port <= 0 {
+		port = 80
+		if server.Https {
+			port = 443
+		}
+	}
+	return net.JoinHostPort(server.Host, fmt.Sprintf("%d", port))
+}
+
+func dial(ctx context.Context, address string) error {
+	conn, err := (&net.Dialer{}).DialContext(ctx, "tcp", address)
+	if err != nil {
+		return errors.Wrap(err, "failed to connect: address=%s", address)
+	}
+	return conn.Close()
+}
+
+// dialAny is ok if any one of the addresses can be reached e.g. one of the kafka brokers
+func dialAny(ctx context.Context, addresses []string) error {
+	var err error
+	for _, address := range addresses {
+		if err = dial(ctx, strings.TrimSpace(address)); err == nil {
+			return nil
+		}
+	}
+	return err
+}
+
+func sortedKeys[V any](in map[string]V) []string {
+	keys := make([]string, 0, len(in))
+	for key := range in {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
@@ -0,0 +1,196 @@
+package health
+
+import (
+	"context"
+	"github.com/devlibx/gox-base/v2/errors"
+	"go.uber.org/fx"
+	"sort"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// CheckerGroup is the fx value group of all readiness checkers
+const CheckerGroup = "health_checkers"
+
+const (
+	StatusUp   = "up"
+	StatusDown = "down"
+)
+
+// Checker is a single readiness check e.g. a ping to a database. Check returns nil when the dependency is usable
+type Checker interface {
+	Name() string
+	Check(ctx context.Context) error
+}
+
+// NewChecker gives a Checker which calls the given func
+func NewChecker(name string, check func(ctx context.Context) error) Checker {
+	return &funcChecker{name: name, check: check}
+}
+
+type funcChecker struct {
+	name  string
+	check func(ctx context.Context) error
+}
+
+func (f *funcChecker) Name() string {
+	return f.name
+}
+
+func (f *funcChecker) Check(ctx context.Context) error {
+	return f.check(ctx)
+}
+
+// ProvideCheckers registers constructors which return []Checker (and optionally an error) into the readiness checker
+// group e.g.
+//
+//	health.ProvideCheckers(func(db *sql.DB) []health.Checker { ... })
+func ProvideCheckers(constructors ...interface{}) fx.Option {
+	options := make([]fx.Option, 0, len(constructors))
+	for _, constructor := range constructors {
+		options = append(options, fx.Provide(fx.Annotate(constructor, fx.ResultTags(`group:"`+CheckerGroup+`,flatten"`))))
+	}
+	return fx.Options(options...)
+}
+
+// Config is the configuration of readiness checks
+type Config struct {
+	CheckTimeoutMs int `yaml:"check_timeout_ms"`
+
+	// HttpServers are the gox-http servers (server_config.servers) which must be reachable for the service to be
+	// ready. None by default, so an upstream which is down does not take this service out of the load balancer
+	HttpServers []string `yaml:"http_servers"`
+}
+
+func (c *Config) SetupDefaults() {
+	if c.CheckTimeoutMs <= 0 {
+		c.CheckTimeoutMs = 1000
+	}
+}
+
+// CheckResult is the result of one checker. LastError is the last failure seen, and is kept after the check
+// recovers to help debug flaky dependencies
+type CheckResult struct {
+	Name        string     `json:"name"`
+	Status      string     `json:"status"`
+	LatencyMs   int64      `json:"latency_ms"`
+	Error       string     `json:"error,omitempty"`
+	LastError   string     `json:"last_error,omitempty"`
+	LastErrorAt *time.Time `json:"last_error_at,omitempty"`
+}
+
+// Report is the result of all checkers. Status is up only if the service is marked ready and all checks are up
+type Report struct {
+	Status string         `json:"status"`
+	Ready  bool           `json:"ready"`
+	Checks []*CheckResult `json:"checks"`
+}
+
+type lastError struct {
+	message string
+	at      time.Time
+}
+
+// Registry runs all registered checkers. A service is not ready until MarkReady(true) is called e.g. once the
+// server is started, and it can be marked not ready again e.g. on shutdown
+type Registry struct {
+	checkers []Checker
+	timeout  time.Duration
+	ready    atomic.Bool
+
+	lock       sync.Mutex
+	lastErrors map[string]lastError
+}
+
+type RegistryParams struct {
+	fx.In
+	Config   *Config   `optional:"true"`
+	Checkers []Checker `group:"health_checkers"`
+}
+
+func NewRegistry(params RegistryParams) *Registry {
+	config := params.Config
+	if config == nil {
+		config = &Config{}
+	}
+	config.SetupDefaults()
+
+	checkers := append([]Checker{}, params.Checkers...)
+	sort.SliceStable(checkers, func(i, j int) bool { return checkers[i].Name() < checkers[j].Name() })
+	return &Registry{
+		checkers:   checkers,
+		timeout:    time.Duration(config.CheckTimeoutMs) * time.Millisecond,
+		lastErrors: map[string]lastError{},
+	}
+}
+
+// MarkReady switches readiness on or off
+func (r *Registry) MarkReady(ready bool) {
+	r.ready.Store(ready)
+}
+
+func (r *Registry) IsReady() bool {
+	return r.ready.Load()
+}
+
+// Check runs all checkers concurrently, each one with the configured timeout
+func (r *Registry) Check(ctx context.Context) *Report {
+	results := make([]*CheckResult, len(r.checkers))
+	wg := sync.WaitGroup{}
+	for i, checker := range r.checkers {
+		wg.Add(1)
+		go func(i int, checker Checker) {
+			defer wg.Done()
+			results[i] = r.run(ctx, checker)
+		}(i, checker)
+	}
+	wg.Wait()
+
+	report := &Report{Status: StatusUp, Ready: r.IsReady(), Checks: results}
+	if !report.Ready {
+		report.Status = StatusDown
+	}
+	for _, result := range results {
+		if result.Status != StatusUp {
+			report.Status = StatusDown
+		}
+	}
+	return report
+}
+
+func (r *Registry) run(ctx context.Context, checker Checker) *CheckResult {
+	ctx, cancel := context.WithTimeout(ctx, r.timeout)
+	defer cancel()
+
+	start := time.Now()
+	err := runCheck(ctx, checker)
+	result := &CheckResult{Name: checker.Name(), Status: StatusUp, LatencyMs: time.Since(start).Milliseconds()}
+
+	r.lock.Lock()
+	defer r.lock.Unlock()
+	if err != nil {
+		result.Status = StatusDown
+		result.Error = err.Error()
+		r.lastErrors[checker.Name()] = lastError{message: err.Error(), at: start}
+	}
+	if last, ok := r.lastErrors[checker.Name()]; ok {
+		at := last.at
+		result.LastError, result.LastErrorAt = last.message, &at
+	}
+	return result
+}
+
+// runCheck stops waiting for a checker when the timeout is over, even if the checker does not honor the context
+func runCheck(ctx context.Context, checker Checker) error {
+	ch := make(chan error, 1)
+	go func() {
+		ch <- checker.Check(ctx)
+	}()
+	select {
+	case err := <-ch:
+		return err
+	case <-ctx.Done():
+		return errors.Wrap(ctx.Err(), "check did not finish in time")
+	}
+}
@@ -0,0 +1,106 @@
+package health
+
+import (
+	"context"
+	"github.com/devlibx/gox-base/v2/errors"
+	goxHttp "github.com/devlibx/gox-http/v4/command"
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/fx"
+	"net"
+	"strconv"
+	"testing"
+	"time"
+)
+
+func TestRegistry_Check(t *testing.T) {
+	var failing error
+	registry := NewRegistry(RegistryParams{
+		Config: &Config{CheckTimeoutMs: 50},
+		Checkers: []Checker{
+			NewChecker("b_flaky", func(ctx context.Context) error { return failing }),
+			NewChecker("a_ok", func(ctx context.Context) error { return nil }),
+			NewChecker("c_slow", func(ctx context.Context) error {
+				time.Sleep(time.Second)
+				return nil
+			}),
+		},
+	})
+
+	// Not ready until the server marks it
+	report := registry.Check(context.Background())
+	assert.Equal(t, StatusDown, report.Status)
+	assert.False(t, report.Ready)
+
+	registry.MarkReady(true)
+	failing = errors.New("connection refused")
+	report = registry.Check(context.Background())
+	assert.Equal(t, StatusDown, report.Status)
+	assert.True(t, report.Ready)
+	assert.Equal(t, "a_ok", report.Checks[0].Name)
+	assert.Equal(t, StatusUp, report.Checks[0].Status)
+	assert.Equal(t, StatusDown, report.Checks[1].Status)
+	assert.Equal(t, "connection refused", report.Checks[1].Error)
+	assert.Equal(t, StatusDown, report.Checks[2].Status)
+	assert.Contains(t, report.Checks[2].Error, "did not finish in time")
+	assert.Less(t, report.Checks[2].LatencyMs, int64(500))
+
+	// Last error is kept after the check recovers
+	failing = nil
+	report = registry.Check(context.Background())
+	assert.Equal(t, StatusUp, report.Checks[1].Status)
+	assert.Empty(t, report.Checks[1].Error)
+	assert.Equal(t, "connection refused", report.Checks[1].LastError)
+	assert.NotNil(t, report.Checks[1].LastErrorAt)
+}
+
+func TestProvideCheckers(t *testing.T) {
+	var registry *Registry
+	app := fx.New(
+		fx.NopLogger,
+		fx.Provide(NewRegistry),
+		ProvideCheckers(
+			func() []Checker { return []Checker{NewChecker("one", func(ctx context.Context) error { return nil })} },
+			func() []Checker { return []Checker{NewChecker("two", func(ctx context.Context) error { return nil })} },
+		),
+		fx.Populate(&registry),
+	)
+	assert.NoError(t, app.Err())
+
+	registry.MarkReady(true)
+	report := registry.Check(context.Background())
+	assert.Equal(t, StatusUp, report.Status)
+	assert.Len(t, report.Checks, 2)
+}
+
+func TestNewHttpServerCheckers(t *testing.T) {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	assert.NoError(t, err)
+	defer listener.Close()
+	port := listener.Addr().(*net.TCPAddr).Port
+
+	config := &goxHttp.Config{Servers: goxHttp.Servers{
+		"local":    {Host: "127.0.0.1", Port: port},
+		"upstream": {Host: "example.com", Port: -1, Https: true},
+	}}
+
+	// Upstream servers are not checked unless they are listed in http_servers
+	checkers, err := NewHttpServerCheckers(config, &Config{})
+	assert.NoError(t, err)
+	assert.Empty(t, checkers)
+
+	_, err = NewHttpServerCheckers(config, &Config{HttpServers: []string{"missing"}})
+	assert.Error(t, err)
+
+	checkers, err = NewHttpServerCheckers(config, &Config{HttpServers: []string{"local"}})
+	assert.NoError(t, err)
+	assert.Len(t, checkers, 1)
+	assert.Equal(t, "http.local", checkers[0].Name())
+	assert.NoError(t, checkers[0].Check(context.Background()))
+
+	_ = listener.Close()
+	assert.Error(t, checkers[0].Check(context.Background()))
+
+	assert.Equal(t, "example.com:443", serverAddress(&goxHttp.Server{Host: "example.com", Port: -1, Https: true}))
+	assert.Equal(t, "example.com:80", serverAddress(&goxHttp.Server{Host: "example.com", Port: -1}))
+	assert.Equal(t, "example.com:"+strconv.Itoa(port), serverAddress(&goxHttp.Server{Host: "example.com", Port: port}))
+}
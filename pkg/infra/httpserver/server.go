@@ -0,0 +1,231 @@
+package httpserver
+
+import (
+	"context"
+	"fmt"
+	"github.com/devlibx/gox-base/v2"
+	"github.com/devlibx/gox-base/v2/config"
+	"github.com/devlibx/gox-base/v2/errors"
+	"github.com/devlibx/gox-base/v2/server/common"
+	"github.com/gin-gonic/gin"
+	"github.com/urfave/negroni"
+	"go.uber.org/zap"
+	"gopkg.in/tylerb/graceful.v1"
+	"net"
+	"net/http"
+	"sync"
+	"time"
+)
+
+// Server is same as the gox-base server, but it binds the listener before serving. This lets the caller know the
+// address the server is bound to (e.g. with http_port=0 a free port is picked), and that it serves before the server
+// is marked started
+type Server interface {
+	common.Server
+
+	// Listen binds the listener, and gives the bound address. Start serves on this listener. It is ok to call Start
+	// without Listen
+	Listen() (net.Addr, error)
+
+	// Serving is closed by Start right before it serves on the listener
+	Serving() <-chan struct{}
+
+	// Shutdown stops accepting new connections and waits for in-flight requests till the context is done
+	Shutdown(ctx context.Context) error
+}
+
+type server struct {
+	gox.CrossFunction
+	router    *gin.Engine
+	appConfig *config.App
+	logger    *zap.Logger
+
+	lock           sync.Mutex
+	listener       net.Listener
+	gracefulServer *graceful.Server
+	servingOnce    sync.Once
+	serving        chan struct{}
+	stopOnce       sync.Once
+	stopped        chan bool
+}
+
+func NewServer(cf gox.CrossFunction, appConfig *config.App) (Server, error) {
+	if appConfig == nil {
+		return nil, errors.New("application config is nil")
+	}
+
+	// Handlers pass the gin context (or a context derived from it e.g. by opentracing) down, so values of the request
+	// context such as the request id must be found through it
+	router := gin.New()
+	router.ContextWithFallback = true
+
+	// gin trusts X-Forwarded-For from any address by default, which lets a caller pick its client IP. No proxy is
+	// trusted until the caller sets them with SetTrustedProxies
+	_ = router.SetTrustedProxies(nil)
+
+	return &server{
+		CrossFunction: cf,
+		router:        router,
+		appConfig:     appConfig,
+		logger:        cf.Logger().Named("server"),
+		serving:       make(chan struct{}),
+		stopped:       make(chan bool, 1),
+	}, nil
+}
+
+func (s *server) GetRouter() *gin.Engine {
+	return s.router
+}
+
+func (s *server) Listen() (net.Addr, error) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	if s.listener != nil {
+		return s.listener.Addr(), nil
+	}
+
+	s.appConfig.SetupDefaults()
+	listener, err := net.Listen("tcp", fmt.Sprintf("0.0.0.0:%d", s.appConfig.HttpPort))
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to listen: port=%d", s.appConfig.HttpPort)
+	}
+	s.listener = listener
+
+	s.gracefulServer = &graceful.Server{
+		Server: &http.Server{
+			Handler:      s.rootHandler(),
+			WriteTimeout: time.Duration(s.appConfig.RequestWriteTimeoutMs) * time.Millisecond,
+			ReadTimeout:  time.Duration(s.appConfig.RequestReadTimeoutMs) * time.Millisecond,
+			IdleTimeout:  time.Duration(s.appConfig.IdleTimeoutMs) * time.Millisecond,
+		},
+		Timeout: time.Duration(s.appConfig.OutstandingRequestTimeoutMs) * time.Millisecond,
+
+		// The app handles SIGINT and SIGTERM, so the shutdown stages run in order e.g. readiness is off before the
+		// listener is closed
+		NoSignalHandling: true,
+		LogFunc: func(format string, args ...interface{}) {
+			s.logger.Info(fmt.Sprintf(format, args...))
+		},
+	}
+	return listener.Addr(), nil
+}
+
+func (s *server) Serving() <-chan struct{} {
+	return s.serving
+}
+
+func (s *server) Start() error {
+	if _, err := s.Listen(); err != nil {
+		return err
+	}
+	s.servingOnce.Do(func() { close(s.serving) })
+	if err := s.gracefulServer.Serve(s.listener); err != nil && err != http.ErrServerClosed {
+		s.logger.Error("failed to start server", zap.Error(err))
+		return errors.Wrap(err, "failed to run http server")
+	}
+	return nil
+}
+
+// Stop drains the server, in-flight requests get app.outstanding_request_timeout_ms to finish
+func (s *server) Stop() chan bool {
+	s.stopOnce.Do(func() {
+		go func() {
+			timeout := time.Duration(s.appConfig.OutstandingRequestTimeoutMs) * time.Millisecond
+			ctx, cancel := context.WithTimeout(context.Background(), timeout)
+			defer cancel()
+			if err := s.Shutdown(ctx); err != nil {
+				s.logger.Error("failed to drain server", zap.Error(err))
+			}
+			s.stopped <- true
+			close(s.stopped)
+		}()
+	})
+	return s.stopped
+}
+
+func (s *server) Shutdown(ctx context.Context) error {
+	s.lock.Lock()
+	gracefulServer := s.gracefulServer
+	s.lock.Unlock()
+
+	if gracefulServer == nil {
+		return nil
+	}
+	if err := gracefulServer.Shutdown(ctx); err != nil {
+		return errors.Wrap(err, "in-flight requests did not finish")
+	}
+	return nil
+}
+
+// rootHandler gives the same handler chain as the gox-base server. pprof is not added here, it is only served by the
+// admin server
+func (s *server) rootHandler() http.Handler {
+	rootHandler := negroni.New(negroni.NewRecovery(), negroni.NewStatic(http.Dir("public")))
+	if s.appConfig.IsServerTimeLoggingEnabled() {
+		rootHandler.Use(s.timeLogging())
+	}
+	if s.appConfig.IsDefaultResponseOnPanicEnabled() {
+		rootHandler.Use(s.panicResponse())
+	}
+	rootHandler.UseHandler(s.router)
+	return rootHandler
+}
+
+func (s *server) timeLogging() negroni.HandlerFunc {
+	logger := s.Logger().Named("negroni")
+	return func(rw http.ResponseWriter, r *http.Request, next http.HandlerFunc) {
+		start := time.Now()
+		next(rw, r)
+		logger.Info("",
+			zap.String("requestUrl", r.RequestURI),
+			zap.String("remoteAddr", r.RemoteAddr),
+			zap.String("source", r.Header.Get("X-FORWARDED-FOR")),
+			zap.Int64("duration", time.Since(start).Milliseconds()),
+		)
+	}
+}
+
+func (s *server) panicResponse() negroni.HandlerFunc {
+	logger := s.Logger().Named("negroni-panic")
+	return func(rw http.ResponseWriter, r *http.Request, next http.HandlerFunc) {
+		defer func() {
+			if err := recover(); err != nil {
+				rw.Header().Set("Content-Type", "application/json")
+				rw.WriteHeader(http.StatusInternalServerError)
+				_, _ = rw.Write([]byte(`{"error":"internal server error"}`))
+				logger.Error("panic is service http request", zap.String("url", r.RequestURI), zap.Any("error", err))
+			}
+		}()
+		next(rw, r)
+	}
+}
+
+// StartServer binds the listener, starts serving on it in the background and waits till Start serves or the context
+// is done. The server is stopped if it did not start
+func StartServer(ctx context.Context, server Server) (net.Addr, error) {
+	addr, err := server.Listen()
+	if err != nil {
+		return nil, err
+	}
+
+	ch := make(chan error, 1)
+	go func() {
+		ch <- server.Start()
+	}()
+
+	select {
+	case <-server.Serving():
+	case <-ctx.Done():
+		err = ctx.Err()
+	case err = <-ch:
+		if err == nil {
+			err = errors.New("server stopped before it was started")
+		}
+	}
+	if err != nil {
+		<-server.Stop()
+		return nil, errors.Wrap(err, "server did not start: address=%s", addr.String())
+	}
+	return addr, nil
+}
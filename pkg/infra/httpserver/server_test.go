@@ -0,0 +1,179 @@
+package httpserver
+
+import (
+	"context"
+	"fmt"
+	"github.com/devlibx/go-template-project/pkg/infra/requestid"
+	"github.com/devlibx/gox-base/v2"
+	"github.com/devlibx/gox-base/v2/config"
+	"github.com/gin-gonic/gin"
+	"github.com/opentracing/opentracing-go"
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/zap"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestServer_ListenOnFreePort(t *testing.T) {
+	s, err := NewServer(gox.NewCrossFunction(zap.NewNop()), &config.App{AppName: "test", HttpPort: 0})
+	assert.NoError(t, err)
+	s.GetRouter().GET("/ping", func(c *gin.Context) { c.String(http.StatusOK, "pong") })
+
+	addr, err := s.Listen()
+	assert.NoError(t, err)
+	port := addr.(*net.TCPAddr).Port
+	assert.NotZero(t, port)
+
+	// Listen is done once - the same address is given again
+	again, err := s.Listen()
+	assert.NoError(t, err)
+	assert.Equal(t, addr.String(), again.String())
+
+	ch := make(chan error, 1)
+	go func() {
+		ch <- s.Start()
+	}()
+
+	<-s.Serving()
+	response, err := http.Get(fmt.Sprintf("http://127.0.0.1:%d/ping", port))
+	assert.NoError(t, err)
+	body, _ := io.ReadAll(response.Body)
+	_ = response.Body.Close()
+	assert.Equal(t, "pong", string(body))
+
+	<-s.Stop()
+	assert.NoError(t, <-ch)
+}
+
+func TestServer_ListenFailsIfPortIsUsed(t *testing.T) {
+	listener, err := net.Listen("tcp", "0.0.0.0:0")
+	assert.NoError(t, err)
+	defer listener.Close()
+
+	s, err := NewServer(gox.NewCrossFunction(zap.NewNop()), &config.App{AppName: "test", HttpPort: listener.Addr().(*net.TCPAddr).Port})
+	assert.NoError(t, err)
+	_, err = s.Listen()
+	assert.Error(t, err)
+	assert.Error(t, s.Start())
+}
+
+func TestStartServer(t *testing.T) {
+	s, err := NewServer(gox.NewCrossFunction(zap.NewNop()), &config.App{AppName: "test", HttpPort: 0})
+	assert.NoError(t, err)
+	s.GetRouter().GET("/ping", func(c *gin.Context) { c.String(http.StatusOK, "pong") })
+
+	ctx, cancel := context.WithTimeout(context.Background(), 2*time.Second)
+	defer cancel()
+	addr, err := StartServer(ctx, s)
+	assert.NoError(t, err)
+
+	response, err := http.Get(fmt.Sprintf("http://127.0.0.1:%d/ping", addr.(*net.TCPAddr).Port))
+	assert.NoError(t, err)
+	_ = response.Body.Close()
+	assert.Equal(t, http.StatusOK, response.StatusCode)
+	<-s.Stop()
+}
+
+func TestStartServer_FailsIfPortIsUsed(t *testing.T) {
+	listener, err := net.Listen("tcp", "0.0.0.0:0")
+	assert.NoError(t, err)
+	defer listener.Close()
+
+	s, err := NewServer(gox.NewCrossFunction(zap.NewNop()), &config.App{AppName: "test", HttpPort: listener.Addr().(*net.TCPAddr).Port})
+	assert.NoError(t, err)
+	_, err = StartServer(context.Background(), s)
+	assert.Error(t, err)
+
+	// Start did not serve
+	select {
+	case <-s.Serving():
+		t.Fatal("server must not be serving")
+	default:
+	}
+}
+
+func TestServer_ShutdownDrainsInFlightRequests(t *testing.T) {
+	s, err := NewServer(gox.NewCrossFunction(zap.NewNop()), &config.App{AppName: "test", HttpPort: 0})
+	assert.NoError(t, err)
+	inFlight := make(chan bool)
+	s.GetRouter().GET("/slow", func(c *gin.Context) {
+		inFlight <- true
+		time.Sleep(200 * time.Millisecond)
+		c.String(http.StatusOK, "done")
+	})
+
+	addr, err := s.Listen()
+	assert.NoError(t, err)
+	go func() {
+		_ = s.Start()
+	}()
+
+	responseCh := make(chan string, 1)
+	go func() {
+		response, err := http.Get(fmt.Sprintf("http://127.0.0.1:%d/slow", addr.(*net.TCPAddr).Port))
+		if err != nil {
+			responseCh <- err.Error()
+			return
+		}
+		body, _ := io.ReadAll(response.Body)
+		_ = response.Body.Close()
+		responseCh <- string(body)
+	}()
+	<-inFlight
+
+	ctx, cancel := context.WithTimeout(context.Background(), 2*time.Second)
+	defer cancel()
+	assert.NoError(t, s.Shutdown(ctx))
+	assert.Equal(t, "done", <-responseCh)
+
+	// New connections are not accepted after shutdown
+	_, err = net.DialTimeout("tcp", fmt.Sprintf("127.0.0.1:%d", addr.(*net.TCPAddr).Port), 100*time.Millisecond)
+	assert.Error(t, err)
+}
+
+func TestServer_RequestContextIsFoundThroughDerivedContext(t *testing.T) {
+	s, err := NewServer(gox.NewCrossFunction(zap.NewNop()), &config.App{AppName: "test"})
+	assert.NoError(t, err)
+
+	var direct, derived string
+	s.GetRouter().Use(requestid.Middleware())
+	s.GetRouter().GET("/ping", func(c *gin.Context) {
+		span, ctx := opentracing.StartSpanFromContext(c, "ping")
+		defer span.Finish()
+		direct, derived = requestid.FromContext(c), requestid.FromContext(ctx)
+	})
+
+	request := httptest.NewRequest(http.MethodGet, "/ping", nil)
+	request.Header.Set(requestid.Header, "req-1")
+	s.GetRouter().ServeHTTP(httptest.NewRecorder(), request)
+	assert.Equal(t, "req-1", direct)
+	assert.Equal(t, "req-1", derived)
+}
+
+func TestServer_ClientIPIgnoresForwardedHeadersOfUntrustedCallers(t *testing.T) {
+	s, err := NewServer(gox.NewCrossFunction(zap.NewNop()), &config.App{AppName: "test"})
+	assert.NoError(t, err)
+
+	var clientIP string
+	s.GetRouter().GET("/ping", func(c *gin.Context) {
+		clientIP = c.ClientIP()
+	})
+	call := func() string {
+		request := httptest.NewRequest(http.MethodGet, "/ping", nil)
+		request.RemoteAddr = "10.0.0.5:4000"
+		request.Header.Set("X-Forwarded-For", "1.2.3.4")
+		s.GetRouter().ServeHTTP(httptest.NewRecorder(), request)
+		return clientIP
+	}
+
+	// No proxy is trusted by default
+	assert.Equal(t, "10.0.0.5", call())
+
+	// The forwarded IP is used when the request comes from a trusted proxy
+	assert.NoError(t, s.GetRouter().SetTrustedProxies([]string{"10.0.0.0/8"}))
+	assert.Equal(t, "1.2.3.4", call())
+}
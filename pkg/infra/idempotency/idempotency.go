@@ -0,0 +1,376 @@
+package idempotency
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"github.com/devlibx/go-template-project/pkg/apperror"
+	"github.com/devlibx/go-template-project/pkg/infra/auth"
+	"github.com/devlibx/go-template-project/pkg/infra/requestid"
+	"github.com/devlibx/go-template-project/pkg/infra/router"
+	"github.com/devlibx/go-template-project/pkg/infra/shutdown"
+	"github.com/devlibx/gox-base/v2"
+	goxBaseConfig "github.com/devlibx/gox-base/v2/config"
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"go.uber.org/fx"
+	"go.uber.org/zap"
+	"io"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	// Header is the request header with the idempotency key
+	Header = "Idempotency-Key"
+
+	// ReplayedHeader is set to "true" on a stored response which is sent again
+	ReplayedHeader = "Idempotent-Replayed"
+
+	// maxKeyLength is the size of the idempotency_key column
+	maxKeyLength = 128
+
+	// storeTimeout is the max time to store the response, it is used even if the client went away
+	storeTimeout = 5 * time.Second
+
+	// MaxStoredResponseBytes is the size of the response_body column (MEDIUMBLOB)
+	MaxStoredResponseBytes = 16*1024*1024 - 1
+)
+
+// Methods are the mutating methods which can use the Idempotency-Key header
+var Methods = []string{http.MethodPost, http.MethodPut, http.MethodPatch, http.MethodDelete}
+
+// IsMethod returns true if the method is one of Methods
+func IsMethod(method string) bool {
+	for _, m := range Methods {
+		if m == strings.ToUpper(method) {
+			return true
+		}
+	}
+	return false
+}
+
+// Config enables Idempotency-Key support. A request with the header is run once per key - a retry with the same key
+// gets the stored response
+type Config struct {
+	Enabled bool `yaml:"enabled"`
+
+	// Methods which use the Idempotency-Key header, all of Methods by default
+	Methods []string `yaml:"methods"`
+
+	// TtlSec is how long a key is kept, and its response replayed - 24 hours by default
+	TtlSec int `yaml:"ttl_sec"`
+
+	// LockTimeoutMs is how long a request holds its key. If the request does not finish by then (e.g. the process
+	// died), a retry with the key runs instead of getting 409. It must be longer than the slowest request - 60s by
+	// default
+	LockTimeoutMs int `yaml:"lock_timeout_ms"`
+
+	// MaxRequestBodyBytes is the largest body of a request with a key, a larger request is rejected - 1MB by default
+	MaxRequestBodyBytes int `yaml:"max_request_body_bytes"`
+
+	// MaxResponseBodyBytes is the largest response which is stored. A larger response is sent, but the key is
+	// released so a retry runs the request again - 1MB by default
+	MaxResponseBodyBytes int `yaml:"max_response_body_bytes"`
+
+	// Expired keys are deleted every CleanupIntervalMs, at most CleanupBatchSize in one statement
+	CleanupIntervalMs int `yaml:"cleanup_interval_ms"`
+	CleanupBatchSize  int `yaml:"cleanup_batch_size"`
+}
+
+func (c *Config) SetupDefaults() {
+	if len(c.Methods) == 0 {
+		c.Methods = append([]string{}, Methods...)
+	}
+	if c.TtlSec <= 0 {
+		c.TtlSec = 24 * 60 * 60
+	}
+	if c.LockTimeoutMs <= 0 {
+		c.LockTimeoutMs = 60000
+	}
+	if c.MaxRequestBodyBytes <= 0 {
+		c.MaxRequestBodyBytes = 1024 * 1024
+	}
+	if c.MaxResponseBodyBytes <= 0 {
+		c.MaxResponseBodyBytes = 1024 * 1024
+	}
+	if c.CleanupIntervalMs <= 0 {
+		c.CleanupIntervalMs = 60000
+	}
+	if c.CleanupBatchSize <= 0 {
+		c.CleanupBatchSize = 1000
+	}
+}
+
+// Guard runs a request with an Idempotency-Key once, as gin middleware. It runs after auth, so a key is scoped to the
+// client and the route
+type Guard struct {
+	gox.CrossFunction
+	config  *Config
+	routes  router.RouteTemplates
+	methods map[string]bool
+	store   Store
+	logger  *zap.Logger
+
+	stop     chan struct{}
+	done     chan struct{}
+	stopOnce sync.Once
+}
+
+func NewGuard(cf gox.CrossFunction, config *Config, app *goxBaseConfig.App, store Store) *Guard {
+	g := &Guard{
+		CrossFunction: cf,
+		config:        config,
+		routes:        router.NewRouteTemplates(app.AppName),
+		methods:       map[string]bool{},
+		store:         store,
+		logger:        cf.Logger().Named("idempotency"),
+		stop:          make(chan struct{}),
+		done:          make(chan struct{}),
+	}
+	for _, method := range config.Methods {
+		g.methods[strings.ToUpper(method)] = true
+	}
+	return g
+}
+
+// NewGuardLifecycle starts the cleanup of expired keys with the application. Like the other background workers it is
+// stopped in the consumers shutdown stage, after the http drain and well before the database is closed
+func NewGuardLifecycle(lifecycle fx.Lifecycle, coordinator *shutdown.Coordinator, g *Guard) error {
+	if !g.config.Enabled {
+		return nil
+	}
+	lifecycle.Append(fx.Hook{
+		OnStart: func(ctx context.Context) error {
+			go g.cleanup()
+			return nil
+		},
+	})
+	return coordinator.Register(shutdown.StageConsumers, "idempotency_cleanup", g.Stop)
+}
+
+// Middleware replays the stored response of a key, and rejects with 409 a key which is in progress or was used for a
+// different request. Only responses without errors and below 500 are stored - otherwise the key is released, so the
+// request can be retried with it
+func (g *Guard) Middleware() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		key := c.GetHeader(Header)
+		if !g.config.Enabled || key == "" || !g.methods[c.Request.Method] {
+			c.Next()
+			return
+		}
+		if !validKey(key) {
+			apperror.Abort(g, c, apperror.InvalidArgument("invalid_idempotency_key", "%s must have 1 to %d printable characters", Header, maxKeyLength))
+			return
+		}
+
+		var body []byte
+		if c.Request.Body != nil {
+			var err error
+			body, err = io.ReadAll(http.MaxBytesReader(c.Writer, c.Request.Body, int64(g.config.MaxRequestBodyBytes)))
+			var tooLarge *http.MaxBytesError
+			if errors.As(err, &tooLarge) {
+				apperror.Abort(g, c, apperror.InvalidArgument("request_too_large", "request with %s must not be larger than %d bytes", Header, tooLarge.Limit).WithCause(err))
+				return
+			} else if err != nil {
+				apperror.Abort(g, c, apperror.InvalidArgument("invalid_body", "failed to read the request body").WithCause(err))
+				return
+			}
+			c.Request.Body = io.NopCloser(bytes.NewReader(body))
+		}
+
+		route := g.routes.Of(c)
+		scope := strings.Join([]string{auth.CallerID(c), c.Request.Method, route}, " ")
+		requestSha256 := fingerprint(c.Request, body)
+		now := time.Now()
+		lockedUntil := now.Add(time.Duration(g.config.LockTimeoutMs) * time.Millisecond)
+		lockID := uuid.NewString()
+		record, err := g.store.Begin(c.Request.Context(), scope, key, lockID, requestSha256, lockedUntil, now.Add(time.Duration(g.config.TtlSec)*time.Second))
+		switch {
+		case err != nil:
+			apperror.Abort(g, c, err)
+			return
+		case record != nil && record.RequestSha256 != requestSha256:
+			g.count(route, "reused")
+			apperror.Abort(g, c, apperror.Conflict("idempotency_key_reused", "%s was used for a different request", Header))
+			return
+		case record != nil && record.InProgress():
+			g.count(route, "in_progress")
+			apperror.Abort(g, c, apperror.Conflict("idempotency_request_in_progress", "a request with this %s is in progress", Header))
+			return
+		case record != nil:
+			g.count(route, "replayed")
+			replay(c, record)
+			return
+		}
+
+		// The key is released if the request does not complete e.g. on a panic
+		before := c.Writer.Header().Clone()
+		writer := &responseWriter{ResponseWriter: c.Writer, maxBytes: g.config.MaxResponseBodyBytes}
+		c.Writer = writer
+		completed := false
+		defer func() {
+			if !completed {
+				g.release(c, route, scope, key, lockID)
+			}
+		}()
+
+		c.Next()
+
+		if len(c.Errors) > 0 || writer.Status() >= http.StatusInternalServerError {
+			return
+		} else if writer.tooLarge {
+			g.count(route, "too_large")
+			requestid.Logger(c, g.logger).Warn("idempotent response is too large to store", zap.String("route", route), zap.Int("max_response_body_bytes", g.config.MaxResponseBodyBytes))
+			return
+		}
+		ctx, cancel := context.WithTimeout(context.WithoutCancel(c.Request.Context()), storeTimeout)
+		defer cancel()
+		err = g.store.Complete(ctx, scope, key, lockID, writer.Status(), addedHeaders(before, writer.Header()), writer.body.Bytes())
+		if errors.Is(err, ErrLockLost) {
+			// The key was taken over by a retry after the lock timed out - its response is the one which is kept
+			g.count(route, "lock_lost")
+			requestid.Logger(c, g.logger).Warn("idempotency key was taken over before the response was stored", zap.String("route", route), zap.Int("lock_timeout_ms", g.config.LockTimeoutMs))
+		} else if err != nil {
+			requestid.Logger(c, g.logger).Error("failed to store the idempotent response", zap.String("route", route), zap.Error(err))
+			return
+		}
+		completed = true
+	}
+}
+
+// Stop stops the cleanup of expired keys
+func (g *Guard) Stop(ctx context.Context) error {
+	g.stopOnce.Do(func() { close(g.stop) })
+	select {
+	case <-g.done:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+func (g *Guard) release(c *gin.Context, route string, scope string, key string, lockID string) {
+	ctx, cancel := context.WithTimeout(context.WithoutCancel(c.Request.Context()), storeTimeout)
+	defer cancel()
+	if err := g.store.Release(ctx, scope, key, lockID); errors.Is(err, ErrLockLost) {
+		g.count(route, "lock_lost")
+	} else if err != nil {
+		requestid.Logger(c, g.logger).Error("failed to release the idempotency key", zap.String("scope", scope), zap.Error(err))
+	}
+}
+
+func (g *Guard) count(route string, status string) {
+	g.Metric().Tagged(map[string]string{"route": route, "status": status}).Counter("idempotency").Inc(1)
+}
+
+func (g *Guard) cleanup() {
+	defer close(g.done)
+	ticker := time.NewTicker(time.Duration(g.config.CleanupIntervalMs) * time.Millisecond)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-g.stop:
+			return
+		case <-ticker.C:
+			g.deleteExpired()
+		}
+	}
+}
+
+// deleteExpired deletes expired keys in batches, until a batch is not full
+func (g *Guard) deleteExpired() {
+	for {
+		ctx, cancel := context.WithTimeout(context.Background(), storeTimeout)
+		deleted, err := g.store.DeleteExpired(ctx, time.Now(), g.config.CleanupBatchSize)
+		cancel()
+		if err != nil {
+			g.logger.Warn("failed to delete expired idempotency keys", zap.Error(err))
+			return
+		}
+		select {
+		case <-g.stop:
+			return
+		default:
+		}
+		if deleted < int64(g.config.CleanupBatchSize) {
+			return
+		}
+	}
+}
+
+// replay sends a stored response. The headers set before the handler e.g. X-Request-ID are from this request
+func replay(c *gin.Context, record *Record) {
+	for name, values := range record.Header {
+		c.Writer.Header()[name] = values
+	}
+	c.Header(ReplayedHeader, "true")
+	c.Data(record.StatusCode, record.Header.Get("Content-Type"), record.Body)
+	c.Abort()
+}
+
+// fingerprint is the sha256 of the method, url and body - a key must always be sent with the same request
+func fingerprint(r *http.Request, body []byte) string {
+	h := sha256.New()
+	h.Write([]byte(r.Method + " " + r.URL.RequestURI() + "\n"))
+	h.Write(body)
+	return hex.EncodeToString(h.Sum(nil))
+}
+
+// addedHeaders are the headers set or changed after before was taken, e.g. by the handler
+func addedHeaders(before http.Header, after http.Header) http.Header {
+	added := http.Header{}
+	for name, values := range after {
+		if strings.Join(before[name], ",") != strings.Join(values, ",") {
+			added[name] = values
+		}
+	}
+	return added
+}
+
+func validKey(key string) bool {
+	if len(key) > maxKeyLength {
+		return false
+	}
+	for i := 0; i < len(key); i++ {
+		if key[i] < '!' || key[i] > '~' {
+			return false
+		}
+	}
+	return true
+}
+
+// responseWriter keeps the response body, so it can be stored. A body larger than maxBytes is not kept, tooLarge is
+// set instead
+type responseWriter struct {
+	gin.ResponseWriter
+	maxBytes int
+	body     bytes.Buffer
+	tooLarge bool
+}
+
+func (w *responseWriter) Write(p []byte) (int, error) {
+	w.keep(p)
+	return w.ResponseWriter.Write(p)
+}
+
+func (w *responseWriter) WriteString(s string) (int, error) {
+	w.keep([]byte(s))
+	return w.ResponseWriter.WriteString(s)
+}
+
+func (w *responseWriter) keep(p []byte) {
+	if w.tooLarge {
+		return
+	}
+	if w.body.Len()+len(p) > w.maxBytes {
+		w.tooLarge = true
+		w.body = bytes.Buffer{}
+		return
+	}
+	w.body.Write(p)
+}
@@ -0,0 +1,295 @@
+package idempotency
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"github.com/devlibx/go-template-project/pkg/apperror"
+	"github.com/devlibx/go-template-project/pkg/infra/auth"
+	ordersDataStore "github.com/devlibx/go-template-project/pkg/infra/database/mysql/user/rw"
+	"github.com/devlibx/go-template-project/pkg/infra/requestid"
+	"github.com/devlibx/gox-base/v2"
+	goxBaseConfig "github.com/devlibx/gox-base/v2/config"
+	"github.com/gin-gonic/gin"
+	"github.com/go-sql-driver/mysql"
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/zap"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+// testQuerier keeps the idempotency_keys table in memory
+type testQuerier struct {
+	ordersDataStore.Querier
+	lock sync.Mutex
+	rows map[string]*ordersDataStore.IdempotencyKey
+}
+
+func newTestQuerier() *testQuerier {
+	return &testQuerier{rows: map[string]*ordersDataStore.IdempotencyKey{}}
+}
+
+func (q *testQuerier) CreateIdempotencyKey(ctx context.Context, arg ordersDataStore.CreateIdempotencyKeyParams) error {
+	q.lock.Lock()
+	defer q.lock.Unlock()
+	if _, ok := q.rows[arg.Scope+"/"+arg.IdempotencyKey]; ok {
+		return &mysql.MySQLError{Number: 1062, Message: "Duplicate entry"}
+	}
+	q.rows[arg.Scope+"/"+arg.IdempotencyKey] = &ordersDataStore.IdempotencyKey{
+		Scope:          arg.Scope,
+		IdempotencyKey: arg.IdempotencyKey,
+		RequestSha256:  arg.RequestSha256,
+		LockID:         arg.LockID,
+		LockedUntil:    arg.LockedUntil,
+		ExpiresAt:      arg.ExpiresAt,
+	}
+	return nil
+}
+
+func (q *testQuerier) GetIdempotencyKey(ctx context.Context, arg ordersDataStore.GetIdempotencyKeyParams) (*ordersDataStore.IdempotencyKey, error) {
+	q.lock.Lock()
+	defer q.lock.Unlock()
+	if row, ok := q.rows[arg.Scope+"/"+arg.IdempotencyKey]; ok {
+		copied := *row
+		return &copied, nil
+	}
+	return nil, sql.ErrNoRows
+}
+
+func (q *testQuerier) CompleteIdempotencyKey(ctx context.Context, arg ordersDataStore.CompleteIdempotencyKeyParams) (int64, error) {
+	q.lock.Lock()
+	defer q.lock.Unlock()
+	if row, ok := q.rows[arg.Scope+"/"+arg.IdempotencyKey]; ok && row.LockID == arg.LockID {
+		row.StatusCode, row.ResponseHeaders, row.ResponseBody = arg.StatusCode, arg.ResponseHeaders, arg.ResponseBody
+		return 1, nil
+	}
+	return 0, nil
+}
+
+func (q *testQuerier) DeleteIdempotencyKey(ctx context.Context, arg ordersDataStore.DeleteIdempotencyKeyParams) (int64, error) {
+	q.lock.Lock()
+	defer q.lock.Unlock()
+	if row, ok := q.rows[arg.Scope+"/"+arg.IdempotencyKey]; ok && row.LockID == arg.LockID {
+		delete(q.rows, arg.Scope+"/"+arg.IdempotencyKey)
+		return 1, nil
+	}
+	return 0, nil
+}
+
+func (q *testQuerier) DeleteStaleIdempotencyKey(ctx context.Context, arg ordersDataStore.DeleteStaleIdempotencyKeyParams) (int64, error) {
+	q.lock.Lock()
+	defer q.lock.Unlock()
+	if row, ok := q.rows[arg.Scope+"/"+arg.IdempotencyKey]; ok && stale(row, arg.Now) {
+		delete(q.rows, arg.Scope+"/"+arg.IdempotencyKey)
+		return 1, nil
+	}
+	return 0, nil
+}
+
+func (q *testQuerier) DeleteExpiredIdempotencyKeys(ctx context.Context, arg ordersDataStore.DeleteExpiredIdempotencyKeysParams) (int64, error) {
+	q.lock.Lock()
+	defer q.lock.Unlock()
+	var deleted int64
+	for id, row := range q.rows {
+		if row.ExpiresAt.Before(arg.ExpiresAt) && deleted < int64(arg.Limit) {
+			delete(q.rows, id)
+			deleted++
+		}
+	}
+	return deleted, nil
+}
+
+func newTestEngine(config *Config, store Store) (*gin.Engine, *int) {
+	config.SetupDefaults()
+	cf := gox.NewCrossFunction(zap.NewNop())
+	guard := NewGuard(cf, config, &goxBaseConfig.App{AppName: "app"}, store)
+
+	calls := 0
+	engine := gin.New()
+	group := engine.Group("/app")
+	group.Use(requestid.Middleware(), apperror.Handler(cf))
+	group.Use(func(c *gin.Context) {
+		c.Request = c.Request.WithContext(auth.WithClient(c.Request.Context(), &auth.Client{ID: c.GetHeader("client")}))
+	})
+	group.Use(guard.Middleware())
+	group.POST("/api/v1/order", func(c *gin.Context) {
+		calls++
+		body, _ := io.ReadAll(c.Request.Body)
+		switch string(body) {
+		case "invalid":
+			_ = c.Error(apperror.InvalidArgument("invalid_order", "order is not valid"))
+		case "unavailable":
+			c.Status(http.StatusServiceUnavailable)
+		default:
+			c.Header("Location", "/app/api/v1/order/1")
+			c.Data(http.StatusCreated, "application/json", body)
+		}
+	})
+	group.GET("/api/v1/order", func(c *gin.Context) {
+		calls++
+		c.Status(http.StatusOK)
+	})
+	return engine, &calls
+}
+
+func call(engine *gin.Engine, method string, client string, key string, body string) *httptest.ResponseRecorder {
+	request := httptest.NewRequest(method, "/app/api/v1/order", strings.NewReader(body))
+	request.Header.Set("client", client)
+	if key != "" {
+		request.Header.Set(Header, key)
+	}
+	recorder := httptest.NewRecorder()
+	engine.ServeHTTP(recorder, request)
+	return recorder
+}
+
+func errorCode(t *testing.T, response *httptest.ResponseRecorder) string {
+	problem := &apperror.Problem{}
+	assert.NoError(t, json.Unmarshal(response.Body.Bytes(), problem))
+	return problem.Code
+}
+
+func TestGuard(t *testing.T) {
+	engine, calls := newTestEngine(&Config{Enabled: true}, NewMySqlStore(newTestQuerier()))
+
+	first := call(engine, http.MethodPost, "c1", "k1", `{"qty":1}`)
+	assert.Equal(t, http.StatusCreated, first.Code)
+	assert.Equal(t, 1, *calls)
+
+	// The stored response is replayed, with the request id of the retry
+	response := call(engine, http.MethodPost, "c1", "k1", `{"qty":1}`)
+	assert.Equal(t, http.StatusCreated, response.Code)
+	assert.Equal(t, `{"qty":1}`, response.Body.String())
+	assert.Equal(t, "/app/api/v1/order/1", response.Header().Get("Location"))
+	assert.Equal(t, "application/json", response.Header().Get("Content-Type"))
+	assert.Equal(t, "true", response.Header().Get(ReplayedHeader))
+	assert.NotEqual(t, first.Header().Get(requestid.Header), response.Header().Get(requestid.Header))
+	assert.Equal(t, 1, *calls)
+
+	// The same key with a different body
+	response = call(engine, http.MethodPost, "c1", "k1", `{"qty":2}`)
+	assert.Equal(t, http.StatusConflict, response.Code)
+	assert.Equal(t, "idempotency_key_reused", errorCode(t, response))
+
+	// Keys are scoped to the client
+	assert.Equal(t, http.StatusCreated, call(engine, http.MethodPost, "c2", "k1", `{"qty":2}`).Code)
+	assert.Equal(t, 2, *calls)
+
+	// No key, or a method which is not mutating
+	assert.Equal(t, http.StatusCreated, call(engine, http.MethodPost, "c1", "", `{"qty":1}`).Code)
+	assert.Equal(t, http.StatusOK, call(engine, http.MethodGet, "c1", "k1", "").Code)
+	assert.Equal(t, 4, *calls)
+
+	response = call(engine, http.MethodPost, "c1", strings.Repeat("k", 129), `{"qty":1}`)
+	assert.Equal(t, http.StatusBadRequest, response.Code)
+	assert.Equal(t, "invalid_idempotency_key", errorCode(t, response))
+	assert.Equal(t, 4, *calls)
+}
+
+func TestGuard_FailedRequestsAreNotStored(t *testing.T) {
+	engine, calls := newTestEngine(&Config{Enabled: true}, NewMySqlStore(newTestQuerier()))
+
+	assert.Equal(t, http.StatusBadRequest, call(engine, http.MethodPost, "c1", "k1", "invalid").Code)
+	assert.Equal(t, http.StatusBadRequest, call(engine, http.MethodPost, "c1", "k1", "invalid").Code)
+	assert.Equal(t, http.StatusServiceUnavailable, call(engine, http.MethodPost, "c1", "k2", "unavailable").Code)
+	assert.Equal(t, http.StatusServiceUnavailable, call(engine, http.MethodPost, "c1", "k2", "unavailable").Code)
+	assert.Equal(t, 4, *calls)
+}
+
+func TestGuard_BodyLimits(t *testing.T) {
+	engine, calls := newTestEngine(&Config{Enabled: true, MaxRequestBodyBytes: 16, MaxResponseBodyBytes: 8}, NewMySqlStore(newTestQuerier()))
+
+	response := call(engine, http.MethodPost, "c1", "k1", `{"qty":1234567890}`)
+	assert.Equal(t, http.StatusBadRequest, response.Code)
+	assert.Equal(t, "request_too_large", errorCode(t, response))
+	assert.Equal(t, 0, *calls)
+
+	// The response is sent, but it is too large to store - so the key is released and a retry runs again
+	assert.Equal(t, http.StatusCreated, call(engine, http.MethodPost, "c1", "k1", `{"qty":123}`).Code)
+	response = call(engine, http.MethodPost, "c1", "k1", `{"qty":123}`)
+	assert.Equal(t, http.StatusCreated, response.Code)
+	assert.Equal(t, `{"qty":123}`, response.Body.String())
+	assert.Empty(t, response.Header().Get(ReplayedHeader))
+	assert.Equal(t, 2, *calls)
+}
+
+func TestGuard_InProgress(t *testing.T) {
+	store := NewMySqlStore(newTestQuerier())
+	engine, calls := newTestEngine(&Config{Enabled: true}, store)
+
+	request := httptest.NewRequest(http.MethodPost, "/app/api/v1/order", strings.NewReader("{}"))
+	record, err := store.Begin(context.Background(), "c1 POST /api/v1/order", "k1", "l1", fingerprint(request, []byte("{}")), time.Now().Add(time.Minute), time.Now().Add(time.Hour))
+	assert.NoError(t, err)
+	assert.Nil(t, record)
+
+	response := call(engine, http.MethodPost, "c1", "k1", "{}")
+	assert.Equal(t, http.StatusConflict, response.Code)
+	assert.Equal(t, "idempotency_request_in_progress", errorCode(t, response))
+	assert.Equal(t, 0, *calls)
+
+	// The request which held the key died - once its lock passed, a retry runs
+	record, err = store.Begin(context.Background(), "c1 POST /api/v1/order", "k2", "l1", fingerprint(request, []byte("{}")), time.Now().Add(-time.Second), time.Now().Add(time.Hour))
+	assert.NoError(t, err)
+	assert.Nil(t, record)
+
+	response = call(engine, http.MethodPost, "c1", "k2", "{}")
+	assert.Equal(t, http.StatusCreated, response.Code)
+	assert.Equal(t, 1, *calls)
+}
+
+func TestMySqlStore_Expiry(t *testing.T) {
+	querier := newTestQuerier()
+	store := NewMySqlStore(querier)
+	ctx := context.Background()
+
+	lockedUntil := time.Now().Add(time.Minute)
+	_, err := store.Begin(ctx, "s", "k1", "l1", "a", lockedUntil, time.Now().Add(-time.Second))
+	assert.NoError(t, err)
+	_, err = store.Begin(ctx, "s", "k2", "l1", "a", lockedUntil, time.Now().Add(-time.Second))
+	assert.NoError(t, err)
+	_, err = store.Begin(ctx, "s", "k3", "l1", "a", lockedUntil, time.Now().Add(time.Minute))
+	assert.NoError(t, err)
+
+	// An expired key is taken over
+	record, err := store.Begin(ctx, "s", "k1", "l2", "b", lockedUntil, time.Now().Add(time.Minute))
+	assert.NoError(t, err)
+	assert.Nil(t, record)
+	assert.Equal(t, "b", querier.rows["s/k1"].RequestSha256)
+
+	deleted, err := store.DeleteExpired(ctx, time.Now(), 10)
+	assert.NoError(t, err)
+	assert.Equal(t, int64(1), deleted)
+	assert.Len(t, querier.rows, 2)
+}
+
+func TestMySqlStore_TakenOverKey(t *testing.T) {
+	querier := newTestQuerier()
+	store := NewMySqlStore(querier)
+	ctx := context.Background()
+
+	// l1 takes the key, its lock passes and l2 takes the key over
+	_, err := store.Begin(ctx, "s", "k1", "l1", "a", time.Now().Add(-time.Second), time.Now().Add(time.Hour))
+	assert.NoError(t, err)
+	record, err := store.Begin(ctx, "s", "k1", "l2", "a", time.Now().Add(time.Minute), time.Now().Add(time.Hour))
+	assert.NoError(t, err)
+	assert.Nil(t, record)
+
+	// The slow first request can neither complete nor release the key of l2
+	assert.ErrorIs(t, store.Complete(ctx, "s", "k1", "l1", http.StatusCreated, nil, []byte("first")), ErrLockLost)
+	assert.ErrorIs(t, store.Release(ctx, "s", "k1", "l1"), ErrLockLost)
+	assert.Equal(t, "l2", querier.rows["s/k1"].LockID)
+	assert.Equal(t, int32(0), querier.rows["s/k1"].StatusCode)
+
+	// A third request is still rejected while l2 is in progress, and l2 completes the key
+	record, err = store.Begin(ctx, "s", "k1", "l3", "a", time.Now().Add(time.Minute), time.Now().Add(time.Hour))
+	assert.NoError(t, err)
+	assert.True(t, record.InProgress())
+	assert.NoError(t, store.Complete(ctx, "s", "k1", "l2", http.StatusCreated, nil, []byte("second")))
+	assert.Equal(t, []byte("second"), querier.rows["s/k1"].ResponseBody)
+	assert.NoError(t, store.Release(ctx, "s", "k1", "l2"))
+}
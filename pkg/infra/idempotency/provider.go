@@ -0,0 +1,10 @@
+package idempotency
+
+import "go.uber.org/fx"
+
+// Provider gives the Guard with the MySQL store, and starts the cleanup of expired keys
+var Provider = fx.Options(
+	fx.Provide(NewMySqlStore),
+	fx.Provide(NewGuard),
+	fx.Invoke(NewGuardLifecycle),
+)
@@ -0,0 +1,155 @@
+package idempotency
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"github.com/devlibx/go-template-project/pkg/apperror"
+	"github.com/devlibx/go-template-project/pkg/infra/database"
+	ordersDataStore "github.com/devlibx/go-template-project/pkg/infra/database/mysql/user/rw"
+	"net/http"
+	"time"
+)
+
+// ErrLockLost is given by Complete and Release if the key was taken over by another request, after the lock of the
+// request which took it passed
+var ErrLockLost = errors.New("idempotency key lock lost")
+
+// beginAttempts is how often Begin tries to take a key which is released or taken over by another request meanwhile
+const beginAttempts = 3
+
+// Record is a request stored for an idempotency key. StatusCode is 0 while the first request is in progress
+type Record struct {
+	RequestSha256 string
+	StatusCode    int
+	Header        http.Header
+	Body          []byte
+}
+
+// InProgress is true if the response of the first request is not stored yet
+func (r *Record) InProgress() bool {
+	return r.StatusCode == 0
+}
+
+// Store keeps the idempotency keys. A key is scoped e.g. to the client and route, so two clients can use the same key
+type Store interface {
+	// Begin takes the key for a new request with the lockID till lockedUntil, and gives nil. If the key is taken, the
+	// record of the request which has it is given - unless the key expired, or its request is still in progress after
+	// its lockedUntil (e.g. the process died), in which case the key is taken over
+	Begin(ctx context.Context, scope string, key string, lockID string, requestSha256 string, lockedUntil time.Time, expiresAt time.Time) (*Record, error)
+
+	// Complete stores the response of the request which took the key with the lockID. It gives ErrLockLost if the key
+	// was taken over meanwhile
+	Complete(ctx context.Context, scope string, key string, lockID string, statusCode int, header http.Header, body []byte) error
+
+	// Release gives the key back e.g. if the request failed, so it can be retried with the same key. It gives
+	// ErrLockLost if the key was taken over meanwhile
+	Release(ctx context.Context, scope string, key string, lockID string) error
+
+	// DeleteExpired deletes up to limit records which expired before the given time, and gives the number deleted
+	DeleteExpired(ctx context.Context, before time.Time, limit int) (int64, error)
+}
+
+type mySqlStore struct {
+	querier ordersDataStore.Querier
+}
+
+// NewMySqlStore gives a store which keeps the keys in the idempotency_keys table of the RW connection
+func NewMySqlStore(querier ordersDataStore.Querier) Store {
+	return &mySqlStore{querier: querier}
+}
+
+func (s *mySqlStore) Begin(ctx context.Context, scope string, key string, lockID string, requestSha256 string, lockedUntil time.Time, expiresAt time.Time) (*Record, error) {
+	for attempt := 0; attempt < beginAttempts; attempt++ {
+		err := s.querier.CreateIdempotencyKey(ctx, ordersDataStore.CreateIdempotencyKeyParams{
+			Scope:          scope,
+			IdempotencyKey: key,
+			RequestSha256:  requestSha256,
+			LockID:         lockID,
+			LockedUntil:    lockedUntil,
+			ExpiresAt:      expiresAt,
+		})
+		if err == nil {
+			return nil, nil
+		} else if err = database.ToAppError(err, nil); apperror.KindOf(err) != apperror.KindConflict {
+			return nil, err
+		}
+
+		// The key is taken - give the record, or take the key over if it is stale
+		row, err := s.querier.GetIdempotencyKey(ctx, ordersDataStore.GetIdempotencyKeyParams{Scope: scope, IdempotencyKey: key})
+		if errors.Is(err, sql.ErrNoRows) {
+			continue
+		} else if err != nil {
+			return nil, database.ToAppError(err, nil)
+		}
+		if now := time.Now(); !stale(row, now) {
+			return toRecord(row), nil
+		} else if _, err := s.querier.DeleteStaleIdempotencyKey(ctx, ordersDataStore.DeleteStaleIdempotencyKeyParams{
+			Scope:          scope,
+			IdempotencyKey: key,
+			Now:            now,
+		}); err != nil {
+			return nil, database.ToAppError(err, nil)
+		}
+	}
+
+	// Other requests keep taking the key, so one of them is in progress
+	return &Record{RequestSha256: requestSha256}, nil
+}
+
+func (s *mySqlStore) Complete(ctx context.Context, scope string, key string, lockID string, statusCode int, header http.Header, body []byte) error {
+	headers, err := json.Marshal(header)
+	if err != nil {
+		return apperror.Internal("idempotency_error", "failed to store the response headers").WithCause(err)
+	}
+	updated, err := s.querier.CompleteIdempotencyKey(ctx, ordersDataStore.CompleteIdempotencyKeyParams{
+		StatusCode:      int32(statusCode),
+		ResponseHeaders: sql.NullString{String: string(headers), Valid: true},
+		ResponseBody:    body,
+		Scope:           scope,
+		IdempotencyKey:  key,
+		LockID:          lockID,
+	})
+	return lockResult(updated, err)
+}
+
+func (s *mySqlStore) Release(ctx context.Context, scope string, key string, lockID string) error {
+	deleted, err := s.querier.DeleteIdempotencyKey(ctx, ordersDataStore.DeleteIdempotencyKeyParams{
+		Scope:          scope,
+		IdempotencyKey: key,
+		LockID:         lockID,
+	})
+	return lockResult(deleted, err)
+}
+
+func (s *mySqlStore) DeleteExpired(ctx context.Context, before time.Time, limit int) (int64, error) {
+	deleted, err := s.querier.DeleteExpiredIdempotencyKeys(ctx, ordersDataStore.DeleteExpiredIdempotencyKeysParams{
+		ExpiresAt: before,
+		Limit:     int32(limit),
+	})
+	return deleted, database.ToAppError(err, nil)
+}
+
+// lockResult gives ErrLockLost if a statement which matches the lock id of a key changed no row
+func lockResult(changed int64, err error) error {
+	if err != nil {
+		return database.ToAppError(err, nil)
+	} else if changed == 0 {
+		return ErrLockLost
+	}
+	return nil
+}
+
+// stale is true if the key expired, or its request is in progress after locked_until - same as DeleteStaleIdempotencyKey
+func stale(row *ordersDataStore.IdempotencyKey, now time.Time) bool {
+	return row.ExpiresAt.Before(now) || (row.StatusCode == 0 && row.LockedUntil.Before(now))
+}
+
+func toRecord(row *ordersDataStore.IdempotencyKey) *Record {
+	record := &Record{RequestSha256: row.RequestSha256, StatusCode: int(row.StatusCode), Body: row.ResponseBody}
+	if row.ResponseHeaders.Valid {
+		_ = json.Unmarshal([]byte(row.ResponseHeaders.String), &record.Header)
+	}
+	return record
+}
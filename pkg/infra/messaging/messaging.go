@@ -2,12 +2,15 @@ package consumers
 
 import (
 	"context"
+	"github.com/devlibx/go-template-project/pkg/infra/shutdown"
 	"github.com/devlibx/gox-base/v2"
 	"github.com/devlibx/gox-base/v2/errors"
 	goxMessaging "github.com/devlibx/gox-messaging/v2"
 	"github.com/devlibx/gox-messaging/v2/factory"
 	"go.uber.org/fx"
 	"go.uber.org/zap"
+	"sync"
+	"time"
 )
 
 type MessagingFactory goxMessaging.Factory
@@ -16,6 +19,10 @@ type messagingServiceImpl struct {
 	gox.CrossFunction
 	logger *zap.Logger
 	goxMessaging.Factory
+
+	lock            sync.Mutex
+	producerConfigs map[string]goxMessaging.ProducerConfig
+	producers       map[string]*kafkaProducer
 }
 
 func NewMessagingFactoryLifecycle(lifecycle fx.Lifecycle, cf gox.CrossFunction, configuration *goxMessaging.Configuration, service MessagingFactory) {
@@ -48,11 +55,76 @@ func NewMessagingFactoryLifecycle(lifecycle fx.Lifecycle, cf gox.CrossFunction,
 	})
 }
 
+// flusher is implemented by producers which buffer messages e.g. the kafka producer
+type flusher interface {
+	Flush(timeoutMs int) int
+}
+
+// NewMessagingShutdown stops consumers and then flushes and stops producers in the shutdown stages. The lifecycle
+// hook in NewMessagingFactoryLifecycle still stops the factory once the stages are done.
+//
+// A producer is flushed before it is stopped. The gox-messaging kafka producer keeps messages in its own queue, and
+// its Stop closes the librdkafka producer without a flush - but messages sent with a producer of GetProducer never go
+// to that queue (see kafkaProducer). Async messages are given to librdkafka directly, so Flush has all of them, and
+// the Stop of kafkaProducer sends its queued sync messages before it stops the gox-messaging producer
+func NewMessagingShutdown(coordinator *shutdown.Coordinator, configuration *goxMessaging.Configuration, service MessagingFactory) error {
+	if configuration == nil || !configuration.Enabled {
+		return nil
+	}
+
+	for name, config := range configuration.Consumers {
+		if !config.Enabled {
+			continue
+		}
+		name := name
+		err := coordinator.Register(shutdown.StageConsumers, "consumer."+name, func(ctx context.Context) error {
+			consumer, err := service.GetConsumer(name)
+			if err != nil {
+				return errors.Wrap(err, "consumer not found: name=%s", name)
+			}
+			return consumer.Stop()
+		})
+		if err != nil {
+			return err
+		}
+	}
+
+	for name, config := range configuration.Producers {
+		if !config.Enabled {
+			continue
+		}
+		name := name
+		err := coordinator.Register(shutdown.StageProducers, "producer."+name, func(ctx context.Context) error {
+			producer, err := service.GetProducer(name)
+			if err != nil {
+				return errors.Wrap(err, "producer not found: name=%s", name)
+			}
+			if f, ok := producer.(flusher); ok {
+				timeoutMs := 1000
+				if deadline, ok := ctx.Deadline(); ok {
+					timeoutMs = int(time.Until(deadline).Milliseconds())
+				}
+				if remaining := f.Flush(timeoutMs); remaining > 0 {
+					_ = producer.Stop()
+					return errors.New("messages are not flushed: name=%s, count=%d", name, remaining)
+				}
+			}
+			return producer.Stop()
+		})
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
 func NewMessagingFactory(cf gox.CrossFunction) (MessagingFactory, error) {
 	service := messagingServiceImpl{
-		CrossFunction: cf,
-		logger:        cf.Logger(),
-		Factory:       factory.NewMessagingFactory(cf),
+		CrossFunction:   cf,
+		logger:          cf.Logger(),
+		Factory:         factory.NewMessagingFactory(cf),
+		producerConfigs: map[string]goxMessaging.ProducerConfig{},
+		producers:       map[string]*kafkaProducer{},
 	}
 	return &service, nil
 }
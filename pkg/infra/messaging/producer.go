@@ -0,0 +1,246 @@
+package consumers
+
+import (
+	"context"
+	"github.com/confluentinc/confluent-kafka-go/v2/kafka"
+	"github.com/devlibx/go-template-project/pkg/infra/requestid"
+	"github.com/devlibx/gox-base/v2"
+	"github.com/devlibx/gox-base/v2/errors"
+	goxMessaging "github.com/devlibx/gox-messaging/v2"
+	"go.uber.org/zap"
+	"sync"
+	"time"
+)
+
+// librdkafkaProducer is the confluent kafka producer, which the gox-messaging kafka producer embeds
+type librdkafkaProducer interface {
+	Produce(msg *kafka.Message, deliveryChan chan kafka.Event) error
+	Flush(timeoutMs int) int
+}
+
+// Start keeps the producer configs, so GetProducer knows the topic and mode of the kafka producers
+func (m *messagingServiceImpl) Start(configuration goxMessaging.Configuration) error {
+	m.lock.Lock()
+	for name, config := range configuration.Producers {
+		config.Name = name
+		m.producerConfigs[name] = config
+	}
+	m.lock.Unlock()
+	return m.Factory.Start(configuration)
+}
+
+func (m *messagingServiceImpl) RegisterProducer(config goxMessaging.ProducerConfig) error {
+	m.lock.Lock()
+	m.producerConfigs[config.Name] = config
+	m.lock.Unlock()
+	return m.Factory.RegisterProducer(config)
+}
+
+// GetProducer gives the producer which sends the request id of the context in the X-Request-ID header of kafka
+// messages. Producers of other types (sqs, dummy) have no headers, they are given as they are. The same kafka
+// producer is given for a name, so it knows when it is stopped
+func (m *messagingServiceImpl) GetProducer(name string) (goxMessaging.Producer, error) {
+	producer, err := m.Factory.GetProducer(name)
+	if err != nil {
+		return nil, err
+	}
+
+	m.lock.Lock()
+	defer m.lock.Unlock()
+	if existing, ok := m.producers[name]; ok && existing.Producer == producer {
+		return existing, nil
+	}
+	config, ok := m.producerConfigs[name]
+	if rdProducer, isKafka := producer.(librdkafkaProducer); ok && isKafka && config.Type == "kafka" {
+		config.SetupDefaults()
+		wrapped := newKafkaProducer(m.CrossFunction, producer, rdProducer, config)
+		m.producers[name] = wrapped
+		return wrapped, nil
+	}
+	return producer, nil
+}
+
+// Stop stops the sends of the kafka producers given by GetProducer, and then the factory
+func (m *messagingServiceImpl) Stop() error {
+	m.lock.Lock()
+	producers := m.producers
+	m.producers = map[string]*kafkaProducer{}
+	m.lock.Unlock()
+	for _, producer := range producers {
+		producer.stopSends()
+	}
+	return m.Factory.Stop()
+}
+
+// kafkaProducer sends messages with Kafka headers. gox-messaging builds the kafka message itself and sets no headers,
+// so messages are produced with its librdkafka producer directly - the gox-messaging producer is only used to stop it.
+// It keeps what gox-messaging does around a send: a send with a done context or after Stop fails, sync messages are
+// sent by Concurrency workers from a queue of MaxMessageInBuffer, and a failed sync message is reported on the error
+// reporting channel (see goxMessaging.ErrorReporter). Failed async messages are reported by gox-messaging, which reads
+// the events of the librdkafka producer
+type kafkaProducer struct {
+	gox.CrossFunction
+	goxMessaging.Producer
+	rdProducer   librdkafkaProducer
+	config       goxMessaging.ProducerConfig
+	logger       *zap.Logger
+	errorReports chan *goxMessaging.Response
+
+	lock    sync.RWMutex
+	closed  bool
+	queue   chan *kafkaSend
+	workers sync.WaitGroup
+}
+
+// kafkaSend is a sync message waiting for a worker
+type kafkaSend struct {
+	msg       *kafka.Message
+	responses chan *goxMessaging.Response
+}
+
+func newKafkaProducer(cf gox.CrossFunction, producer goxMessaging.Producer, rdProducer librdkafkaProducer, config goxMessaging.ProducerConfig) *kafkaProducer {
+	p := &kafkaProducer{
+		CrossFunction: cf,
+		Producer:      producer,
+		rdProducer:    rdProducer,
+		config:        config,
+		logger:        cf.Logger().Named("kafka.producer").Named(config.Name),
+	}
+	if reporter, ok := producer.(goxMessaging.ErrorReporter); ok {
+		if errorReports, enabled, err := reporter.GetErrorReport(); err == nil && enabled {
+			p.errorReports = errorReports
+		}
+	}
+	if !config.Async {
+		p.queue = make(chan *kafkaSend, config.MaxMessageInBuffer)
+		for i := 0; i < max(config.Concurrency, 1); i++ {
+			p.workers.Add(1)
+			go p.sendWorker()
+		}
+	}
+	return p
+}
+
+func (p *kafkaProducer) Send(ctx context.Context, message *goxMessaging.Message) chan *goxMessaging.Response {
+	responses := make(chan *goxMessaging.Response, 1)
+	p.lock.RLock()
+	defer p.lock.RUnlock()
+	if p.closed {
+		responses <- &goxMessaging.Response{Err: errors.New("producer is closed: name=%s", p.config.Name)}
+		close(responses)
+		return responses
+	} else if err := ctx.Err(); err != nil {
+		responses <- &goxMessaging.Response{Err: errors.Wrap(err, "context is closed to kafka producer: name=%s, message=%s", p.config.Name, message.Key)}
+		close(responses)
+		return responses
+	}
+
+	payload, err := message.PayloadAsBytes()
+	if err != nil {
+		p.respond(responses, &goxMessaging.Response{Err: errors.Wrap(err, "failed to send kafka message - cannot read bytes: name=%s", p.config.Name)}, "error", "payload_error")
+		return responses
+	}
+	msg := &kafka.Message{
+		TopicPartition: kafka.TopicPartition{Topic: &p.config.Topic, Partition: kafka.PartitionAny},
+		Key:            []byte(message.Key),
+		Value:          payload,
+	}
+	if id := requestid.FromContext(ctx); id != "" {
+		msg.Headers = []kafka.Header{{Key: requestid.Header, Value: []byte(id)}}
+	}
+
+	// An async message is reported on the events of the producer, which gox-messaging reads
+	if p.config.Async {
+		if err = p.rdProducer.Produce(msg, nil); err != nil {
+			p.respond(responses, &goxMessaging.Response{Err: errors.Wrap(err, "failed to send async kafka message: name=%s", p.config.Name)}, "error", "produce_failed")
+		} else {
+			p.respond(responses, &goxMessaging.Response{RawPayload: ""}, "ok", "na")
+		}
+		return responses
+	}
+
+	select {
+	case p.queue <- &kafkaSend{msg: msg, responses: responses}:
+	case <-ctx.Done():
+		responses <- &goxMessaging.Response{Err: errors.Wrap(ctx.Err(), "context is closed to kafka producer: name=%s, message=%s", p.config.Name, message.Key)}
+		close(responses)
+	}
+	return responses
+}
+
+// Stop sends the queued sync messages, and then stops the gox-messaging producer
+func (p *kafkaProducer) Stop() error {
+	p.stopSends()
+	return p.Producer.Stop()
+}
+
+// Flush waits for the messages sent with this producer to be delivered - see NewMessagingShutdown
+func (p *kafkaProducer) Flush(timeoutMs int) int {
+	return p.rdProducer.Flush(timeoutMs)
+}
+
+// stopSends fails the next sends, and waits for the workers to send the queued messages
+func (p *kafkaProducer) stopSends() {
+	p.lock.Lock()
+	if !p.closed {
+		p.closed = true
+		if p.queue != nil {
+			close(p.queue)
+		}
+	}
+	p.lock.Unlock()
+	p.workers.Wait()
+}
+
+// sendWorker sends sync messages one at a time, waiting for the delivery of each
+func (p *kafkaProducer) sendWorker() {
+	defer p.workers.Done()
+	for send := range p.queue {
+		deliveries := make(chan kafka.Event, 1)
+		if err := p.rdProducer.Produce(send.msg, deliveries); err != nil {
+			p.respond(send.responses, &goxMessaging.Response{Err: errors.Wrap(err, "failed to send kafka message: name=%s", p.config.Name)}, "error", "produce_failed")
+			continue
+		}
+
+		var err error
+		select {
+		case event := <-deliveries:
+			if delivered, ok := event.(*kafka.Message); ok && delivered.TopicPartition.Error == nil {
+				p.respond(send.responses, &goxMessaging.Response{RawPayload: delivered}, "ok", "na")
+				continue
+			} else if ok {
+				err = errors.Wrap(delivered.TopicPartition.Error, "failed to produce message to kafka: name=%s", p.config.Name)
+			} else {
+				err = errors.New("unexpected kafka delivery event: name=%s, event=%v", p.config.Name, event)
+			}
+			p.respond(send.responses, &goxMessaging.Response{Err: err}, "error", "failed_after_produce")
+		case <-time.After(time.Duration(p.config.MessageTimeoutInMs) * time.Millisecond):
+			err = errors.New("kafka message produce timeout - not sure if this got delivered: name=%s", p.config.Name)
+			p.respond(send.responses, &goxMessaging.Response{Err: err}, "error", "timeout")
+		}
+		p.reportError(send.msg.Value, err)
+	}
+}
+
+func (p *kafkaProducer) respond(responses chan *goxMessaging.Response, response *goxMessaging.Response, status string, reason string) {
+	mode := "sync"
+	if p.config.Async {
+		mode = "async"
+	}
+	p.Metric().Tagged(map[string]string{"type": "kafka", "topic": p.config.Topic, "mode": mode, "status": status, "error": reason}).Counter("message_send").Inc(1)
+	responses <- response
+	close(responses)
+}
+
+// reportError gives a failed sync message to the error reporting channel, without waiting long for a slow reader
+func (p *kafkaProducer) reportError(payload []byte, err error) {
+	if p.errorReports == nil {
+		return
+	}
+	select {
+	case p.errorReports <- &goxMessaging.Response{RawPayload: payload, Err: errors.Wrap(err, "error in sending message over Kafka: %s", p.config.Topic)}:
+	case <-time.After(10 * time.Millisecond):
+		p.logger.Warn("failed to report a kafka error - nobody reads the error reporting channel, or it is slow", zap.String("topic", p.config.Topic))
+		p.Metric().Tagged(map[string]string{"type": "kafka", "topic": p.config.Topic, "mode": "sync", "status": "error", "error": "failed_to_report_error_after_produce"}).Counter("message_send").Inc(1)
+	}
+}
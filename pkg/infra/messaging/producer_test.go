@@ -0,0 +1,194 @@
+package consumers
+
+import (
+	"context"
+	"github.com/confluentinc/confluent-kafka-go/v2/kafka"
+	"github.com/devlibx/go-template-project/pkg/infra/requestid"
+	"github.com/devlibx/go-template-project/pkg/infra/shutdown"
+	"github.com/devlibx/gox-base/v2"
+	goxMessaging "github.com/devlibx/gox-messaging/v2"
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/zap"
+	"strconv"
+	"sync"
+	"testing"
+)
+
+// testProducer stands in for the gox-messaging kafka producer and the librdkafka producer it embeds
+type testProducer struct {
+	lock         sync.Mutex
+	produced     []*kafka.Message
+	flushed      int
+	calls        []string
+	err          error
+	errorReports chan *goxMessaging.Response
+}
+
+func (p *testProducer) GetErrorReport() (chan *goxMessaging.Response, bool, error) {
+	return p.errorReports, p.errorReports != nil, nil
+}
+
+func (p *testProducer) Send(ctx context.Context, message *goxMessaging.Message) chan *goxMessaging.Response {
+	panic("messages must be produced with the librdkafka producer")
+}
+
+func (p *testProducer) Stop() error {
+	p.lock.Lock()
+	defer p.lock.Unlock()
+	p.calls = append(p.calls, "stop")
+	return nil
+}
+
+func (p *testProducer) Produce(msg *kafka.Message, deliveryChan chan kafka.Event) error {
+	p.lock.Lock()
+	defer p.lock.Unlock()
+	p.produced = append(p.produced, msg)
+	if deliveryChan != nil {
+		delivered := *msg
+		delivered.TopicPartition.Error = p.err
+		deliveryChan <- &delivered
+	}
+	return nil
+}
+
+func (p *testProducer) Flush(timeoutMs int) int {
+	p.lock.Lock()
+	defer p.lock.Unlock()
+	p.flushed++
+	p.calls = append(p.calls, "flush")
+	return 0
+}
+
+func TestKafkaProducer(t *testing.T) {
+	inner := &testProducer{errorReports: make(chan *goxMessaging.Response, 1)}
+	config := goxMessaging.ProducerConfig{Name: "orders", Type: "kafka", Topic: "orders"}
+	config.SetupDefaults()
+	producer := newKafkaProducer(gox.NewCrossFunction(zap.NewNop()), inner, inner, config)
+
+	// The request id of the context is sent in the header
+	ctx := requestid.WithRequestId(context.Background(), "req-1")
+	response := <-producer.Send(ctx, &goxMessaging.Message{Key: "o1", Payload: map[string]string{"order_id": "o1"}})
+	assert.NoError(t, response.Err)
+	assert.Equal(t, "o1", string(inner.produced[0].Key))
+	assert.Equal(t, `{"order_id":"o1"}`, string(inner.produced[0].Value))
+	assert.Equal(t, "orders", *inner.produced[0].TopicPartition.Topic)
+	assert.Equal(t, []kafka.Header{{Key: requestid.Header, Value: []byte("req-1")}}, inner.produced[0].Headers)
+
+	// No request id, no header
+	response = <-producer.Send(context.Background(), &goxMessaging.Message{Key: "o2", Payload: "raw"})
+	assert.NoError(t, response.Err)
+	assert.Empty(t, inner.produced[1].Headers)
+
+	// A failed delivery is given back, and reported on the error reporting channel
+	inner.lock.Lock()
+	inner.err = kafka.NewError(kafka.ErrMsgTimedOut, "timed out", false)
+	inner.lock.Unlock()
+	response = <-producer.Send(ctx, &goxMessaging.Message{Key: "o3", Payload: "raw"})
+	assert.Error(t, response.Err)
+	report := <-inner.errorReports
+	assert.Error(t, report.Err)
+	assert.Equal(t, []byte("raw"), report.RawPayload)
+
+	// A done context is not sent
+	done, cancel := context.WithCancel(ctx)
+	cancel()
+	response = <-producer.Send(done, &goxMessaging.Message{Key: "o4", Payload: "raw"})
+	assert.ErrorIs(t, response.Err, context.Canceled)
+	assert.Len(t, inner.produced, 3)
+
+	// The producer is still flushed on shutdown, and nothing is sent after it is stopped
+	var f flusher = producer
+	f.Flush(100)
+	assert.Equal(t, 1, inner.flushed)
+	assert.NoError(t, producer.Stop())
+	response = <-producer.Send(ctx, &goxMessaging.Message{Key: "o5", Payload: "raw"})
+	assert.ErrorContains(t, response.Err, "producer is closed")
+	assert.Len(t, inner.produced, 3)
+	assert.Equal(t, []string{"flush", "stop"}, inner.calls)
+}
+
+func TestKafkaProducer_Concurrency(t *testing.T) {
+	inner := &testProducer{}
+	config := goxMessaging.ProducerConfig{Name: "orders", Type: "kafka", Topic: "orders", Concurrency: 1}
+	config.SetupDefaults()
+	producer := newKafkaProducer(gox.NewCrossFunction(zap.NewNop()), inner, inner, config)
+
+	// With one worker, sync messages are produced in the order they are sent
+	var responses []chan *goxMessaging.Response
+	for i := 0; i < 20; i++ {
+		responses = append(responses, producer.Send(context.Background(), &goxMessaging.Message{Key: strconv.Itoa(i), Payload: "raw"}))
+	}
+	for _, response := range responses {
+		assert.NoError(t, (<-response).Err)
+	}
+	for i, msg := range inner.produced {
+		assert.Equal(t, strconv.Itoa(i), string(msg.Key))
+	}
+
+	// Queued messages are sent before the producer is stopped
+	for i := 0; i < 5; i++ {
+		responses = append(responses, producer.Send(context.Background(), &goxMessaging.Message{Key: "late", Payload: "raw"}))
+	}
+	assert.NoError(t, producer.Stop())
+	assert.Len(t, inner.produced, 25)
+}
+
+func TestMessagingService_GetKafkaProducer(t *testing.T) {
+	inner := &testProducer{}
+	service := &messagingServiceImpl{
+		CrossFunction:   gox.NewCrossFunction(zap.NewNop()),
+		Factory:         &testFactory{producer: inner},
+		producerConfigs: map[string]goxMessaging.ProducerConfig{"orders": {Name: "orders", Type: "kafka", Topic: "orders"}},
+		producers:       map[string]*kafkaProducer{},
+	}
+
+	// The same producer is given for a name, so a Stop is seen by every caller
+	first, err := service.GetProducer("orders")
+	assert.NoError(t, err)
+	second, err := service.GetProducer("orders")
+	assert.NoError(t, err)
+	assert.Same(t, first, second)
+
+	assert.NoError(t, first.Stop())
+	response := <-second.Send(context.Background(), &goxMessaging.Message{Key: "o1", Payload: "raw"})
+	assert.ErrorContains(t, response.Err, "producer is closed")
+}
+
+func TestMessagingService_GetProducer(t *testing.T) {
+	service, err := NewMessagingFactory(gox.NewCrossFunction(zap.NewNop()))
+	assert.NoError(t, err)
+	assert.NoError(t, service.Start(goxMessaging.Configuration{Enabled: true, Producers: map[string]goxMessaging.ProducerConfig{
+		"dummy": {Type: "dummy", Topic: "orders", Enabled: true},
+	}}))
+
+	// A producer which is not kafka is given as it is
+	producer, err := service.GetProducer("dummy")
+	assert.NoError(t, err)
+	_, ok := producer.(*kafkaProducer)
+	assert.False(t, ok)
+}
+
+// testFactory gives the same producer for every name
+type testFactory struct {
+	goxMessaging.Factory
+	producer goxMessaging.Producer
+}
+
+func (f *testFactory) GetProducer(name string) (goxMessaging.Producer, error) {
+	return f.producer, nil
+}
+
+func TestNewMessagingShutdown_FlushesBeforeStop(t *testing.T) {
+	cf := gox.NewCrossFunction(zap.NewNop())
+	inner := &testProducer{}
+	config := goxMessaging.ProducerConfig{Name: "orders", Type: "kafka", Topic: "orders"}
+	config.SetupDefaults()
+	service := &testFactory{producer: newKafkaProducer(cf, inner, inner, config)}
+
+	coordinator := shutdown.NewCoordinator(cf, nil)
+	assert.NoError(t, NewMessagingShutdown(coordinator, &goxMessaging.Configuration{Enabled: true, Producers: map[string]goxMessaging.ProducerConfig{
+		"orders": {Type: "kafka", Topic: "orders", Enabled: true},
+	}}, service))
+	assert.True(t, coordinator.Shutdown(context.Background()).Complete())
+	assert.Equal(t, []string{"flush", "stop"}, inner.calls)
+}
@@ -0,0 +1,90 @@
+package pagination
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"github.com/devlibx/go-template-project/pkg/apperror"
+	"github.com/devlibx/go-template-project/pkg/infra/validation"
+	"time"
+)
+
+const (
+	// DefaultLimit is the page size if the request has no limit
+	DefaultLimit = 50
+
+	// MaxLimit is the largest page a request gets, a larger limit gives a page of MaxLimit
+	MaxLimit = 200
+)
+
+// Request is the page a list endpoint is asked for, read from the query with validation.Bind e.g.
+// GET /api/v1/user?limit=20&cursor=<next_cursor of the last page>
+type Request struct {
+	Cursor string `form:"cursor"`
+	Limit  int    `form:"limit" validate:"omitempty,min=1"`
+}
+
+// PageSize gives the limit of the request, DefaultLimit if it is not set and at most MaxLimit
+func (r Request) PageSize() int {
+	switch {
+	case r.Limit <= 0:
+		return DefaultLimit
+	case r.Limit > MaxLimit:
+		return MaxLimit
+	default:
+		return r.Limit
+	}
+}
+
+// Page is the standard response of a list endpoint. NextCursor is empty on the last page
+type Page[T any] struct {
+	Items      []T    `json:"items"`
+	NextCursor string `json:"next_cursor,omitempty"`
+}
+
+// Cursor is the key of the last item of a page - the next page has the items after it in (created_at, id) order
+type Cursor struct {
+	CreatedAt time.Time `json:"created_at"`
+	ID        string    `json:"id"`
+}
+
+// Encode gives the opaque cursor which is sent to clients
+func (c Cursor) Encode() string {
+	data, _ := json.Marshal(c)
+	return base64.RawURLEncoding.EncodeToString(data)
+}
+
+// Decode reads a cursor given by Encode. It gives nil for an empty cursor i.e. the first page, and a validation error
+// of the cursor query param if the cursor is not valid
+func Decode(cursor string) (*Cursor, error) {
+	if cursor == "" {
+		return nil, nil
+	}
+	c := &Cursor{}
+	data, err := base64.RawURLEncoding.DecodeString(cursor)
+	if err == nil {
+		err = json.Unmarshal(data, c)
+	}
+	if err != nil || c.ID == "" || c.CreatedAt.IsZero() {
+		return nil, apperror.InvalidArgument("validation_failed", "request is not valid").WithFields(apperror.FieldError{
+			Field:   "cursor",
+			In:      validation.InQuery,
+			Code:    "cursor",
+			Message: "must be the next_cursor of a page",
+		}).WithCause(err)
+	}
+	return c, nil
+}
+
+// NewPage builds the page from rows which were read with a limit of pageSize+1 - the extra row tells that there is a
+// next page, it is not in the page
+func NewPage[R any, T any](rows []R, pageSize int, item func(R) T, cursor func(R) Cursor) *Page[T] {
+	page := &Page[T]{Items: make([]T, 0, min(len(rows), pageSize))}
+	for i, row := range rows {
+		if i == pageSize {
+			page.NextCursor = cursor(rows[i-1]).Encode()
+			break
+		}
+		page.Items = append(page.Items, item(row))
+	}
+	return page
+}
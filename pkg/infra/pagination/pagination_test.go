@@ -0,0 +1,77 @@
+package pagination
+
+import (
+	"github.com/devlibx/go-template-project/pkg/apperror"
+	"github.com/devlibx/go-template-project/pkg/infra/validation"
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"testing"
+	"time"
+)
+
+func TestRequest_Bind(t *testing.T) {
+	bind := func(query string) (Request, error) {
+		c, _ := gin.CreateTestContext(httptest.NewRecorder())
+		c.Request = httptest.NewRequest(http.MethodGet, "/user?"+query, nil)
+		request := Request{}
+		return request, validation.Bind(c, &request)
+	}
+
+	request, err := bind("limit=20&cursor=abc")
+	assert.NoError(t, err)
+	assert.Equal(t, Request{Cursor: "abc", Limit: 20}, request)
+	assert.Equal(t, 20, request.PageSize())
+
+	request, err = bind("")
+	assert.NoError(t, err)
+	assert.Equal(t, DefaultLimit, request.PageSize())
+
+	request, err = bind("limit=500")
+	assert.NoError(t, err)
+	assert.Equal(t, MaxLimit, request.PageSize())
+
+	_, err = bind("limit=-1")
+	assert.Equal(t, "limit", apperror.From(err).Fields[0].Field)
+	assert.Equal(t, "min", apperror.From(err).Fields[0].Code)
+}
+
+func TestCursor(t *testing.T) {
+	cursor := Cursor{CreatedAt: time.Date(2024, 5, 1, 10, 0, 0, 0, time.UTC), ID: "o1"}
+	decoded, err := Decode(cursor.Encode())
+	assert.NoError(t, err)
+	assert.Equal(t, cursor, *decoded)
+
+	decoded, err = Decode("")
+	assert.NoError(t, err)
+	assert.Nil(t, decoded)
+
+	for _, invalid := range []string{"not a cursor", Cursor{ID: "o1"}.Encode(), Cursor{CreatedAt: time.Now()}.Encode()} {
+		_, err = Decode(invalid)
+		assert.Equal(t, apperror.KindInvalidArgument, apperror.KindOf(err))
+		assert.Equal(t, []apperror.FieldError{{Field: "cursor", In: validation.InQuery, Code: "cursor", Message: "must be the next_cursor of a page"}}, apperror.From(err).Fields)
+	}
+}
+
+func TestNewPage(t *testing.T) {
+	created := time.Date(2024, 5, 1, 10, 0, 0, 0, time.UTC)
+	item := func(i int) string { return strconv.Itoa(i) }
+	cursor := func(i int) Cursor { return Cursor{CreatedAt: created, ID: strconv.Itoa(i)} }
+
+	// The extra row is not in the page, the cursor is the last item of the page
+	page := NewPage([]int{3, 2, 1}, 2, item, cursor)
+	assert.Equal(t, []string{"3", "2"}, page.Items)
+	next, err := Decode(page.NextCursor)
+	assert.NoError(t, err)
+	assert.Equal(t, "2", next.ID)
+
+	// The last page
+	page = NewPage([]int{3, 2}, 2, item, cursor)
+	assert.Equal(t, []string{"3", "2"}, page.Items)
+	assert.Empty(t, page.NextCursor)
+
+	page = NewPage([]int{}, 2, item, cursor)
+	assert.Equal(t, []string{}, page.Items)
+}
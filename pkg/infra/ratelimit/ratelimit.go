@@ -0,0 +1,233 @@
+package ratelimit
+
+import (
+	"github.com/devlibx/go-template-project/pkg/apperror"
+	"github.com/devlibx/go-template-project/pkg/infra/auth"
+	"github.com/devlibx/go-template-project/pkg/infra/router"
+	"github.com/devlibx/gox-base/v2"
+	goxBaseConfig "github.com/devlibx/gox-base/v2/config"
+	"github.com/gin-gonic/gin"
+	"golang.org/x/time/rate"
+	"math"
+	"strconv"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// Keys of a rule - each key value gets its own token bucket
+const (
+	// KeyClient limits each client, the id comes from client or bearer auth. A caller without an identity is limited by IP
+	KeyClient = "client"
+
+	// KeyIP limits each caller IP
+	KeyIP = "ip"
+
+	// KeyRoute limits all callers of a route together
+	KeyRoute = "route"
+)
+
+// Keys is the list of all rule keys
+var Keys = []string{KeyClient, KeyIP, KeyRoute}
+
+// Config declares the rate limit rules, a request must pass all rules which apply to it
+type Config struct {
+	Enabled bool   `yaml:"enabled"`
+	Rules   []Rule `yaml:"rules"`
+}
+
+// Rule is a token bucket of burst requests which refills at requests_per_second
+type Rule struct {
+	Name string `yaml:"name"`
+	Key  string `yaml:"key"`
+
+	// Routes are route templates without the app name prefix e.g. /api/v1/post/:postId. Empty means all routes
+	Routes []string `yaml:"routes"`
+
+	// Clients limits the rule to these client ids e.g. to give one client a different limit. Empty means all callers
+	Clients []string `yaml:"clients"`
+
+	RequestsPerSecond float64 `yaml:"requests_per_second"`
+	Burst             int     `yaml:"burst"`
+}
+
+// IsKey returns true if the key is a known rule key
+func IsKey(key string) bool {
+	for _, k := range Keys {
+		if k == key {
+			return true
+		}
+	}
+	return false
+}
+
+// Limiter enforces the rules as gin middleware. It runs after auth, so the client id is known
+type Limiter struct {
+	gox.CrossFunction
+	routes router.RouteTemplates
+	rules  atomic.Pointer[[]*rule]
+}
+
+func NewLimiter(cf gox.CrossFunction, config *Config, app *goxBaseConfig.App) *Limiter {
+	l := &Limiter{CrossFunction: cf, routes: router.NewRouteTemplates(app.AppName)}
+	l.Update(config)
+	return l
+}
+
+// Update replaces the rules e.g. on config reload. All buckets start full again
+func (l *Limiter) Update(config *Config) {
+	var rules []*rule
+	if config != nil && config.Enabled {
+		for _, r := range config.Rules {
+			rules = append(rules, newRule(r))
+		}
+	}
+	l.rules.Store(&rules)
+}
+
+// Middleware rejects a request with 429 if a rule has no token left - the tokens taken from the other rules are given
+// back then. The X-RateLimit-* headers are from the rule with the fewest remaining tokens
+func (l *Limiter) Middleware() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		rules := *l.rules.Load()
+		if len(rules) == 0 {
+			c.Next()
+			return
+		}
+
+		now := time.Now()
+		route := l.routes.Of(c)
+		clientID := auth.CallerID(c)
+
+		var tightest *decision
+		var taken []*rate.Reservation
+		for _, r := range rules {
+			if !r.applies(route, clientID) {
+				continue
+			}
+			d, reservation := r.take(r.key(c, route, clientID), now)
+			if !d.allowed {
+				for _, t := range taken {
+					t.CancelAt(now)
+				}
+				d.setHeaders(c)
+				c.Header("Retry-After", strconv.Itoa(int(math.Ceil(d.retryAfter.Seconds()))))
+				l.Metric().Tagged(map[string]string{"rule": r.Name, "route": route}).Counter("rate_limited").Inc(1)
+				apperror.Abort(l, c, apperror.ResourceExhausted("rate_limited", "rate limit exceeded"))
+				return
+			}
+			taken = append(taken, reservation)
+			if tightest == nil || d.remaining < tightest.remaining {
+				tightest = &d
+			}
+		}
+		if tightest != nil {
+			tightest.setHeaders(c)
+		}
+		c.Next()
+	}
+}
+
+type decision struct {
+	allowed    bool
+	limit      int
+	remaining  int
+	reset      time.Duration
+	retryAfter time.Duration
+}
+
+func (d decision) setHeaders(c *gin.Context) {
+	c.Header("X-RateLimit-Limit", strconv.Itoa(d.limit))
+	c.Header("X-RateLimit-Remaining", strconv.Itoa(d.remaining))
+	c.Header("X-RateLimit-Reset", strconv.Itoa(int(math.Ceil(d.reset.Seconds()))))
+}
+
+type bucket struct {
+	limiter  *rate.Limiter
+	lastSeen time.Time
+}
+
+type rule struct {
+	Rule
+	routes  map[string]bool
+	clients map[string]bool
+
+	// idleTimeout is the time in which an empty bucket is full again - an idle bucket is dropped after it, which is
+	// same as keeping a full bucket
+	idleTimeout time.Duration
+
+	lock      sync.Mutex
+	buckets   map[string]*bucket
+	lastSweep time.Time
+}
+
+func newRule(r Rule) *rule {
+	out := &rule{Rule: r, routes: toSet(r.Routes), clients: toSet(r.Clients), buckets: map[string]*bucket{}, lastSweep: time.Now()}
+	out.idleTimeout = time.Duration(float64(r.Burst) / r.RequestsPerSecond * float64(time.Second))
+	if out.idleTimeout < time.Minute {
+		out.idleTimeout = time.Minute
+	}
+	return out
+}
+
+func (r *rule) applies(route string, clientID string) bool {
+	if len(r.routes) > 0 && !r.routes[route] {
+		return false
+	}
+	return len(r.clients) == 0 || r.clients[clientID]
+}
+
+func (r *rule) key(c *gin.Context, route string, clientID string) string {
+	switch r.Key {
+	case KeyClient:
+		if clientID != "" {
+			return "client:" + clientID
+		}
+		return "ip:" + c.ClientIP()
+	case KeyRoute:
+		return "route:" + c.Request.Method + " " + route
+	default:
+		return "ip:" + c.ClientIP()
+	}
+}
+
+func (r *rule) take(key string, now time.Time) (decision, *rate.Reservation) {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+
+	if now.Sub(r.lastSweep) > time.Minute {
+		for k, b := range r.buckets {
+			if now.Sub(b.lastSeen) > r.idleTimeout {
+				delete(r.buckets, k)
+			}
+		}
+		r.lastSweep = now
+	}
+
+	b, ok := r.buckets[key]
+	if !ok {
+		b = &bucket{limiter: rate.NewLimiter(rate.Limit(r.RequestsPerSecond), r.Burst)}
+		r.buckets[key] = b
+	}
+	b.lastSeen = now
+
+	d := decision{allowed: true, limit: r.Burst}
+	reservation := b.limiter.ReserveN(now, 1)
+	if delay := reservation.DelayFrom(now); !reservation.OK() || delay > 0 {
+		reservation.CancelAt(now)
+		d.allowed, d.retryAfter = false, delay
+	}
+
+	tokens := b.limiter.TokensAt(now)
+	d.remaining = int(math.Max(0, math.Floor(tokens)))
+	d.reset = time.Duration((float64(r.Burst) - tokens) / r.RequestsPerSecond * float64(time.Second))
+	return d, reservation
+}
+
+func toSet(values []string) map[string]bool {
+	set := map[string]bool{}
+	for _, v := range values {
+		set[v] = true
+	}
+	return set
+}
@@ -0,0 +1,126 @@
+package ratelimit
+
+import (
+	"github.com/devlibx/go-template-project/pkg/infra/auth"
+	"github.com/devlibx/gox-base/v2"
+	goxBaseConfig "github.com/devlibx/gox-base/v2/config"
+	"github.com/devlibx/gox-base/v2/metrics"
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+)
+
+// testScope counts the rate_limited counter by rule
+type testScope struct {
+	metrics.Scope
+	tags   map[string]string
+	lock   *sync.Mutex
+	counts map[string]int64
+}
+
+func (s *testScope) Tagged(tags map[string]string) metrics.Scope {
+	return &testScope{Scope: s.Scope, tags: tags, lock: s.lock, counts: s.counts}
+}
+
+func (s *testScope) Counter(name string) metrics.Counter {
+	return testCounter{scope: s, name: name}
+}
+
+type testCounter struct {
+	scope *testScope
+	name  string
+}
+
+func (c testCounter) Inc(delta int64) {
+	c.scope.lock.Lock()
+	defer c.scope.lock.Unlock()
+	c.scope.counts[c.name+"."+c.scope.tags["rule"]] += delta
+}
+
+func newTestLimiter(config *Config) (*Limiter, *testScope) {
+	scope := &testScope{Scope: metrics.NoOpMetric(), lock: &sync.Mutex{}, counts: map[string]int64{}}
+	return NewLimiter(gox.NewCrossFunction(scope), config, &goxBaseConfig.App{AppName: "app"}), scope
+}
+
+func newTestEngine(limiter *Limiter) *gin.Engine {
+	engine := gin.New()
+	group := engine.Group("/app")
+	group.Use(func(c *gin.Context) {
+		if id := c.GetHeader("client"); id != "" {
+			c.Request = c.Request.WithContext(auth.WithClient(c.Request.Context(), &auth.Client{ID: id}))
+		}
+	})
+	group.Use(limiter.Middleware())
+	group.GET("/api/v1/post/:postId", func(c *gin.Context) { c.Status(http.StatusOK) })
+	group.GET("/api/v1/other", func(c *gin.Context) { c.Status(http.StatusOK) })
+	return engine
+}
+
+func call(engine *gin.Engine, path string, client string, ip string) *httptest.ResponseRecorder {
+	request := httptest.NewRequest(http.MethodGet, path, nil)
+	request.RemoteAddr = ip + ":1234"
+	if client != "" {
+		request.Header.Set("client", client)
+	}
+	recorder := httptest.NewRecorder()
+	engine.ServeHTTP(recorder, request)
+	return recorder
+}
+
+func TestLimiter_Client(t *testing.T) {
+	limiter, scope := newTestLimiter(&Config{Enabled: true, Rules: []Rule{
+		{Name: "per_client", Key: KeyClient, Routes: []string{"/api/v1/post/:postId"}, RequestsPerSecond: 0.001, Burst: 2},
+	}})
+	engine := newTestEngine(limiter)
+
+	response := call(engine, "/app/api/v1/post/1", "c1", "10.0.0.1")
+	assert.Equal(t, http.StatusOK, response.Code)
+	assert.Equal(t, "2", response.Header().Get("X-RateLimit-Limit"))
+	assert.Equal(t, "1", response.Header().Get("X-RateLimit-Remaining"))
+
+	// Another post id is the same route template
+	assert.Equal(t, http.StatusOK, call(engine, "/app/api/v1/post/2", "c1", "10.0.0.1").Code)
+	response = call(engine, "/app/api/v1/post/3", "c1", "10.0.0.1")
+	assert.Equal(t, http.StatusTooManyRequests, response.Code)
+	assert.Equal(t, "0", response.Header().Get("X-RateLimit-Remaining"))
+	assert.NotEmpty(t, response.Header().Get("Retry-After"))
+	assert.NotEmpty(t, response.Header().Get("X-RateLimit-Reset"))
+	assert.Equal(t, int64(1), scope.counts["rate_limited.per_client"])
+
+	// Other clients and routes have their own limits
+	assert.Equal(t, http.StatusOK, call(engine, "/app/api/v1/post/1", "c2", "10.0.0.1").Code)
+	response = call(engine, "/app/api/v1/other", "c1", "10.0.0.1")
+	assert.Equal(t, http.StatusOK, response.Code)
+	assert.Empty(t, response.Header().Get("X-RateLimit-Limit"))
+
+	// Without a client the IP is used
+	assert.Equal(t, http.StatusOK, call(engine, "/app/api/v1/post/1", "", "10.0.0.2").Code)
+	assert.Equal(t, http.StatusOK, call(engine, "/app/api/v1/post/1", "", "10.0.0.2").Code)
+	assert.Equal(t, http.StatusTooManyRequests, call(engine, "/app/api/v1/post/1", "", "10.0.0.2").Code)
+	assert.Equal(t, http.StatusOK, call(engine, "/app/api/v1/post/1", "", "10.0.0.3").Code)
+}
+
+func TestLimiter_RouteAndClientOverride(t *testing.T) {
+	limiter, scope := newTestLimiter(&Config{Enabled: true, Rules: []Rule{
+		{Name: "route", Key: KeyRoute, RequestsPerSecond: 0.001, Burst: 3},
+		{Name: "batch", Key: KeyClient, Clients: []string{"batch"}, RequestsPerSecond: 0.001, Burst: 1},
+	}})
+	engine := newTestEngine(limiter)
+
+	// The batch client has a smaller limit, and all calls count for the route
+	assert.Equal(t, http.StatusOK, call(engine, "/app/api/v1/post/1", "batch", "10.0.0.1").Code)
+	assert.Equal(t, http.StatusTooManyRequests, call(engine, "/app/api/v1/post/1", "batch", "10.0.0.1").Code)
+	assert.Equal(t, int64(1), scope.counts["rate_limited.batch"])
+
+	assert.Equal(t, http.StatusOK, call(engine, "/app/api/v1/post/1", "c1", "10.0.0.1").Code)
+	assert.Equal(t, http.StatusOK, call(engine, "/app/api/v1/post/1", "c2", "10.0.0.2").Code)
+	assert.Equal(t, http.StatusTooManyRequests, call(engine, "/app/api/v1/post/1", "c3", "10.0.0.3").Code)
+	assert.Equal(t, int64(1), scope.counts["rate_limited.route"])
+
+	// A config reload replaces the rules
+	limiter.Update(&Config{Enabled: false})
+	assert.Equal(t, http.StatusOK, call(engine, "/app/api/v1/post/1", "c3", "10.0.0.3").Code)
+}
@@ -0,0 +1,38 @@
+package requestid
+
+import (
+	goxHttpApi "github.com/devlibx/gox-http/v4/api"
+	goxHttp "github.com/devlibx/gox-http/v4/command"
+	"github.com/go-resty/resty/v2"
+	"log/slog"
+)
+
+// OnBeforeRequest is a resty middleware which sends the request id of the request context in the X-Request-ID header.
+// A header set by the caller is kept
+func OnBeforeRequest(_ *resty.Client, r *resty.Request) error {
+	if r.Header.Get(Header) != "" {
+		return nil
+	}
+	if id := FromContext(r.Context()); id != "" {
+		r.SetHeader(Header, id)
+	}
+	return nil
+}
+
+// SetupGoxHttp adds OnBeforeRequest to all apis of the gox-http config
+func SetupGoxHttp(goxHttpCtx goxHttpApi.GoxHttpContext, config *goxHttp.Config) {
+	if config == nil {
+		return
+	}
+	for name := range config.Apis {
+		SetupGoxHttpApi(goxHttpCtx, name)
+	}
+}
+
+// SetupGoxHttpApi adds OnBeforeRequest to one api. It must be called again after goxHttpCtx.ReloadApi(), which creates
+// a new client for the api
+func SetupGoxHttpApi(goxHttpCtx goxHttpApi.GoxHttpContext, name string) {
+	if !goxHttpApi.SetupOnBeforeRequestOverRestyClientFromGoxHttpCtx(goxHttpCtx, name, OnBeforeRequest) {
+		slog.Warn("request id is not sent with http api - it does not use a resty client", "api", name)
+	}
+}
@@ -0,0 +1,86 @@
+package requestid
+
+import (
+	"context"
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"go.uber.org/zap"
+	"gopkg.in/DataDog/dd-trace-go.v1/ddtrace/tracer"
+)
+
+// Header is the header with the id of a request. It is read from incoming requests, sent back in the response and
+// sent with outbound gox-http calls
+const Header = "X-Request-ID"
+
+// LogField is the name of the log field with the request id
+const LogField = "requestId"
+
+// maxLength of a request id which is accepted from the caller, a longer id is replaced
+const maxLength = 128
+
+type requestIdContextKey struct{}
+
+// WithRequestId puts the request id into the context
+func WithRequestId(ctx context.Context, id string) context.Context {
+	return context.WithValue(ctx, requestIdContextKey{}, id)
+}
+
+// FromContext gives the request id of the context, or "" if there is none
+func FromContext(ctx context.Context) string {
+	if ctx == nil {
+		return ""
+	}
+	if c, ok := ctx.(*gin.Context); ok && c.Request != nil {
+		ctx = c.Request.Context()
+	}
+	id, _ := ctx.Value(requestIdContextKey{}).(string)
+	return id
+}
+
+// Logger gives the logger with the request id of the context on every log line
+func Logger(ctx context.Context, logger *zap.Logger) *zap.Logger {
+	if id := FromContext(ctx); id != "" {
+		return logger.With(zap.String(LogField, id))
+	}
+	return logger
+}
+
+// New generates a request id
+func New() string {
+	return uuid.NewString()
+}
+
+// Middleware takes the request id from the X-Request-ID header, or generates one if the header is missing or not
+// valid. The id is put into the request context and sent back in the response header
+func Middleware() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		id := c.GetHeader(Header)
+		if !valid(id) {
+			id = New()
+		}
+
+		ctx := WithRequestId(c.Request.Context(), id)
+		if span, ok := tracer.SpanFromContext(ctx); ok {
+			span.SetTag("request_id", id)
+		}
+		c.Request = c.Request.WithContext(ctx)
+		c.Header(Header, id)
+		c.Next()
+	}
+}
+
+// valid accepts ids of letters, digits and "-_.:" - the id is logged and sent to other systems, so anything else is
+// replaced
+func valid(id string) bool {
+	if id == "" || len(id) > maxLength {
+		return false
+	}
+	for _, r := range id {
+		switch {
+		case r >= 'a' && r <= 'z', r >= 'A' && r <= 'Z', r >= '0' && r <= '9', r == '-', r == '_', r == '.', r == ':':
+		default:
+			return false
+		}
+	}
+	return true
+}
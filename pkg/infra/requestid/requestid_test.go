@@ -0,0 +1,78 @@
+package requestid
+
+import (
+	"context"
+	"github.com/gin-gonic/gin"
+	"github.com/go-resty/resty/v2"
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+	"go.uber.org/zap/zaptest/observer"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestMiddleware(t *testing.T) {
+	var seen string
+	engine := gin.New()
+	engine.Use(Middleware())
+	engine.GET("/", func(c *gin.Context) {
+		seen = FromContext(c)
+		c.Status(http.StatusOK)
+	})
+
+	call := func(id string) *httptest.ResponseRecorder {
+		request := httptest.NewRequest(http.MethodGet, "/", nil)
+		if id != "" {
+			request.Header.Set(Header, id)
+		}
+		recorder := httptest.NewRecorder()
+		engine.ServeHTTP(recorder, request)
+		return recorder
+	}
+
+	// The id of the caller is kept
+	response := call("abc-123")
+	assert.Equal(t, "abc-123", seen)
+	assert.Equal(t, "abc-123", response.Header().Get(Header))
+
+	// A missing or not valid id is generated
+	for _, id := range []string{"", "bad id", "bad\nid", strings.Repeat("a", maxLength+1)} {
+		response = call(id)
+		assert.Len(t, seen, 36, id)
+		assert.NotEqual(t, id, seen)
+		assert.Equal(t, seen, response.Header().Get(Header))
+	}
+}
+
+func TestLogger(t *testing.T) {
+	core, logs := observer.New(zapcore.DebugLevel)
+	logger := zap.New(core)
+
+	Logger(WithRequestId(context.Background(), "req-1"), logger).Info("with id")
+	Logger(context.Background(), logger).Info("without id")
+
+	entries := logs.AllUntimed()
+	assert.Equal(t, "req-1", entries[0].ContextMap()[LogField])
+	assert.NotContains(t, entries[1].ContextMap(), LogField)
+	assert.Equal(t, "", FromContext(nil))
+}
+
+func TestOnBeforeRequest(t *testing.T) {
+	var received []string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		received = append(received, r.Header.Get(Header))
+	}))
+	defer server.Close()
+
+	client := resty.New().OnBeforeRequest(OnBeforeRequest)
+	_, err := client.R().SetContext(WithRequestId(context.Background(), "req-1")).Get(server.URL)
+	assert.NoError(t, err)
+	_, err = client.R().SetContext(WithRequestId(context.Background(), "req-1")).SetHeader(Header, "own").Get(server.URL)
+	assert.NoError(t, err)
+	_, err = client.R().Get(server.URL)
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"req-1", "own", ""}, received)
+}
@@ -0,0 +1,134 @@
+package router
+
+import (
+	"github.com/devlibx/gox-base/v2/errors"
+	"github.com/gin-gonic/gin"
+	"go.uber.org/fx"
+	"sort"
+	"strings"
+)
+
+// fx value groups used by Mount
+const (
+	RouteRegistrarGroup = "route_registrars"
+	AuthenticatorGroup  = "authenticators"
+)
+
+// API versions - a route group is mounted at /<app name>/api/<version>/<path>
+const (
+	V1 = "v1"
+	V2 = "v2"
+)
+
+var versions = map[string]bool{V1: true, V2: true}
+
+// AuthNone is the auth scheme of a public route group
+const AuthNone = ""
+
+// Auth is the auth requirement of a route group
+type Auth struct {
+	// Scheme selects the Authenticator used for the group. AuthNone means the group is public
+	Scheme string
+
+	// Clients which may call the group. Empty means any authenticated client
+	Clients []string
+}
+
+// Group tells where and how the routes of a RouteRegistrar are mounted
+type Group struct {
+	Version    string
+	Path       string
+	Auth       Auth
+	Middleware []gin.HandlerFunc
+}
+
+// RouteTemplates gives the route template of a request without the app name prefix e.g. /api/v1/post/:postId - the way
+// route level config (rate limits, access log, capture, idempotency) names a route
+type RouteTemplates struct {
+	prefix string
+}
+
+// NewRouteTemplates gives the RouteTemplates of the routes mounted under the app name
+func NewRouteTemplates(appName string) RouteTemplates {
+	return RouteTemplates{prefix: "/" + strings.Trim(appName, "/")}
+}
+
+// Of gives the route template of the request, "" if no route matched
+func (r RouteTemplates) Of(c *gin.Context) string {
+	return strings.TrimPrefix(c.FullPath(), r.prefix)
+}
+
+// RouteRegistrar is implemented by a handler module. It declares its route group, and adds its routes to it.
+// Provide it with ProvideRouteRegistrar, the server mounts all of them
+type RouteRegistrar interface {
+	RouteGroup() Group
+	RegisterRoutes(router gin.IRouter)
+}
+
+// Authenticator gives the middleware which checks an auth scheme e.g. client id and token
+type Authenticator interface {
+	Scheme() string
+	Middleware(auth Auth) gin.HandlerFunc
+}
+
+// ProvideRouteRegistrar provides constructors of RouteRegistrar into the RouteRegistrarGroup
+func ProvideRouteRegistrar(constructors ...interface{}) fx.Option {
+	return provideInto(RouteRegistrarGroup, new(RouteRegistrar), constructors)
+}
+
+// ProvideAuthenticator provides constructors of Authenticator into the AuthenticatorGroup
+func ProvideAuthenticator(constructors ...interface{}) fx.Option {
+	return provideInto(AuthenticatorGroup, new(Authenticator), constructors)
+}
+
+func provideInto(group string, as interface{}, constructors []interface{}) fx.Option {
+	options := make([]fx.Option, 0, len(constructors))
+	for _, constructor := range constructors {
+		options = append(options, fx.Provide(fx.Annotate(constructor, fx.As(as), fx.ResultTags(`group:"`+group+`"`))))
+	}
+	return fx.Options(options...)
+}
+
+// Mount adds the routes of all registrars under their version group. In each group the auth middleware runs first, then
+// the given middleware (e.g. rate limits which need the authenticated client), and then the middleware declared by the
+// group. It fails if a group uses an unknown version or an auth scheme without an Authenticator
+func Mount(parent gin.IRouter, registrars []RouteRegistrar, authenticators []Authenticator, middleware ...gin.HandlerFunc) error {
+	byScheme := map[string]Authenticator{}
+	for _, a := range authenticators {
+		byScheme[a.Scheme()] = a
+	}
+
+	// Mount in a fixed order, the order in an fx group is not defined
+	registrars = append([]RouteRegistrar{}, registrars...)
+	sort.SliceStable(registrars, func(i, j int) bool {
+		gi, gj := registrars[i].RouteGroup(), registrars[j].RouteGroup()
+		if gi.Version != gj.Version {
+			return gi.Version < gj.Version
+		}
+		return gi.Path < gj.Path
+	})
+
+	versionGroups := map[string]*gin.RouterGroup{}
+	for _, registrar := range registrars {
+		group := registrar.RouteGroup()
+		if !versions[group.Version] {
+			return errors.New("unknown api version: version=%s, path=%s", group.Version, group.Path)
+		}
+		if versionGroups[group.Version] == nil {
+			versionGroups[group.Version] = parent.Group("/api/" + group.Version)
+		}
+
+		routerGroup := versionGroups[group.Version].Group(group.Path)
+		if group.Auth.Scheme != AuthNone {
+			authenticator, ok := byScheme[group.Auth.Scheme]
+			if !ok {
+				return errors.New("no authenticator for auth scheme: scheme=%s, version=%s, path=%s", group.Auth.Scheme, group.Version, group.Path)
+			}
+			routerGroup.Use(authenticator.Middleware(group.Auth))
+		}
+		routerGroup.Use(middleware...)
+		routerGroup.Use(group.Middleware...)
+		registrar.RegisterRoutes(routerGroup)
+	}
+	return nil
+}
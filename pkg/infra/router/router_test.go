@@ -0,0 +1,113 @@
+package router
+
+import (
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/fx"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+type testRegistrar struct {
+	group Group
+	path  string
+}
+
+func (t *testRegistrar) RouteGroup() Group {
+	return t.group
+}
+
+func (t *testRegistrar) RegisterRoutes(router gin.IRouter) {
+	router.GET(t.path, func(c *gin.Context) {
+		c.String(http.StatusOK, c.GetString("trace"))
+	})
+}
+
+type testAuthenticator struct{}
+
+func (testAuthenticator) Scheme() string {
+	return "test"
+}
+
+func (testAuthenticator) Middleware(auth Auth) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if !strings.Contains(strings.Join(auth.Clients, ","), c.GetHeader("client")) {
+			c.AbortWithStatus(http.StatusForbidden)
+			return
+		}
+		c.Set("trace", "auth")
+	}
+}
+
+func trace(name string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		c.Set("trace", c.GetString("trace")+">"+name)
+	}
+}
+
+func serve(engine *gin.Engine, path string, client string) *httptest.ResponseRecorder {
+	request := httptest.NewRequest(http.MethodGet, path, nil)
+	request.Header.Set("client", client)
+	recorder := httptest.NewRecorder()
+	engine.ServeHTTP(recorder, request)
+	return recorder
+}
+
+func TestMount(t *testing.T) {
+	var registrars []RouteRegistrar
+	var authenticators []Authenticator
+	app := fx.New(
+		fx.NopLogger,
+		ProvideRouteRegistrar(
+			func() *testRegistrar {
+				return &testRegistrar{group: Group{Version: V1, Path: "/post", Middleware: []gin.HandlerFunc{trace("m1")}}, path: "/:id"}
+			},
+			func() *testRegistrar {
+				return &testRegistrar{group: Group{Version: V2, Path: "/post", Auth: Auth{Scheme: "test", Clients: []string{"c1"}}, Middleware: []gin.HandlerFunc{trace("m2")}}, path: "/:id"}
+			},
+		),
+		ProvideAuthenticator(func() testAuthenticator { return testAuthenticator{} }),
+		fx.Invoke(func(p struct {
+			fx.In
+			Registrars     []RouteRegistrar `group:"route_registrars"`
+			Authenticators []Authenticator  `group:"authenticators"`
+		}) {
+			registrars, authenticators = p.Registrars, p.Authenticators
+		}),
+	)
+	assert.NoError(t, app.Err())
+	assert.Len(t, registrars, 2)
+
+	engine := gin.New()
+	assert.NoError(t, Mount(engine.Group("/app"), registrars, authenticators, trace("common")))
+
+	assert.Equal(t, ">common>m1", serve(engine, "/app/api/v1/post/1", "").Body.String())
+
+	// Auth runs before the common middleware, and then the middleware of the group
+	assert.Equal(t, "auth>common>m2", serve(engine, "/app/api/v2/post/1", "c1").Body.String())
+	assert.Equal(t, http.StatusForbidden, serve(engine, "/app/api/v2/post/1", "c2").Code)
+}
+
+func TestMount_Errors(t *testing.T) {
+	err := Mount(gin.New(), []RouteRegistrar{&testRegistrar{group: Group{Version: "v9", Path: "/x"}, path: "/"}}, nil)
+	assert.Error(t, err)
+	assert.Contains(t, err.Error(), "unknown api version")
+
+	err = Mount(gin.New(), []RouteRegistrar{&testRegistrar{group: Group{Version: V1, Path: "/x", Auth: Auth{Scheme: "client"}}, path: "/"}}, nil)
+	assert.Error(t, err)
+	assert.Contains(t, err.Error(), "no authenticator")
+}
+
+func TestRouteTemplates(t *testing.T) {
+	for _, appName := range []string{"app", "/app/"} {
+		routes := NewRouteTemplates(appName)
+		engine := gin.New()
+		engine.Group("/app").GET("/api/v1/post/:postId", func(c *gin.Context) { c.String(http.StatusOK, routes.Of(c)) })
+		engine.NoRoute(func(c *gin.Context) { c.String(http.StatusNotFound, routes.Of(c)) })
+
+		assert.Equal(t, "/api/v1/post/:postId", serve(engine, "/app/api/v1/post/1", "").Body.String())
+		assert.Equal(t, "", serve(engine, "/app/api/v1/other", "").Body.String())
+	}
+}
@@ -0,0 +1,225 @@
+package shutdown
+
+import (
+	"context"
+	"github.com/devlibx/gox-base/v2"
+	"github.com/devlibx/gox-base/v2/errors"
+	"go.uber.org/fx"
+	"go.uber.org/zap"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Shutdown stages - they run in the order given in Stages
+const (
+	StageReadiness = "readiness"
+	StageHttpDrain = "http_drain"
+	StageConsumers = "consumers"
+	StageProducers = "producers"
+	StageWorkflows = "workflows"
+	StageDatabase  = "database"
+)
+
+// Stages is the order in which shutdown stages run
+var Stages = []string{StageReadiness, StageHttpDrain, StageConsumers, StageProducers, StageWorkflows, StageDatabase}
+
+const defaultStageTimeoutMs = 5000
+
+// Config is the configuration of graceful shutdown
+type Config struct {
+	// ReadinessGraceMs is the time to wait after readiness is off, so load balancers stop sending new requests
+	// before the http server is drained
+	ReadinessGraceMs int `yaml:"readiness_grace_ms"`
+
+	// StageTimeoutsMs is the max time of each stage, by stage name. A stage which is not given gets 5000ms
+	StageTimeoutsMs map[string]int `yaml:"stage_timeouts_ms"`
+}
+
+func (c *Config) SetupDefaults() {
+	if c.StageTimeoutsMs == nil {
+		c.StageTimeoutsMs = map[string]int{}
+	}
+	for _, stage := range Stages {
+		if c.StageTimeoutsMs[stage] <= 0 {
+			c.StageTimeoutsMs[stage] = defaultStageTimeoutMs
+		}
+	}
+}
+
+// Timeout gives the max time of a stage
+func (c *Config) Timeout(stage string) time.Duration {
+	if ms, ok := c.StageTimeoutsMs[stage]; ok && ms > 0 {
+		return time.Duration(ms) * time.Millisecond
+	}
+	return defaultStageTimeoutMs * time.Millisecond
+}
+
+// IsStage is true if the name is one of Stages
+func IsStage(name string) bool {
+	for _, stage := range Stages {
+		if stage == name {
+			return true
+		}
+	}
+	return false
+}
+
+// StageReport tells what happened in a stage. Unfinished are the tasks which were still running when the stage timed
+// out - shutdown does not wait for them
+type StageReport struct {
+	Name       string
+	Duration   time.Duration
+	Done       []string
+	Failed     map[string]string
+	Unfinished []string
+}
+
+// Report is the result of a shutdown
+type Report struct {
+	Stages []*StageReport
+}
+
+// Complete is true if every task of every stage finished without error
+func (r *Report) Complete() bool {
+	for _, stage := range r.Stages {
+		if len(stage.Failed) > 0 || len(stage.Unfinished) > 0 {
+			return false
+		}
+	}
+	return true
+}
+
+type task struct {
+	name string
+	run  func(ctx context.Context) error
+}
+
+// Coordinator runs the registered shutdown tasks stage by stage. Tasks of a stage run concurrently, and the next
+// stage starts once all tasks are done or the stage timed out
+type Coordinator struct {
+	config *Config
+	logger *zap.Logger
+
+	lock   sync.Mutex
+	tasks  map[string][]task
+	once   sync.Once
+	report *Report
+}
+
+func NewCoordinator(cf gox.CrossFunction, config *Config) *Coordinator {
+	if config == nil {
+		config = &Config{}
+	}
+	config.SetupDefaults()
+	return &Coordinator{
+		config: config,
+		logger: cf.Logger().Named("shutdown"),
+		tasks:  map[string][]task{},
+	}
+}
+
+// Register adds a task to a stage. The context given to the task is done when the stage times out. The name of a task
+// is unique in its stage, the stage report tells the tasks by name
+func (c *Coordinator) Register(stage string, name string, run func(ctx context.Context) error) error {
+	if !IsStage(stage) {
+		return errors.New("unknown shutdown stage: stage=%s, name=%s", stage, name)
+	}
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	for _, t := range c.tasks[stage] {
+		if t.name == name {
+			return errors.New("shutdown task is already registered: stage=%s, name=%s", stage, name)
+		}
+	}
+	c.tasks[stage] = append(c.tasks[stage], task{name: name, run: run})
+	return nil
+}
+
+// Shutdown runs all stages. It is done only once, a second call gives the report of the first call
+func (c *Coordinator) Shutdown(ctx context.Context) *Report {
+	c.once.Do(func() {
+		c.report = &Report{}
+		for _, stage := range Stages {
+			c.lock.Lock()
+			tasks := append([]task{}, c.tasks[stage]...)
+			c.lock.Unlock()
+
+			stageReport := c.runStage(ctx, stage, tasks)
+			c.report.Stages = append(c.report.Stages, stageReport)
+			c.log(stageReport)
+		}
+	})
+	return c.report
+}
+
+func (c *Coordinator) runStage(ctx context.Context, stage string, tasks []task) *StageReport {
+	ctx, cancel := context.WithTimeout(ctx, c.config.Timeout(stage))
+	defer cancel()
+
+	start := time.Now()
+	type result struct {
+		name string
+		err  error
+	}
+	results := make(chan result, len(tasks))
+	for _, t := range tasks {
+		go func(t task) {
+			results <- result{name: t.name, err: t.run(ctx)}
+		}(t)
+	}
+
+	report := &StageReport{Name: stage, Failed: map[string]string{}}
+	pending := map[string]bool{}
+	for _, t := range tasks {
+		pending[t.name] = true
+	}
+	for len(pending) > 0 {
+		select {
+		case r := <-results:
+			delete(pending, r.name)
+			if r.err != nil {
+				report.Failed[r.name] = r.err.Error()
+			} else {
+				report.Done = append(report.Done, r.name)
+			}
+		case <-ctx.Done():
+			for name := range pending {
+				report.Unfinished = append(report.Unfinished, name)
+			}
+			pending = nil
+		}
+	}
+	sort.Strings(report.Done)
+	sort.Strings(report.Unfinished)
+	report.Duration = time.Since(start)
+	return report
+}
+
+func (c *Coordinator) log(report *StageReport) {
+	fields := []zap.Field{
+		zap.String("stage", report.Name),
+		zap.Int64("duration_ms", report.Duration.Milliseconds()),
+		zap.String("done", strings.Join(report.Done, ",")),
+	}
+	if len(report.Failed) == 0 && len(report.Unfinished) == 0 {
+		c.logger.Info("shutdown stage done", fields...)
+		return
+	}
+	fields = append(fields, zap.Any("failed", report.Failed), zap.String("unfinished", strings.Join(report.Unfinished, ",")))
+	c.logger.Warn("shutdown stage did not finish cleanly", fields...)
+}
+
+// NewCoordinatorLifecycle runs the shutdown when the app is stopped. It must be invoked after all other lifecycle
+// hooks, so it is stopped first
+func NewCoordinatorLifecycle(lifecycle fx.Lifecycle, coordinator *Coordinator) {
+	lifecycle.Append(fx.Hook{
+		OnStop: func(ctx context.Context) error {
+			if report := coordinator.Shutdown(ctx); !report.Complete() {
+				coordinator.logger.Warn("shutdown did not finish cleanly, see the stage logs above")
+			}
+			return nil
+		},
+	})
+}
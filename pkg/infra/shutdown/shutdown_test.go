@@ -0,0 +1,73 @@
+package shutdown
+
+import (
+	"context"
+	"github.com/devlibx/gox-base/v2"
+	"github.com/devlibx/gox-base/v2/errors"
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/zap"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestCoordinator_Shutdown(t *testing.T) {
+	coordinator := NewCoordinator(gox.NewCrossFunction(zap.NewNop()), &Config{
+		StageTimeoutsMs: map[string]int{StageConsumers: 50},
+	})
+
+	lock := sync.Mutex{}
+	var order []string
+	record := func(name string) func(ctx context.Context) error {
+		return func(ctx context.Context) error {
+			lock.Lock()
+			defer lock.Unlock()
+			order = append(order, name)
+			return nil
+		}
+	}
+
+	// Registered out of order - they run in the order of the stages
+	assert.NoError(t, coordinator.Register(StageDatabase, "db", record("db")))
+	assert.NoError(t, coordinator.Register(StageReadiness, "readiness", record("readiness")))
+	assert.NoError(t, coordinator.Register(StageHttpDrain, "http", record("http")))
+	assert.NoError(t, coordinator.Register(StageProducers, "producer.metrics", func(ctx context.Context) error {
+		return errors.New("messages are not flushed")
+	}))
+	assert.NoError(t, coordinator.Register(StageConsumers, "consumer.slow", func(ctx context.Context) error {
+		time.Sleep(time.Second)
+		return nil
+	}))
+	assert.NoError(t, coordinator.Register(StageConsumers, "consumer.fast", record("consumer.fast")))
+	assert.Error(t, coordinator.Register("unknown", "x", record("x")))
+
+	// A name is unique in its stage, the report tells the tasks by name
+	assert.ErrorContains(t, coordinator.Register(StageConsumers, "consumer.fast", record("consumer.fast")), "already registered")
+
+	start := time.Now()
+	report := coordinator.Shutdown(context.Background())
+	assert.Less(t, time.Since(start), 500*time.Millisecond)
+
+	assert.Equal(t, []string{"readiness", "http", "consumer.fast", "db"}, order)
+	assert.False(t, report.Complete())
+	assert.Len(t, report.Stages, len(Stages))
+
+	consumers := report.Stages[2]
+	assert.Equal(t, StageConsumers, consumers.Name)
+	assert.Equal(t, []string{"consumer.fast"}, consumers.Done)
+	assert.Equal(t, []string{"consumer.slow"}, consumers.Unfinished)
+
+	producers := report.Stages[3]
+	assert.Equal(t, "messages are not flushed", producers.Failed["producer.metrics"])
+
+	// Shutdown is done only once
+	assert.Same(t, report, coordinator.Shutdown(context.Background()))
+	assert.Len(t, order, 4)
+}
+
+func TestConfig_SetupDefaults(t *testing.T) {
+	config := &Config{StageTimeoutsMs: map[string]int{StageHttpDrain: 100}}
+	config.SetupDefaults()
+	assert.Equal(t, 100*time.Millisecond, config.Timeout(StageHttpDrain))
+	assert.Equal(t, 5*time.Second, config.Timeout(StageDatabase))
+}
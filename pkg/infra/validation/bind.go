@@ -0,0 +1,135 @@
+package validation
+
+import (
+	"encoding/json"
+	"errors"
+	"github.com/devlibx/go-template-project/pkg/apperror"
+	"github.com/gin-gonic/gin"
+	"github.com/gin-gonic/gin/binding"
+	"io"
+	"reflect"
+	"sync"
+)
+
+// source is the part of a request struct which is read from one part of the request. gin reads a field without a
+// form or header tag by its Go name, and encoding/json does the same for a field without a json tag, so a source is
+// read into view - a struct of only the fields tagged for it - and they are copied to the request struct
+type source struct {
+	view   reflect.Type
+	fields []int // index in the request struct of each field of view
+}
+
+// sources tells which parts of the request a struct type reads, see Bind. A part which is not read is nil
+type sources struct {
+	path, query, header, body *source
+}
+
+var sourcesByType = sync.Map{}
+
+// Bind reads obj from the request and validates it - see Validate. Fields are read by their tags:
+//
+//	type GetOrderRequest struct {
+//		OrderId string `uri:"orderId" validate:"required,uuid"`
+//		Status  string `form:"status" validate:"omitempty,enum=order_status"`
+//		Tenant  string `header:"x-tenant-id" validate:"required"`
+//		Amount  string `json:"amount" validate:"required,decimal_amount=2"`
+//	}
+//
+// A field is only read from the part of its tag e.g. a header named "Amount" does not set Amount. Untagged fields,
+// including embedded structs, are not read. A handler returns the error with c.Error(), which is sent as a 400 problem
+// with the field errors
+func Bind(c *gin.Context, obj interface{}) error {
+	s := sourcesOf(reflect.TypeOf(obj))
+	target := reflect.Indirect(reflect.ValueOf(obj))
+
+	if s.query != nil {
+		if err := s.query.read(target, func(view interface{}) error { return binding.Query.Bind(c.Request, view) }); err != nil {
+			return readError(InQuery, err)
+		}
+	}
+	if s.header != nil {
+		if err := s.header.read(target, func(view interface{}) error { return binding.Header.Bind(c.Request, view) }); err != nil {
+			return readError(InHeader, err)
+		}
+	}
+	if s.path != nil {
+		params := map[string][]string{}
+		for _, p := range c.Params {
+			params[p.Key] = []string{p.Value}
+		}
+		if err := s.path.read(target, func(view interface{}) error { return binding.Uri.BindUri(params, view) }); err != nil {
+			return readError(InPath, err)
+		}
+	}
+	if s.body != nil && c.Request.Body != nil && c.Request.ContentLength != 0 {
+		err := s.body.read(target, func(view interface{}) error { return binding.JSON.Bind(c.Request, view) })
+		if err != nil && !errors.Is(err, io.EOF) {
+			return readError(InBody, err)
+		}
+	}
+	return Validate(obj)
+}
+
+// read fills the fields of the source with the values read into its view. The view starts with the values which the
+// fields already have, so a field which is not in the request keeps its value
+func (s *source) read(target reflect.Value, bind func(view interface{}) error) error {
+	view := reflect.New(s.view).Elem()
+	for i, index := range s.fields {
+		view.Field(i).Set(target.Field(index))
+	}
+	if err := bind(view.Addr().Interface()); err != nil {
+		return err
+	}
+	for i, index := range s.fields {
+		target.Field(index).Set(view.Field(i))
+	}
+	return nil
+}
+
+func sourcesOf(objType reflect.Type) sources {
+	if s, ok := sourcesByType.Load(objType); ok {
+		return s.(sources)
+	}
+
+	s := sources{}
+	t := objType
+	for t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+	if t.Kind() == reflect.Struct {
+		fields := map[string][]reflect.StructField{}
+		indexes := map[string][]int{}
+		for i := 0; i < t.NumField(); i++ {
+			field := t.Field(i)
+			if _, in := fieldName(field); in != "" && field.IsExported() {
+				fields[in] = append(fields[in], reflect.StructField{Name: field.Name, Type: field.Type, Tag: field.Tag})
+				indexes[in] = append(indexes[in], i)
+			}
+		}
+		for in, target := range map[string]**source{InPath: &s.path, InQuery: &s.query, InHeader: &s.header, InBody: &s.body} {
+			if len(fields[in]) > 0 {
+				*target = &source{view: reflect.StructOf(fields[in]), fields: indexes[in]}
+			}
+		}
+	}
+	sourcesByType.Store(objType, s)
+	return s
+}
+
+// readError is the error of a request which could not be read e.g. a number field with "abc" or a body which is not JSON
+func readError(in string, err error) error {
+	var typeErr *json.UnmarshalTypeError
+	if errors.As(err, &typeErr) && typeErr.Field != "" {
+		return apperror.InvalidArgument("validation_failed", "request is not valid").WithFields(apperror.FieldError{
+			Field:   typeErr.Field,
+			In:      in,
+			Code:    "type",
+			Message: "must be a " + typeErr.Type.String(),
+		}).WithCause(err)
+	}
+
+	if in == InBody {
+		return apperror.InvalidArgument("invalid_body", "request body is not valid JSON").WithCause(err)
+	}
+	return apperror.InvalidArgument("invalid_request", "%s of the request could not be read", in).WithCause(err)
+}
@@ -0,0 +1,204 @@
+package validation
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"github.com/devlibx/go-template-project/pkg/apperror"
+	"github.com/go-playground/validator/v10"
+	"reflect"
+	"strings"
+	"sync"
+)
+
+// Where a field of a request is read from - see Bind
+const (
+	InPath   = "path"
+	InQuery  = "query"
+	InHeader = "header"
+	InBody   = "body"
+)
+
+// tags by which a field is read from each part of the request, in the order of lookup for the field name
+var sourceTags = []struct {
+	tag string
+	in  string
+}{
+	{tag: "uri", in: InPath},
+	{tag: "form", in: InQuery},
+	{tag: "header", in: InHeader},
+	{tag: "json", in: InBody},
+}
+
+var (
+	validate = newValidator()
+	enums    = sync.Map{}
+)
+
+func newValidator() *validator.Validate {
+	v := validator.New(validator.WithRequiredStructEnabled())
+	v.RegisterTagNameFunc(func(field reflect.StructField) string {
+		name, _ := fieldName(field)
+		return name
+	})
+	_ = v.RegisterValidation("decimal_amount", isDecimalAmount)
+	_ = v.RegisterValidation("enum", isEnum)
+	return v
+}
+
+// RegisterValidation adds a custom validation tag, it must be called at startup before any request is validated
+func RegisterValidation(tag string, fn validator.Func) error {
+	return validate.RegisterValidation(tag, fn)
+}
+
+// RegisterEnum declares the values of an enum, which are checked with `validate:"enum=<name>"`
+func RegisterEnum(name string, values ...string) {
+	enums.Store(name, values)
+}
+
+// Validate checks the `validate` tags of obj. A failed check is an apperror.InvalidArgument with code
+// "validation_failed" and an apperror.FieldError for each field
+func Validate(obj interface{}) error {
+	err := validate.Struct(obj)
+	if err == nil {
+		return nil
+	}
+
+	var errs validator.ValidationErrors
+	if !errors.As(err, &errs) {
+		return apperror.Internal("validation_error", "request could not be validated").WithCause(err)
+	}
+
+	fields := make([]apperror.FieldError, 0, len(errs))
+	for _, e := range errs {
+		fields = append(fields, apperror.FieldError{
+			Field:   fieldPath(e.Namespace()),
+			In:      fieldIn(reflect.TypeOf(obj), e.StructNamespace()),
+			Code:    e.Tag(),
+			Message: message(e),
+		})
+	}
+	return apperror.InvalidArgument("validation_failed", "request is not valid").WithFields(fields...).WithCause(err)
+}
+
+// fieldName gives the name of the field in the request, and where it is read from
+func fieldName(field reflect.StructField) (string, string) {
+	for _, source := range sourceTags {
+		name, _, _ := strings.Cut(field.Tag.Get(source.tag), ",")
+		if name == "-" {
+			return "", ""
+		} else if name != "" {
+			return name, source.in
+		}
+	}
+	return field.Name, ""
+}
+
+// fieldPath drops the name of the request struct from the namespace e.g. "GetPostRequest.postId" is "postId"
+func fieldPath(namespace string) string {
+	if _, path, found := strings.Cut(namespace, "."); found {
+		return path
+	}
+	return namespace
+}
+
+// fieldIn gives where the top level field of the struct namespace e.g. "GetPostRequest.PostId" is read from
+func fieldIn(t reflect.Type, structNamespace string) string {
+	for t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+	name, _, _ := strings.Cut(fieldPath(structNamespace), ".")
+	name, _, _ = strings.Cut(name, "[")
+	if field, ok := t.FieldByName(name); ok {
+		_, in := fieldName(field)
+		return in
+	}
+	return ""
+}
+
+func message(e validator.FieldError) string {
+	switch e.Tag() {
+	case "required":
+		return "is required"
+	case "uuid", "uuid4":
+		return "must be a UUID"
+	case "number", "numeric":
+		return "must be a number"
+	case "decimal_amount":
+		return fmt.Sprintf("must be a positive amount with at most %d decimals", decimalScale(e.Param()))
+	case "enum":
+		values, _ := enums.Load(e.Param())
+		return fmt.Sprintf("must be one of %v", values)
+	case "oneof":
+		return fmt.Sprintf("must be one of [%s]", e.Param())
+	case "min", "gte":
+		return fmt.Sprintf("must be at least %s", e.Param())
+	case "max", "lte":
+		return fmt.Sprintf("must be at most %s", e.Param())
+	case "gt":
+		return fmt.Sprintf("must be greater than %s", e.Param())
+	case "lt":
+		return fmt.Sprintf("must be less than %s", e.Param())
+	case "len":
+		return fmt.Sprintf("must have length %s", e.Param())
+	case "email":
+		return "must be an email"
+	default:
+		return fmt.Sprintf("failed the %s validation", e.Tag())
+	}
+}
+
+// isDecimalAmount checks a positive decimal string e.g. "10.25" with at most the decimals given as param, 2 by
+// default. A json.Number is checked as its string
+func isDecimalAmount(fl validator.FieldLevel) bool {
+	var value string
+	switch v := fl.Field().Interface().(type) {
+	case string:
+		value = v
+	case json.Number:
+		value = v.String()
+	default:
+		return false
+	}
+
+	whole, fraction, hasFraction := strings.Cut(value, ".")
+	if !isDigits(whole) || (hasFraction && (!isDigits(fraction) || len(fraction) > decimalScale(fl.Param()))) {
+		return false
+	}
+	return strings.Trim(whole+fraction, "0") != ""
+}
+
+func decimalScale(param string) int {
+	var scale int
+	if _, err := fmt.Sscanf(param, "%d", &scale); err != nil || scale < 0 {
+		return 2
+	}
+	return scale
+}
+
+func isDigits(value string) bool {
+	if value == "" {
+		return false
+	}
+	for _, r := range value {
+		if r < '0' || r > '9' {
+			return false
+		}
+	}
+	return true
+}
+
+// isEnum checks the value is one of the values given to RegisterEnum for the enum name in the param
+func isEnum(fl validator.FieldLevel) bool {
+	values, ok := enums.Load(fl.Param())
+	if !ok {
+		return false
+	}
+	value := fl.Field().String()
+	for _, v := range values.([]string) {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
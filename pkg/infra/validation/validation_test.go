@@ -0,0 +1,129 @@
+package validation
+
+import (
+	"github.com/devlibx/go-template-project/pkg/apperror"
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+type createOrderRequest struct {
+	UserId   string `uri:"userId" validate:"required,uuid"`
+	DryRun   bool   `form:"dry_run"`
+	TenantId string `header:"x-tenant-id" validate:"required"`
+	Amount   string `json:"amount" validate:"required,decimal_amount=2"`
+	Status   string `json:"status" validate:"required,enum=order_status"`
+	Qty      int    `json:"qty" validate:"gte=1"`
+	Address  struct {
+		City string `json:"city" validate:"required"`
+	} `json:"address"`
+}
+
+func bind(method string, path string, body string, headers map[string]string) (*createOrderRequest, error) {
+	var request *createOrderRequest
+	var err error
+
+	engine := gin.New()
+	engine.Handle(method, "/user/:userId/order", func(c *gin.Context) {
+		request = &createOrderRequest{}
+		err = Bind(c, request)
+	})
+
+	httpRequest := httptest.NewRequest(method, path, strings.NewReader(body))
+	for k, v := range headers {
+		httpRequest.Header.Set(k, v)
+	}
+	engine.ServeHTTP(httptest.NewRecorder(), httpRequest)
+	return request, err
+}
+
+func fieldsOf(t *testing.T, err error) map[string]apperror.FieldError {
+	e := apperror.From(err)
+	assert.Equal(t, apperror.KindInvalidArgument, e.Kind)
+	fields := map[string]apperror.FieldError{}
+	for _, f := range e.Fields {
+		fields[f.Field] = f
+	}
+	return fields
+}
+
+func TestBind(t *testing.T) {
+	RegisterEnum("order_status", "pending", "paid")
+
+	request, err := bind(http.MethodPost, "/user/0b7c2a1e-93f4-4b8e-9a57-2f0e4f9d1c11/order?dry_run=true",
+		`{"amount": "10.25", "status": "paid", "qty": 2, "address": {"city": "Pune"}}`,
+		map[string]string{"x-tenant-id": "t1"})
+	assert.NoError(t, err)
+	assert.Equal(t, "0b7c2a1e-93f4-4b8e-9a57-2f0e4f9d1c11", request.UserId)
+	assert.True(t, request.DryRun)
+	assert.Equal(t, "t1", request.TenantId)
+	assert.Equal(t, "10.25", request.Amount)
+	assert.Equal(t, 2, request.Qty)
+	assert.Equal(t, "Pune", request.Address.City)
+
+	_, err = bind(http.MethodPost, "/user/abc/order", `{"amount": "10.255", "status": "lost", "qty": 0}`, nil)
+	assert.Equal(t, "validation_failed", apperror.From(err).Code)
+	fields := fieldsOf(t, err)
+	assert.Equal(t, apperror.FieldError{Field: "userId", In: InPath, Code: "uuid", Message: "must be a UUID"}, fields["userId"])
+	assert.Equal(t, apperror.FieldError{Field: "x-tenant-id", In: InHeader, Code: "required", Message: "is required"}, fields["x-tenant-id"])
+	assert.Equal(t, "decimal_amount", fields["amount"].Code)
+	assert.Equal(t, InBody, fields["amount"].In)
+	assert.Equal(t, "must be one of [pending paid]", fields["status"].Message)
+	assert.Equal(t, "gte", fields["qty"].Code)
+	assert.Equal(t, InBody, fields["address.city"].In)
+	assert.Len(t, fields, 6)
+
+	// A body which can not be read
+	_, err = bind(http.MethodPost, "/user/abc/order", `{"qty": "two"}`, nil)
+	assert.Equal(t, apperror.FieldError{Field: "qty", In: InBody, Code: "type", Message: "must be a int"}, fieldsOf(t, err)["qty"])
+	_, err = bind(http.MethodPost, "/user/abc/order", `{`, nil)
+	assert.Equal(t, "invalid_body", apperror.From(err).Code)
+	_, err = bind(http.MethodPost, "/user/abc/order?dry_run=maybe", ``, nil)
+	assert.Equal(t, "invalid_request", apperror.From(err).Code)
+}
+
+func TestBind_OnlyTaggedFields(t *testing.T) {
+	RegisterEnum("order_status", "pending", "paid")
+
+	// Fields are not read by their Go name from a part of the request which they have no tag for
+	request, err := bind(http.MethodPost, "/user/0b7c2a1e-93f4-4b8e-9a57-2f0e4f9d1c11/order?Amount=99.00&Status=pending&TenantId=q1",
+		`{"amount": "10.25", "status": "paid", "qty": 2, "address": {"city": "Pune"}, "TenantId": "b1", "UserId": "b2", "DryRun": true}`,
+		map[string]string{"x-tenant-id": "t1", "Amount": "98.00", "Qty": "3"})
+	assert.NoError(t, err)
+	assert.Equal(t, "0b7c2a1e-93f4-4b8e-9a57-2f0e4f9d1c11", request.UserId)
+	assert.False(t, request.DryRun)
+	assert.Equal(t, "t1", request.TenantId)
+	assert.Equal(t, "10.25", request.Amount)
+	assert.Equal(t, "paid", request.Status)
+	assert.Equal(t, 2, request.Qty)
+
+	// A value which is set before Bind is kept if the request does not have the field
+	engine := gin.New()
+	engine.GET("/orders", func(c *gin.Context) {
+		request := &struct {
+			Limit  int    `form:"limit"`
+			Tenant string `header:"x-tenant-id"`
+		}{Limit: 10, Tenant: "default"}
+		assert.NoError(t, Bind(c, request))
+		assert.Equal(t, 10, request.Limit)
+		assert.Equal(t, "t1", request.Tenant)
+	})
+	httpRequest := httptest.NewRequest(http.MethodGet, "/orders", nil)
+	httpRequest.Header.Set("x-tenant-id", "t1")
+	engine.ServeHTTP(httptest.NewRecorder(), httpRequest)
+}
+
+func TestDecimalAmount(t *testing.T) {
+	type amount struct {
+		Value string `json:"value" validate:"decimal_amount"`
+		Rate  string `json:"rate" validate:"omitempty,decimal_amount=4"`
+	}
+	for value, ok := range map[string]bool{"1": true, "0.01": true, "10.25": true, "10.": false, ".5": false, "0": false, "0.00": false, "-1": false, "1.234": false, "1e3": false, "": false} {
+		assert.Equal(t, ok, Validate(&amount{Value: value}) == nil, value)
+	}
+	assert.NoError(t, Validate(&amount{Value: "1", Rate: "0.1234"}))
+	assert.Error(t, Validate(&amount{Value: "1", Rate: "0.12345"}))
+}
@@ -2,8 +2,10 @@ package post
 
 import (
 	"context"
+	"github.com/devlibx/go-template-project/pkg/apperror"
 	jsonplaceholderClient "github.com/devlibx/go-template-project/pkg/clients/jsonplaceholder"
 	"github.com/devlibx/gox-base/v2"
+	"strconv"
 )
 
 type postServiceImpl struct {
@@ -11,6 +13,10 @@ type postServiceImpl struct {
 }
 
 func (p *postServiceImpl) GetPost(ctx context.Context, postId string) (gox.StringObjectMap, error) {
+	if id, err := strconv.Atoi(postId); err != nil || id <= 0 {
+		return nil, apperror.InvalidArgument("invalid_post_id", "post id must be a positive number")
+	}
+
 	if post, err := p.postClient.GetPosts(ctx, postId); err == nil {
 		return gox.StringObjectMap{"id": post.Id}, nil
 	} else {
@@ -3,11 +3,15 @@ package user
 import (
 	"context"
 	userModels "github.com/devlibx/go-template-project/pkg/database/user"
+	"github.com/devlibx/go-template-project/pkg/infra/pagination"
 )
 
 type Service interface {
 	CreateUser(ctx context.Context, req userModels.CreateUserRequest) error
 	GetUserByID(ctx context.Context, userID string) (*userModels.User, error)
+	ListUsers(ctx context.Context, page pagination.Request) (*pagination.Page[*userModels.User], error)
+
+	// Deprecated: GetAllUsers reads all users into memory, use ListUsers
 	GetAllUsers(ctx context.Context) ([]*userModels.User, error)
 	UpdateUser(ctx context.Context, userID string, req userModels.UpdateUserRequest) error
 	DeleteUser(ctx context.Context, userID string) error
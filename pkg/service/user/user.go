@@ -3,6 +3,7 @@ package user
 import (
 	"context"
 	userModels "github.com/devlibx/go-template-project/pkg/database/user"
+	"github.com/devlibx/go-template-project/pkg/infra/pagination"
 	"github.com/devlibx/gox-base/v2"
 )
 
@@ -19,6 +20,10 @@ func (u *userServiceImpl) GetUserByID(ctx context.Context, userID string) (*user
 	return u.userDataStore.GetUserByID(ctx, userID)
 }
 
+func (u *userServiceImpl) ListUsers(ctx context.Context, page pagination.Request) (*pagination.Page[*userModels.User], error) {
+	return u.userDataStore.ListUsers(ctx, page)
+}
+
 func (u *userServiceImpl) GetAllUsers(ctx context.Context) ([]*userModels.User, error) {
 	return u.userDataStore.GetAllUsers(ctx)
 }
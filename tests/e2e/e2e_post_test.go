@@ -2,6 +2,7 @@ package e2e
 
 import (
 	"fmt"
+	"github.com/devlibx/go-template-project/pkg/apperror"
 	"github.com/devlibx/gox-base/v2"
 	"github.com/devlibx/gox-base/v2/serialization"
 	"github.com/zeebo/assert"
@@ -21,4 +22,20 @@ func (s *e2eTestSuite) TestPostApi() {
 		fmt.Println("Get Post - Success Result\n", respMap.JsonPrettyStringIgnoreError())
 		assert.Equal(t, 1, respMap.IntOrZero("id"))
 	})
+
+	s.T().Run("Get Post - Invalid Id", func(t *testing.T) {
+		resp, err := s.restyClient.R().
+			SetHeader("Content-Type", "application/json").
+			Get("/post/abc")
+		assert.NoError(t, err)
+		assert.Equal(t, 400, resp.StatusCode())
+		assert.Equal(t, "application/problem+json", resp.Header().Get("Content-Type"))
+		problem := apperror.Problem{}
+		err = serialization.JsonBytesToObject(resp.Body(), &problem)
+		assert.NoError(t, err)
+		assert.Equal(t, "validation_failed", problem.Code)
+		assert.Equal(t, 1, len(problem.Errors))
+		assert.Equal(t, "postId", problem.Errors[0].Field)
+		assert.Equal(t, "path", problem.Errors[0].In)
+	})
 }
@@ -11,6 +11,7 @@ import (
 	"github.com/stretchr/testify/suite"
 	"github.com/zeebo/assert"
 	"gopkg.in/resty.v1"
+	"net"
 	"os"
 	"testing"
 	"time"
@@ -28,6 +29,10 @@ type e2eTestSuite struct {
 func (s *e2eTestSuite) SetupSuite() {
 	env.SetupE2ETestEnv(map[string]string{}, env.DefaultEnvSetupFunc())
 
+	// Let the servers pick free ports - the bound addresses are in applicationContext once they are started
+	_ = os.Setenv("HTTP_PORT", "0")
+	_ = os.Setenv("ADMIN_HTTP_PORT", "0")
+
 	// Setup random ports for testing - you can simulate TEST service on this port
 	go func() {
 		mapping, err := httpHelper.AllocateFreePortsAndAssignToEnvironmentVariables("TEST_SERVICE", "")
@@ -41,7 +46,6 @@ func (s *e2eTestSuite) SetupSuite() {
 	// Setup resty client
 	httpCommand.EnableRestyDebug = true
 	s.restyClient = resty.New()
-	s.restyClient.HostURL = fmt.Sprintf("http://localhost:%s/%s/api/v1", os.Getenv("HTTP_PORT"), os.Getenv("APP_NAME"))
 	s.restyClient.Debug = true
 	s.restyClient.SetHeader("x-client-id", os.Getenv("CLIENT_ID"))
 	s.restyClient.SetHeader("x-access-token", os.Getenv("CLIENT_TOKEN"))
@@ -53,6 +57,7 @@ func (s *e2eTestSuite) SetupSuite() {
 		command.FullMain(s.ctx, ch, s.applicationContext)
 	}()
 	<-ch
+	s.restyClient.HostURL = fmt.Sprintf("http://localhost:%d/%s/api/v1", s.applicationContext.ServerAddr.(*net.TCPAddr).Port, os.Getenv("APP_NAME"))
 }
 
 func (s *e2eTestSuite) TearDownSuite() {